@@ -0,0 +1,156 @@
+package audio
+
+import (
+	"math"
+)
+
+// SampleProducer - sound source producing new sample on every clock (e.g. APU)
+type SampleProducer interface {
+	Output() float32
+}
+
+// SampleSink - consumer of resampled audio
+type SampleSink interface {
+	WriteSamples(samples []float32) error
+}
+
+const (
+	// Number of output samples each band-limited step is spread over
+	kernelTaps = 16
+
+	// Number of fractional positions between output samples
+	kernelPhases = 32
+
+	// Cutoff frequency relative to output sample rate
+	kernelCutoff = 0.45
+)
+
+// Resampler - converts high rate input (one sample per clock) to host sample rate using band-limited synthesis.
+// Instead of sampling input directly, each change of input amplitude is added to output as band-limited step,
+// which removes aliasing of frequencies above Nyquist frequency of the output.
+// http://www.slack.net/~ant/bl-synth/
+type Resampler struct {
+	producer SampleProducer
+
+	// Output samples per single input clock
+	step float64
+
+	// Current time in output samples, relative to start of the buffer
+	time float64
+
+	lastInput  float32
+	integrator float32
+	deltas     []float32
+	kernel     [kernelPhases][kernelTaps]float32
+
+	// DC blocking filter state
+	lastRaw      float32
+	lastFiltered float32
+}
+
+func NewResampler(producer SampleProducer, clockRate float64, sampleRate int) *Resampler {
+	r := &Resampler{
+		producer: producer,
+		step:     float64(sampleRate) / clockRate,
+		deltas:   make([]float32, sampleRate/10+kernelTaps),
+	}
+
+	r.createKernel()
+
+	return r
+}
+
+// Impulse response of low pass filter (Blackman windowed sinc) for each fractional phase,
+// normalized so whole step is always added to the output.
+func (r *Resampler) createKernel() {
+	for phase := 0; phase < kernelPhases; phase++ {
+		frac := float64(phase) / kernelPhases
+		sum := 0.0
+		values := [kernelTaps]float64{}
+
+		for tap := 0; tap < kernelTaps; tap++ {
+			x := float64(tap) - float64(kernelTaps-1)/2 - frac
+			sinc := 1.0
+
+			if x != 0 {
+				sinc = math.Sin(2*math.Pi*kernelCutoff*x) / (2 * math.Pi * kernelCutoff * x)
+			}
+
+			n := (float64(tap) - frac) / (kernelTaps - 1)
+			window := 0.42 - 0.5*math.Cos(2*math.Pi*n) + 0.08*math.Cos(4*math.Pi*n)
+
+			values[tap] = sinc * window
+			sum += values[tap]
+		}
+
+		for tap := 0; tap < kernelTaps; tap++ {
+			r.kernel[phase][tap] = float32(values[tap] / sum)
+		}
+	}
+}
+
+// Clock - reads single sample from producer, should be called at producer's clock rate
+func (r *Resampler) Clock() {
+	r.AddSample(r.producer.Output())
+}
+
+// AddSample - adds single input sample
+func (r *Resampler) AddSample(value float32) {
+	delta := value - r.lastInput
+
+	if delta != 0 {
+		r.lastInput = value
+
+		position := int(r.time)
+		phase := int((r.time - float64(position)) * kernelPhases)
+
+		r.growBuffer(position + kernelTaps)
+
+		for tap, k := range r.kernel[phase] {
+			r.deltas[position+tap] += delta * k
+		}
+	}
+
+	r.time += r.step
+}
+
+func (r *Resampler) growBuffer(size int) {
+	if size > len(r.deltas) {
+		r.deltas = append(r.deltas, make([]float32, size-len(r.deltas))...)
+	}
+}
+
+// GetAvailableSamples - number of output samples that will not change anymore
+func (r *Resampler) GetAvailableSamples() int {
+	return int(r.time)
+}
+
+// ReadSamples - reads up to len(out) samples, returns number of samples read
+func (r *Resampler) ReadSamples(out []float32) int {
+	count := r.GetAvailableSamples()
+
+	if count > len(out) {
+		count = len(out)
+	}
+
+	r.growBuffer(count)
+
+	for i := 0; i < count; i++ {
+		r.integrator += r.deltas[i]
+
+		// Remove DC offset - NES output is always positive
+		r.lastFiltered = r.integrator - r.lastRaw + 0.995*r.lastFiltered
+		r.lastRaw = r.integrator
+		out[i] = r.lastFiltered
+	}
+
+	// Move remaining deltas to the start of the buffer
+	remaining := copy(r.deltas, r.deltas[count:])
+	for i := remaining; i < len(r.deltas); i++ {
+		r.deltas[i] = 0
+	}
+
+	r.time -= float64(count)
+
+	return count
+}
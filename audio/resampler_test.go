@@ -0,0 +1,69 @@
+package audio_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/audio"
+	"math"
+	"testing"
+)
+
+type squareWave struct {
+	period int
+	clock  int
+}
+
+func (s *squareWave) Output() float32 {
+	s.clock++
+
+	if (s.clock/(s.period/2))%2 == 0 {
+		return 0.5
+	}
+
+	return 0
+}
+
+func TestResamplerSampleCount(t *testing.T) {
+	a := assert.New(t)
+	r := audio.NewResampler(&squareWave{period: 1000}, 1789773, 48000)
+	out := make([]float32, 100000)
+	total := 0
+
+	// One second of input, read in chunks of one frame
+	for frame := 0; frame < 60; frame++ {
+		for i := 0; i < 1789773/60; i++ {
+			r.Clock()
+		}
+
+		total += r.ReadSamples(out)
+	}
+
+	a.InDelta(48000, total, 5, "Resampler should produce output at host sample rate")
+}
+
+func TestResamplerAmplitude(t *testing.T) {
+	a := assert.New(t)
+	r := audio.NewResampler(&squareWave{period: 1789773 / 440}, 1789773, 44100)
+	out := make([]float32, 44100)
+
+	for i := 0; i < 1789773; i++ {
+		r.Clock()
+	}
+
+	n := r.ReadSamples(out)
+	min, max := float32(math.MaxFloat32), float32(-math.MaxFloat32)
+
+	// Skip filter settling time
+	for _, v := range out[n/2 : n] {
+		if v < min {
+			min = v
+		}
+
+		if v > max {
+			max = v
+		}
+	}
+
+	// Band limiting adds some ringing on edges
+	a.InDelta(0.6, max-min, 0.15, "Peak to peak amplitude of square wave should be preserved")
+	a.InDelta(0, (max+min)/2, 0.05, "DC offset should be removed")
+}
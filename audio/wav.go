@@ -0,0 +1,102 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// http://soundfile.sapp.org/doc/WaveFormat/
+type wavHeader struct {
+	ChunkID       [4]uint8
+	ChunkSize     uint32
+	Format        [4]uint8
+	Subchunk1ID   [4]uint8
+	Subchunk1Size uint32
+	AudioFormat   uint16
+	NumChannels   uint16
+	SampleRate    uint32
+	ByteRate      uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+	Subchunk2ID   [4]uint8
+	Subchunk2Size uint32
+}
+
+const wavHeaderSize = 44
+
+// WAVWriter - writes mono 16 bit PCM WAV file. Sizes in header are updated on Close.
+type WAVWriter struct {
+	w          io.WriteSeeker
+	sampleRate int
+	dataSize   uint32
+	data       []byte
+}
+
+func NewWAVWriter(w io.WriteSeeker, sampleRate int) (*WAVWriter, error) {
+	wav := &WAVWriter{w: w, sampleRate: sampleRate}
+
+	// Placeholder header - will be overwritten on close when data size is known
+	err := wav.writeHeader()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return wav, nil
+}
+
+func (wav *WAVWriter) writeHeader() error {
+	header := wavHeader{
+		ChunkID:       [4]uint8{'R', 'I', 'F', 'F'},
+		ChunkSize:     wavHeaderSize - 8 + wav.dataSize,
+		Format:        [4]uint8{'W', 'A', 'V', 'E'},
+		Subchunk1ID:   [4]uint8{'f', 'm', 't', ' '},
+		Subchunk1Size: 16,
+		AudioFormat:   1,
+		NumChannels:   1,
+		SampleRate:    uint32(wav.sampleRate),
+		ByteRate:      uint32(wav.sampleRate) * 2,
+		BlockAlign:    2,
+		BitsPerSample: 16,
+		Subchunk2ID:   [4]uint8{'d', 'a', 't', 'a'},
+		Subchunk2Size: wav.dataSize,
+	}
+
+	return binary.Write(wav.w, binary.LittleEndian, &header)
+}
+
+// WriteSamples - appends samples, values are clamped to -1.0 - 1.0 range
+func (wav *WAVWriter) WriteSamples(samples []float32) error {
+	wav.data = wav.data[:0]
+
+	for _, s := range samples {
+		v := math.Max(-1, math.Min(1, float64(s)))
+		wav.data = append(wav.data, 0, 0)
+		binary.LittleEndian.PutUint16(wav.data[len(wav.data)-2:], uint16(int16(v*math.MaxInt16)))
+	}
+
+	n, err := wav.w.Write(wav.data)
+	wav.dataSize += uint32(n)
+
+	return err
+}
+
+// Close - updates header with final sizes. Underlying writer is not closed.
+func (wav *WAVWriter) Close() error {
+	_, err := wav.w.Seek(0, io.SeekStart)
+
+	if err != nil {
+		return err
+	}
+
+	err = wav.writeHeader()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = wav.w.Seek(0, io.SeekEnd)
+
+	return err
+}
@@ -0,0 +1,40 @@
+package audio_test
+
+import (
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/audio"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestWAVWriter(t *testing.T) {
+	a := assert.New(t)
+	f, err := ioutil.TempFile("", "nesgo-*.wav")
+	a.NoError(err)
+	defer os.Remove(f.Name())
+
+	wav, err := audio.NewWAVWriter(f, 44100)
+	a.NoError(err)
+	a.NoError(wav.WriteSamples([]float32{0, 1, -1}))
+	a.NoError(wav.WriteSamples([]float32{2, 0.5}))
+	a.NoError(wav.Close())
+	a.NoError(f.Close())
+
+	data, err := ioutil.ReadFile(f.Name())
+	a.NoError(err)
+	a.Len(data, 44+5*2, "File should contain header and 16 bit samples")
+	a.Equal("RIFF", string(data[0:4]))
+	a.Equal(uint32(36+10), binary.LittleEndian.Uint32(data[4:8]), "RIFF chunk size should be updated")
+	a.Equal("WAVE", string(data[8:12]))
+	a.Equal(uint32(44100), binary.LittleEndian.Uint32(data[24:28]), "Sample rate should be stored")
+	a.Equal(uint32(10), binary.LittleEndian.Uint32(data[40:44]), "Data chunk size should be updated")
+
+	samples := make([]int16, 5)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(data[44+i*2:]))
+	}
+
+	a.Equal([]int16{0, 32767, -32767, 32767, 16383}, samples, "Samples should be converted and clamped")
+}
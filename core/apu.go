@@ -0,0 +1,254 @@
+package core
+
+import (
+	"github.com/szymonkups/nesgo/core/apu"
+	"github.com/szymonkups/nesgo/core/state"
+)
+
+// APU - audio processing unit of 2A03, registers are exposed on CPU bus under $4000-$4017
+// https://wiki.nesdev.com/w/index.php/APU
+type APU struct {
+	bus *bus
+	cpu *CPU
+
+	pulse1   *apu.Pulse
+	pulse2   *apu.Pulse
+	triangle *apu.Triangle
+	noise    *apu.Noise
+	dmc      *apu.DMC
+	mixer    *apu.Mixer
+
+	frameCounter *apu.FrameCounter
+
+	// Famicom cartridges can add their own sound channels
+	crt *Cartridge
+
+	// CPU cycles since power up, pulse timers are clocked every other cycle
+	cycles uint64
+}
+
+func NewAPU(bus *bus, cpu *CPU) *APU {
+	return &APU{
+		bus:      bus,
+		cpu:      cpu,
+		pulse1:   apu.NewPulse(true),
+		pulse2:   apu.NewPulse(false),
+		triangle: new(apu.Triangle),
+		noise:    apu.NewNoise(),
+		dmc:      apu.NewDMC(),
+		mixer:    apu.NewMixer(),
+
+		frameCounter: new(apu.FrameCounter),
+	}
+}
+
+// ConnectCartridge - expansion audio of cartridge will be mixed with APU channels
+func (apu *APU) ConnectCartridge(crt *Cartridge) {
+	apu.crt = crt
+}
+
+func (apu *APU) Serialize(w *state.Writer) {
+	apu.pulse1.Serialize(w)
+	apu.pulse2.Serialize(w)
+	apu.triangle.Serialize(w)
+	apu.noise.Serialize(w)
+	apu.dmc.Serialize(w)
+	apu.frameCounter.Serialize(w)
+	w.Uint64(apu.cycles)
+}
+
+func (apu *APU) Deserialize(r *state.Reader) {
+	apu.pulse1.Deserialize(r)
+	apu.pulse2.Deserialize(r)
+	apu.triangle.Deserialize(r)
+	apu.noise.Deserialize(r)
+	apu.dmc.Deserialize(r)
+	apu.frameCounter.Deserialize(r)
+	apu.cycles = r.Uint64()
+}
+
+func (apu *APU) Read(_ string, addr uint16, debug bool) (uint8, bool) {
+	if addr != 0x4015 {
+		return 0x00, false
+	}
+
+	// Status - IF-D NT21
+	status := uint8(0)
+
+	if apu.pulse1.IsActive() {
+		status |= 0b00000001
+	}
+
+	if apu.pulse2.IsActive() {
+		status |= 0b00000010
+	}
+
+	if apu.triangle.IsActive() {
+		status |= 0b00000100
+	}
+
+	if apu.noise.IsActive() {
+		status |= 0b00001000
+	}
+
+	if apu.dmc.IsActive() {
+		status |= 0b00010000
+	}
+
+	if apu.frameCounter.GetIRQFlag() {
+		status |= 0b01000000
+	}
+
+	if apu.dmc.GetIRQFlag() {
+		status |= 0b10000000
+	}
+
+	// Reading status acknowledges frame interrupt
+	if !debug {
+		apu.frameCounter.AcknowledgeIRQ()
+		apu.updateIRQ()
+	}
+
+	return status, true
+}
+
+func (apu *APU) Write(_ string, addr uint16, data uint8, debug bool) bool {
+	if debug {
+		return addr >= 0x4000 && addr <= 0x4013 || addr == 0x4015 || addr == 0x4017
+	}
+
+	switch addr {
+	case 0x4000:
+		apu.pulse1.WriteControl(data)
+	case 0x4001:
+		apu.pulse1.WriteSweep(data)
+	case 0x4002:
+		apu.pulse1.WriteTimerLow(data)
+	case 0x4003:
+		apu.pulse1.WriteTimerHigh(data)
+
+	case 0x4004:
+		apu.pulse2.WriteControl(data)
+	case 0x4005:
+		apu.pulse2.WriteSweep(data)
+	case 0x4006:
+		apu.pulse2.WriteTimerLow(data)
+	case 0x4007:
+		apu.pulse2.WriteTimerHigh(data)
+
+	case 0x4008:
+		apu.triangle.WriteControl(data)
+	case 0x4009:
+		// Unused
+	case 0x400A:
+		apu.triangle.WriteTimerLow(data)
+	case 0x400B:
+		apu.triangle.WriteTimerHigh(data)
+
+	case 0x400C:
+		apu.noise.WriteControl(data)
+	case 0x400D:
+		// Unused
+	case 0x400E:
+		apu.noise.WritePeriod(data)
+	case 0x400F:
+		apu.noise.WriteLength(data)
+
+	case 0x4010:
+		apu.dmc.WriteControl(data)
+	case 0x4011:
+		apu.dmc.WriteDirectLoad(data)
+	case 0x4012:
+		apu.dmc.WriteSampleAddress(data)
+	case 0x4013:
+		apu.dmc.WriteSampleLength(data)
+
+	case 0x4015:
+		// Enable channels - ---D NT21
+		apu.pulse1.SetEnabled(data&0b00000001 != 0)
+		apu.pulse2.SetEnabled(data&0b00000010 != 0)
+		apu.triangle.SetEnabled(data&0b00000100 != 0)
+		apu.noise.SetEnabled(data&0b00001000 != 0)
+		apu.dmc.SetEnabled(data&0b00010000 != 0)
+		apu.updateIRQ()
+
+	case 0x4017:
+		// Frame counter - $4017 reads belong to second controller port
+		apu.frameCounter.Write(data, apu.cycles%2 == 1)
+		apu.updateIRQ()
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// Clock - should be called on every CPU cycle
+func (apu *APU) Clock() {
+	apu.triangle.ClockTimer()
+	apu.noise.ClockTimer()
+	apu.dmc.ClockTimer()
+
+	quarter, half := apu.frameCounter.Clock()
+
+	if quarter {
+		apu.clockQuarterFrame()
+	}
+
+	if half {
+		apu.clockHalfFrame()
+	}
+
+	if apu.cycles%2 == 1 {
+		apu.pulse1.ClockTimer()
+		apu.pulse2.ClockTimer()
+	}
+
+	// DMC memory reader fetches next sample byte, stalling CPU for up to 4 cycles
+	if addr, ok := apu.dmc.GetPendingRead(); ok {
+		apu.cpu.Stall(4)
+		apu.dmc.FillSampleBuffer(apu.bus.Read(addr))
+	}
+
+	apu.updateIRQ()
+	apu.cycles++
+}
+
+// Frame counter and DMC hold CPU IRQ line until their flags are cleared
+func (apu *APU) updateIRQ() {
+	apu.cpu.SetIRQ(IRQSourceFrameCounter, apu.frameCounter.GetIRQFlag())
+	apu.cpu.SetIRQ(IRQSourceDMC, apu.dmc.GetIRQFlag())
+}
+
+func (apu *APU) clockQuarterFrame() {
+	apu.pulse1.ClockQuarterFrame()
+	apu.pulse2.ClockQuarterFrame()
+	apu.triangle.ClockQuarterFrame()
+	apu.noise.ClockQuarterFrame()
+}
+
+func (apu *APU) clockHalfFrame() {
+	apu.pulse1.ClockHalfFrame()
+	apu.pulse2.ClockHalfFrame()
+	apu.triangle.ClockHalfFrame()
+	apu.noise.ClockHalfFrame()
+}
+
+// Output - current mixed output level, 1.0 is APU maximum which can be exceeded with expansion audio,
+// one sample per CPU cycle
+func (apu *APU) Output() float32 {
+	output := apu.mixer.Mix(
+		apu.pulse1.Output(),
+		apu.pulse2.Output(),
+		apu.triangle.Output(),
+		apu.noise.Output(),
+		apu.dmc.Output(),
+	)
+
+	if apu.crt != nil {
+		output += apu.crt.AudioOutput()
+	}
+
+	return output
+}
@@ -0,0 +1,196 @@
+package apu
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// https://wiki.nesdev.com/w/index.php/APU_DMC
+// Rates in CPU cycles (NTSC)
+var dmcRateTable = [16]uint16{
+	428, 380, 340, 320, 286, 254, 226, 214, 190, 160, 142, 128, 106, 84, 72, 54,
+}
+
+type DMC struct {
+	irqEnabled bool
+	irqFlag    bool
+	loop       bool
+
+	timerPeriod uint16
+	timerValue  uint16
+
+	// Memory reader
+	sampleAddress  uint16
+	sampleLength   uint16
+	currentAddress uint16
+	bytesRemaining uint16
+	sampleBuffer   uint8
+	bufferEmpty    bool
+
+	// Output unit
+	shiftRegister uint8
+	bitsRemaining uint8
+	silence       bool
+	level         uint8
+}
+
+func NewDMC() *DMC {
+	return &DMC{
+		timerPeriod:   dmcRateTable[0],
+		bufferEmpty:   true,
+		silence:       true,
+		bitsRemaining: 8,
+	}
+}
+
+// WriteControl - $4010 IL-- RRRR
+func (d *DMC) WriteControl(data uint8) {
+	d.irqEnabled = data&0b10000000 != 0
+	d.loop = data&0b01000000 != 0
+	d.timerPeriod = dmcRateTable[data&0b00001111]
+
+	if !d.irqEnabled {
+		d.irqFlag = false
+	}
+}
+
+// WriteDirectLoad - $4011 -DDD DDDD
+func (d *DMC) WriteDirectLoad(data uint8) {
+	d.level = data & 0b01111111
+}
+
+// WriteSampleAddress - $4012 AAAA AAAA, sample address is %11AAAAAA.AA000000
+func (d *DMC) WriteSampleAddress(data uint8) {
+	d.sampleAddress = 0xC000 | uint16(data)<<6
+}
+
+// WriteSampleLength - $4013 LLLL LLLL, sample length is %LLLL.LLLL0001
+func (d *DMC) WriteSampleLength(data uint8) {
+	d.sampleLength = uint16(data)<<4 | 1
+}
+
+// SetEnabled - bit 4 of $4015. Writing to $4015 also clears DMC interrupt flag.
+func (d *DMC) SetEnabled(enabled bool) {
+	d.irqFlag = false
+
+	if !enabled {
+		d.bytesRemaining = 0
+	} else if d.bytesRemaining == 0 {
+		d.restart()
+	}
+}
+
+func (d *DMC) IsActive() bool {
+	return d.bytesRemaining > 0
+}
+
+func (d *DMC) GetIRQFlag() bool {
+	return d.irqFlag
+}
+
+func (d *DMC) restart() {
+	d.currentAddress = d.sampleAddress
+	d.bytesRemaining = d.sampleLength
+}
+
+// GetPendingRead - returns address of the next sample byte if memory reader needs to fetch one.
+func (d *DMC) GetPendingRead() (uint16, bool) {
+	return d.currentAddress, d.bufferEmpty && d.bytesRemaining > 0
+}
+
+// FillSampleBuffer - provides sample byte fetched from the address returned by GetPendingRead
+func (d *DMC) FillSampleBuffer(data uint8) {
+	d.sampleBuffer = data
+	d.bufferEmpty = false
+
+	// Address wraps around to $8000
+	if d.currentAddress == 0xFFFF {
+		d.currentAddress = 0x8000
+	} else {
+		d.currentAddress++
+	}
+
+	d.bytesRemaining--
+
+	if d.bytesRemaining == 0 {
+		if d.loop {
+			d.restart()
+		} else if d.irqEnabled {
+			d.irqFlag = true
+		}
+	}
+}
+
+// ClockTimer - DMC timer is clocked every CPU cycle
+func (d *DMC) ClockTimer() {
+	if d.timerValue > 0 {
+		d.timerValue--
+		return
+	}
+
+	d.timerValue = d.timerPeriod - 1
+
+	if !d.silence {
+		if d.shiftRegister&0x01 != 0 {
+			if d.level <= 125 {
+				d.level += 2
+			}
+		} else if d.level >= 2 {
+			d.level -= 2
+		}
+
+		d.shiftRegister >>= 1
+	}
+
+	d.bitsRemaining--
+
+	// New output cycle
+	if d.bitsRemaining == 0 {
+		d.bitsRemaining = 8
+
+		if d.bufferEmpty {
+			d.silence = true
+		} else {
+			d.silence = false
+			d.shiftRegister = d.sampleBuffer
+			d.bufferEmpty = true
+		}
+	}
+}
+
+func (d *DMC) Output() uint8 {
+	return d.level
+}
+
+func (d *DMC) Serialize(w *state.Writer) {
+	w.Bool(d.irqEnabled)
+	w.Bool(d.irqFlag)
+	w.Bool(d.loop)
+	w.Uint16(d.timerPeriod)
+	w.Uint16(d.timerValue)
+	w.Uint16(d.sampleAddress)
+	w.Uint16(d.sampleLength)
+	w.Uint16(d.currentAddress)
+	w.Uint16(d.bytesRemaining)
+	w.Uint8(d.sampleBuffer)
+	w.Bool(d.bufferEmpty)
+	w.Uint8(d.shiftRegister)
+	w.Uint8(d.bitsRemaining)
+	w.Bool(d.silence)
+	w.Uint8(d.level)
+}
+
+func (d *DMC) Deserialize(r *state.Reader) {
+	d.irqEnabled = r.Bool()
+	d.irqFlag = r.Bool()
+	d.loop = r.Bool()
+	d.timerPeriod = r.Uint16()
+	d.timerValue = r.Uint16()
+	d.sampleAddress = r.Uint16()
+	d.sampleLength = r.Uint16()
+	d.currentAddress = r.Uint16()
+	d.bytesRemaining = r.Uint16()
+	d.sampleBuffer = r.Uint8()
+	d.bufferEmpty = r.Bool()
+	d.shiftRegister = r.Uint8()
+	d.bitsRemaining = r.Uint8()
+	d.silence = r.Bool()
+	d.level = r.Uint8()
+}
@@ -0,0 +1,62 @@
+package apu_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/apu"
+	"testing"
+)
+
+func TestDMCSampleAddressWrap(t *testing.T) {
+	a := assert.New(t)
+	d := apu.NewDMC()
+
+	// Sample at $FFC0, 65 bytes long
+	d.WriteControl(0b10000000)
+	d.WriteSampleAddress(0xFF)
+	d.WriteSampleLength(0x04)
+	d.SetEnabled(true)
+
+	addr, ok := d.GetPendingRead()
+	a.True(ok, "Memory reader should fetch first byte after channel is enabled")
+	a.Equal(uint16(0xFFC0), addr)
+
+	for i := 0; i < 64; i++ {
+		d.FillSampleBuffer(0)
+
+		// Let output unit empty sample buffer
+		for {
+			if _, ok := d.GetPendingRead(); ok {
+				break
+			}
+
+			d.ClockTimer()
+		}
+	}
+
+	addr, ok = d.GetPendingRead()
+	a.True(ok)
+	a.Equal(uint16(0x8000), addr, "Address should wrap from $FFFF to $8000")
+	a.False(d.GetIRQFlag(), "IRQ should not be set before last byte is fetched")
+
+	d.FillSampleBuffer(0)
+	a.False(d.IsActive(), "Sample should end after 65 bytes")
+	a.True(d.GetIRQFlag(), "IRQ should be set when sample ends")
+
+	d.SetEnabled(false)
+	a.False(d.GetIRQFlag(), "Writing $4015 should clear IRQ flag")
+}
+
+func TestDMCLoop(t *testing.T) {
+	a := assert.New(t)
+	d := apu.NewDMC()
+
+	// Looped sample with IRQ enabled, 1 byte at $C000
+	d.WriteControl(0b11000000)
+	d.WriteSampleAddress(0x00)
+	d.WriteSampleLength(0x00)
+	d.SetEnabled(true)
+	d.FillSampleBuffer(0)
+
+	a.True(d.IsActive(), "Looped sample should restart")
+	a.False(d.GetIRQFlag(), "Looped sample should not set IRQ")
+}
@@ -0,0 +1,67 @@
+package apu
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// https://wiki.nesdev.com/w/index.php/APU_Envelope
+type envelope struct {
+	start          bool
+	loop           bool
+	constantVolume bool
+	volume         uint8
+	divider        uint8
+	decay          uint8
+}
+
+func (e *envelope) write(data uint8) {
+	e.loop = data&0b00100000 != 0
+	e.constantVolume = data&0b00010000 != 0
+	e.volume = data & 0b00001111
+}
+
+func (e *envelope) clock() {
+	if e.start {
+		e.start = false
+		e.decay = 15
+		e.divider = e.volume
+		return
+	}
+
+	if e.divider > 0 {
+		e.divider--
+		return
+	}
+
+	e.divider = e.volume
+
+	if e.decay > 0 {
+		e.decay--
+	} else if e.loop {
+		e.decay = 15
+	}
+}
+
+func (e *envelope) output() uint8 {
+	if e.constantVolume {
+		return e.volume
+	}
+
+	return e.decay
+}
+
+func (e *envelope) serialize(w *state.Writer) {
+	w.Bool(e.start)
+	w.Bool(e.loop)
+	w.Bool(e.constantVolume)
+	w.Uint8(e.volume)
+	w.Uint8(e.divider)
+	w.Uint8(e.decay)
+}
+
+func (e *envelope) deserialize(r *state.Reader) {
+	e.start = r.Bool()
+	e.loop = r.Bool()
+	e.constantVolume = r.Bool()
+	e.volume = r.Uint8()
+	e.divider = r.Uint8()
+	e.decay = r.Uint8()
+}
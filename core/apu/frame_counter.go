@@ -0,0 +1,125 @@
+package apu
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// https://wiki.nesdev.com/w/index.php/APU_Frame_Counter
+// Step timings in CPU cycles (NTSC)
+const (
+	frameStep1        = 7457
+	frameStep2        = 14913
+	frameStep3        = 22371
+	frameStep4        = 29829
+	frameFourStepEnd  = 29830
+	frameStep5        = 37281
+	frameFiveStepEnd  = 37282
+	frameResetDelayLo = 3
+	frameResetDelayHi = 4
+)
+
+type FrameCounter struct {
+	fiveStepMode bool
+	irqInhibit   bool
+	irqFlag      bool
+	cycle        uint16
+
+	// CPU cycles left until sequencer is reset after write to $4017
+	resetDelay uint8
+}
+
+// Write - $4017 MI-- ----. Odd cycle tells if write happened between APU cycles.
+func (f *FrameCounter) Write(data uint8, oddCycle bool) {
+	f.fiveStepMode = data&0b10000000 != 0
+	f.irqInhibit = data&0b01000000 != 0
+
+	if f.irqInhibit {
+		f.irqFlag = false
+	}
+
+	// Sequencer is reset 3 or 4 CPU cycles after the write
+	if oddCycle {
+		f.resetDelay = frameResetDelayHi
+	} else {
+		f.resetDelay = frameResetDelayLo
+	}
+}
+
+func (f *FrameCounter) GetIRQFlag() bool {
+	return f.irqFlag
+}
+
+// AcknowledgeIRQ - reading $4015 clears frame interrupt flag
+func (f *FrameCounter) AcknowledgeIRQ() {
+	f.irqFlag = false
+}
+
+// Clock - should be called every CPU cycle, returns which frame clocks should be sent to channels.
+func (f *FrameCounter) Clock() (quarter bool, half bool) {
+	if f.resetDelay > 0 {
+		f.resetDelay--
+
+		if f.resetDelay == 0 {
+			f.cycle = 0
+
+			// Writing to $4017 with 5-step mode set immediately clocks all units
+			return f.fiveStepMode, f.fiveStepMode
+		}
+	}
+
+	f.cycle++
+
+	switch f.cycle {
+	case frameStep1:
+		return true, false
+
+	case frameStep2:
+		return true, true
+
+	case frameStep3:
+		return true, false
+
+	case frameStep4 - 1:
+		f.setIRQ()
+
+	case frameStep4:
+		if !f.fiveStepMode {
+			f.setIRQ()
+			return true, true
+		}
+
+	case frameFourStepEnd:
+		if !f.fiveStepMode {
+			f.setIRQ()
+			f.cycle = 0
+		}
+
+	case frameStep5:
+		return true, true
+
+	case frameFiveStepEnd:
+		f.cycle = 0
+	}
+
+	return false, false
+}
+
+func (f *FrameCounter) setIRQ() {
+	if !f.fiveStepMode && !f.irqInhibit {
+		f.irqFlag = true
+	}
+}
+
+func (f *FrameCounter) Serialize(w *state.Writer) {
+	w.Bool(f.fiveStepMode)
+	w.Bool(f.irqInhibit)
+	w.Bool(f.irqFlag)
+	w.Uint16(f.cycle)
+	w.Uint8(f.resetDelay)
+}
+
+func (f *FrameCounter) Deserialize(r *state.Reader) {
+	f.fiveStepMode = r.Bool()
+	f.irqInhibit = r.Bool()
+	f.irqFlag = r.Bool()
+	f.cycle = r.Uint16()
+	f.resetDelay = r.Uint8()
+}
@@ -0,0 +1,60 @@
+package apu_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/apu"
+	"testing"
+)
+
+func countFrameClocks(f *apu.FrameCounter, cycles int) (quarters int, halves int) {
+	for i := 0; i < cycles; i++ {
+		q, h := f.Clock()
+
+		if q {
+			quarters++
+		}
+
+		if h {
+			halves++
+		}
+	}
+
+	return quarters, halves
+}
+
+func TestFrameCounterFourStep(t *testing.T) {
+	a := assert.New(t)
+	f := new(apu.FrameCounter)
+
+	q, h := countFrameClocks(f, 29830)
+	a.Equal(4, q, "4-step mode should clock quarter frame 4 times")
+	a.Equal(2, h, "4-step mode should clock half frame 2 times")
+	a.True(f.GetIRQFlag(), "4-step mode should set frame IRQ flag")
+
+	f.AcknowledgeIRQ()
+	a.False(f.GetIRQFlag(), "Acknowledging should clear frame IRQ flag")
+}
+
+func TestFrameCounterIRQInhibit(t *testing.T) {
+	a := assert.New(t)
+	f := new(apu.FrameCounter)
+
+	f.Write(0b01000000, false)
+	countFrameClocks(f, 29833)
+	a.False(f.GetIRQFlag(), "Inhibited frame counter should not set IRQ flag")
+}
+
+func TestFrameCounterFiveStep(t *testing.T) {
+	a := assert.New(t)
+	f := new(apu.FrameCounter)
+
+	f.Write(0b10000000, false)
+	q, h := countFrameClocks(f, 3)
+	a.Equal(1, q, "Switching to 5-step mode should immediately clock quarter frame")
+	a.Equal(1, h, "Switching to 5-step mode should immediately clock half frame")
+
+	q, h = countFrameClocks(f, 37282)
+	a.Equal(4, q, "5-step mode should clock quarter frame 4 times")
+	a.Equal(2, h, "5-step mode should clock half frame 2 times")
+	a.False(f.GetIRQFlag(), "5-step mode should never set frame IRQ flag")
+}
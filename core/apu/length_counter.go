@@ -0,0 +1,47 @@
+package apu
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// https://wiki.nesdev.com/w/index.php/APU_Length_Counter
+var lengthTable = [32]uint8{
+	10, 254, 20, 2, 40, 4, 80, 6, 160, 8, 60, 10, 14, 12, 26, 14,
+	12, 16, 24, 18, 48, 20, 96, 22, 192, 24, 72, 26, 16, 28, 32, 30,
+}
+
+type lengthCounter struct {
+	enabled bool
+	halt    bool
+	value   uint8
+}
+
+func (l *lengthCounter) setEnabled(enabled bool) {
+	l.enabled = enabled
+
+	if !enabled {
+		l.value = 0
+	}
+}
+
+func (l *lengthCounter) load(index uint8) {
+	if l.enabled {
+		l.value = lengthTable[index&0x1F]
+	}
+}
+
+func (l *lengthCounter) clock() {
+	if !l.halt && l.value > 0 {
+		l.value--
+	}
+}
+
+func (l *lengthCounter) serialize(w *state.Writer) {
+	w.Bool(l.enabled)
+	w.Bool(l.halt)
+	w.Uint8(l.value)
+}
+
+func (l *lengthCounter) deserialize(r *state.Reader) {
+	l.enabled = r.Bool()
+	l.halt = r.Bool()
+	l.value = r.Uint8()
+}
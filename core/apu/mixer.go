@@ -0,0 +1,30 @@
+package apu
+
+// https://wiki.nesdev.com/w/index.php/APU_Mixer
+// Lookup table approximation of non-linear mixer.
+type Mixer struct {
+	pulseTable [31]float32
+	tndTable   [203]float32
+}
+
+func NewMixer() *Mixer {
+	m := new(Mixer)
+
+	for i := 1; i < len(m.pulseTable); i++ {
+		m.pulseTable[i] = float32(95.52 / (8128.0/float64(i) + 100))
+	}
+
+	for i := 1; i < len(m.tndTable); i++ {
+		m.tndTable[i] = float32(163.67 / (24329.0/float64(i) + 100))
+	}
+
+	return m
+}
+
+// Mix - returns output level in range 0.0 - 1.0
+func (m *Mixer) Mix(pulse1, pulse2, triangle, noise, dmc uint8) float32 {
+	pulseOut := m.pulseTable[pulse1+pulse2]
+	tndOut := m.tndTable[3*uint16(triangle)+2*uint16(noise)+uint16(dmc)]
+
+	return pulseOut + tndOut
+}
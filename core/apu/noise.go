@@ -0,0 +1,106 @@
+package apu
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// https://wiki.nesdev.com/w/index.php/APU_Noise
+// Periods in CPU cycles (NTSC)
+var noisePeriodTable = [16]uint16{
+	4, 8, 16, 32, 64, 96, 128, 160, 202, 254, 380, 508, 762, 1016, 2034, 4068,
+}
+
+type Noise struct {
+	envelope
+	length lengthCounter
+
+	mode          bool
+	shiftRegister uint16
+
+	timerPeriod uint16
+	timerValue  uint16
+}
+
+func NewNoise() *Noise {
+	// Shift register is loaded with 1 on power up
+	return &Noise{shiftRegister: 1, timerPeriod: noisePeriodTable[0]}
+}
+
+// WriteControl - $400C --LC VVVV
+func (n *Noise) WriteControl(data uint8) {
+	n.length.halt = data&0b00100000 != 0
+	n.envelope.write(data)
+}
+
+// WritePeriod - $400E M--- PPPP
+func (n *Noise) WritePeriod(data uint8) {
+	n.mode = data&0b10000000 != 0
+	n.timerPeriod = noisePeriodTable[data&0b00001111]
+}
+
+// WriteLength - $400F LLLL L---
+func (n *Noise) WriteLength(data uint8) {
+	n.length.load(data >> 3)
+	n.envelope.start = true
+}
+
+func (n *Noise) SetEnabled(enabled bool) {
+	n.length.setEnabled(enabled)
+}
+
+func (n *Noise) IsActive() bool {
+	return n.length.value > 0
+}
+
+// ClockTimer - noise timer is clocked every CPU cycle
+func (n *Noise) ClockTimer() {
+	if n.timerValue > 0 {
+		n.timerValue--
+		return
+	}
+
+	n.timerValue = n.timerPeriod - 1
+
+	// Feedback is bit 0 XOR bit 6 in short mode or bit 1 in normal mode
+	tap := uint16(1)
+	if n.mode {
+		tap = 6
+	}
+
+	feedback := (n.shiftRegister & 0x01) ^ ((n.shiftRegister >> tap) & 0x01)
+	n.shiftRegister = (n.shiftRegister >> 1) | (feedback << 14)
+}
+
+// ClockQuarterFrame - clocked by frame counter
+func (n *Noise) ClockQuarterFrame() {
+	n.envelope.clock()
+}
+
+// ClockHalfFrame - clocked by frame counter
+func (n *Noise) ClockHalfFrame() {
+	n.length.clock()
+}
+
+func (n *Noise) Output() uint8 {
+	if n.length.value == 0 || n.shiftRegister&0x01 == 1 {
+		return 0
+	}
+
+	return n.envelope.output()
+}
+
+func (n *Noise) Serialize(w *state.Writer) {
+	n.envelope.serialize(w)
+	n.length.serialize(w)
+	w.Bool(n.mode)
+	w.Uint16(n.shiftRegister)
+	w.Uint16(n.timerPeriod)
+	w.Uint16(n.timerValue)
+}
+
+func (n *Noise) Deserialize(r *state.Reader) {
+	n.envelope.deserialize(r)
+	n.length.deserialize(r)
+	n.mode = r.Bool()
+	n.shiftRegister = r.Uint16()
+	n.timerPeriod = r.Uint16()
+	n.timerValue = r.Uint16()
+}
@@ -0,0 +1,75 @@
+package apu_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/apu"
+	"testing"
+)
+
+var noisePeriods = [16]int{4, 8, 16, 32, 64, 96, 128, 160, 202, 254, 380, 508, 762, 1016, 2034, 4068}
+
+func createNoise(period uint8) *apu.Noise {
+	n := apu.NewNoise()
+	n.SetEnabled(true)
+	// Constant volume 15
+	n.WriteControl(0b00011111)
+	n.WritePeriod(period)
+	n.WriteLength(0b00001000)
+
+	return n
+}
+
+// Output after each of given number of timer periods, period is given in CPU cycles
+func getNoiseOutputs(n *apu.Noise, period int, steps int) []uint8 {
+	var outputs []uint8
+
+	for i := 0; i < steps; i++ {
+		for c := 0; c < period; c++ {
+			n.ClockTimer()
+		}
+
+		outputs = append(outputs, n.Output())
+	}
+
+	return outputs
+}
+
+// Expected output of 15 bit LFSR starting with 1, feedback is taken from bit 0 and given tap
+func getLFSROutputs(tap uint, steps int) []uint8 {
+	var outputs []uint8
+	shiftRegister := uint16(1)
+
+	for i := 0; i < steps; i++ {
+		feedback := (shiftRegister & 0x01) ^ ((shiftRegister >> tap) & 0x01)
+		shiftRegister = (shiftRegister >> 1) | (feedback << 14)
+
+		if shiftRegister&0x01 == 0 {
+			outputs = append(outputs, 15)
+		} else {
+			outputs = append(outputs, 0)
+		}
+	}
+
+	return outputs
+}
+
+func TestNoisePeriods(t *testing.T) {
+	a := assert.New(t)
+
+	for index, period := range noisePeriods {
+		n := createNoise(uint8(index))
+		a.Equal(getLFSROutputs(1, 100), getNoiseOutputs(n, period, 100), "Shift register should be clocked every %d cycles", period)
+	}
+}
+
+func TestNoiseShortMode(t *testing.T) {
+	a := assert.New(t)
+
+	// Mode 1 takes feedback from bit 6
+	outputs := getNoiseOutputs(createNoise(0b10000000), noisePeriods[0], 93*2)
+	a.Equal(getLFSROutputs(6, 93*2), outputs, "Short mode should take feedback from bit 6")
+	a.Equal(outputs[:93], outputs[93:], "Short mode sequence should repeat after 93 steps")
+
+	long := getNoiseOutputs(createNoise(0), noisePeriods[0], 93*2)
+	a.NotEqual(long[:93], long[93:], "Normal mode sequence should not repeat after 93 steps")
+}
@@ -0,0 +1,180 @@
+package apu
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// https://wiki.nesdev.com/w/index.php/APU_Pulse
+var dutyTable = [4][8]uint8{
+	{0, 1, 0, 0, 0, 0, 0, 0},
+	{0, 1, 1, 0, 0, 0, 0, 0},
+	{0, 1, 1, 1, 1, 0, 0, 0},
+	{1, 0, 0, 1, 1, 1, 1, 1},
+}
+
+type Pulse struct {
+	// Pulse 1 and 2 differ in the way sweep unit negates the period
+	isFirst bool
+
+	// Expansion audio pulses (MMC5) have no sweep unit, so they are never muted by it
+	hasNoSweep bool
+
+	envelope
+	length lengthCounter
+
+	duty         uint8
+	dutyPosition uint8
+
+	timerPeriod uint16
+	timerValue  uint16
+
+	sweepEnabled bool
+	sweepPeriod  uint8
+	sweepNegate  bool
+	sweepShift   uint8
+	sweepDivider uint8
+	sweepReload  bool
+}
+
+func NewPulse(isFirst bool) *Pulse {
+	return &Pulse{isFirst: isFirst}
+}
+
+// NewPulseWithoutSweep - pulse channel as found in MMC5 expansion audio
+func NewPulseWithoutSweep() *Pulse {
+	return &Pulse{hasNoSweep: true}
+}
+
+// WriteControl - $4000/$4004 DDLC VVVV
+func (p *Pulse) WriteControl(data uint8) {
+	p.duty = data >> 6
+	p.length.halt = data&0b00100000 != 0
+	p.envelope.write(data)
+}
+
+// WriteSweep - $4001/$4005 EPPP NSSS
+func (p *Pulse) WriteSweep(data uint8) {
+	p.sweepEnabled = data&0b10000000 != 0
+	p.sweepPeriod = (data >> 4) & 0b00000111
+	p.sweepNegate = data&0b00001000 != 0
+	p.sweepShift = data & 0b00000111
+	p.sweepReload = true
+}
+
+// WriteTimerLow - $4002/$4006 TTTT TTTT
+func (p *Pulse) WriteTimerLow(data uint8) {
+	p.timerPeriod = (p.timerPeriod & 0xFF00) | uint16(data)
+}
+
+// WriteTimerHigh - $4003/$4007 LLLL LTTT
+func (p *Pulse) WriteTimerHigh(data uint8) {
+	p.timerPeriod = (p.timerPeriod & 0x00FF) | (uint16(data&0b00000111) << 8)
+	p.length.load(data >> 3)
+	p.dutyPosition = 0
+	p.envelope.start = true
+}
+
+func (p *Pulse) SetEnabled(enabled bool) {
+	p.length.setEnabled(enabled)
+}
+
+func (p *Pulse) IsActive() bool {
+	return p.length.value > 0
+}
+
+// ClockTimer - pulse timer is clocked every other CPU cycle
+func (p *Pulse) ClockTimer() {
+	if p.timerValue == 0 {
+		p.timerValue = p.timerPeriod
+		p.dutyPosition = (p.dutyPosition + 1) & 0x07
+	} else {
+		p.timerValue--
+	}
+}
+
+// ClockQuarterFrame - clocked by frame counter
+func (p *Pulse) ClockQuarterFrame() {
+	p.envelope.clock()
+}
+
+// ClockHalfFrame - clocked by frame counter
+func (p *Pulse) ClockHalfFrame() {
+	p.length.clock()
+
+	// https://wiki.nesdev.com/w/index.php/APU_Sweep
+	if p.sweepDivider == 0 && p.sweepEnabled && p.sweepShift > 0 && !p.isMuted() {
+		p.timerPeriod = p.getSweepTargetPeriod()
+	}
+
+	if p.sweepDivider == 0 || p.sweepReload {
+		p.sweepDivider = p.sweepPeriod
+		p.sweepReload = false
+	} else {
+		p.sweepDivider--
+	}
+}
+
+func (p *Pulse) getSweepTargetPeriod() uint16 {
+	change := p.timerPeriod >> p.sweepShift
+
+	if !p.sweepNegate {
+		return p.timerPeriod + change
+	}
+
+	// Pulse 1 adds ones' complement, pulse 2 adds two's complement
+	if p.isFirst {
+		change++
+	}
+
+	if change > p.timerPeriod {
+		return 0
+	}
+
+	return p.timerPeriod - change
+}
+
+// Sweep unit mutes the channel when period is too low or target period overflows,
+// even when sweep is disabled.
+func (p *Pulse) isMuted() bool {
+	if p.hasNoSweep {
+		return false
+	}
+
+	return p.timerPeriod < 8 || p.getSweepTargetPeriod() > 0x7FF
+}
+
+func (p *Pulse) Output() uint8 {
+	if p.length.value == 0 || p.isMuted() || dutyTable[p.duty][p.dutyPosition] == 0 {
+		return 0
+	}
+
+	return p.envelope.output()
+}
+
+func (p *Pulse) Serialize(w *state.Writer) {
+	p.envelope.serialize(w)
+	p.length.serialize(w)
+	w.Uint8(p.duty)
+	w.Uint8(p.dutyPosition)
+	w.Uint16(p.timerPeriod)
+	w.Uint16(p.timerValue)
+	w.Bool(p.sweepEnabled)
+	w.Uint8(p.sweepPeriod)
+	w.Bool(p.sweepNegate)
+	w.Uint8(p.sweepShift)
+	w.Uint8(p.sweepDivider)
+	w.Bool(p.sweepReload)
+}
+
+func (p *Pulse) Deserialize(r *state.Reader) {
+	p.envelope.deserialize(r)
+	p.length.deserialize(r)
+	p.duty = r.Uint8()
+	p.dutyPosition = r.Uint8()
+	p.timerPeriod = r.Uint16()
+	p.timerValue = r.Uint16()
+	p.sweepEnabled = r.Bool()
+	p.sweepPeriod = r.Uint8()
+	p.sweepNegate = r.Bool()
+	p.sweepShift = r.Uint8()
+	p.sweepDivider = r.Uint8()
+	p.sweepReload = r.Bool()
+}
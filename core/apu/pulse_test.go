@@ -0,0 +1,99 @@
+package apu_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/apu"
+	"testing"
+)
+
+func createPulse(isFirst bool, period uint16) *apu.Pulse {
+	p := apu.NewPulse(isFirst)
+	p.SetEnabled(true)
+	// 50% duty, constant volume 15
+	p.WriteControl(0b10011111)
+	p.WriteTimerLow(uint8(period))
+	p.WriteTimerHigh(uint8(period>>8) & 0x07)
+
+	return p
+}
+
+func getMaxOutput(p *apu.Pulse, period uint16) uint8 {
+	max := uint8(0)
+
+	for i := uint16(0); i < (period+1)*8; i++ {
+		p.ClockTimer()
+
+		if p.Output() > max {
+			max = p.Output()
+		}
+	}
+
+	return max
+}
+
+func TestPulseOutput(t *testing.T) {
+	a := assert.New(t)
+	p := createPulse(true, 0x100)
+
+	a.True(p.IsActive(), "Pulse should be active after loading length counter")
+	a.Equal(uint8(15), getMaxOutput(p, 0x100), "Pulse should output constant volume")
+}
+
+func TestPulseMutedOnLowPeriod(t *testing.T) {
+	a := assert.New(t)
+	p := createPulse(true, 7)
+
+	a.Equal(uint8(0), getMaxOutput(p, 7), "Pulse should be muted when period is lower than 8")
+}
+
+func TestPulseMutedOnSweepOverflow(t *testing.T) {
+	a := assert.New(t)
+	p := createPulse(true, 0x7F0)
+
+	// Sweep disabled, shift 0 - target period still overflows
+	p.WriteSweep(0b00000000)
+	a.Equal(uint8(0), getMaxOutput(p, 0x7F0), "Pulse should be muted when sweep target overflows")
+}
+
+func TestPulseDisabled(t *testing.T) {
+	a := assert.New(t)
+	p := createPulse(true, 0x100)
+	p.SetEnabled(false)
+
+	a.False(p.IsActive(), "Disabling channel should clear length counter")
+	a.Equal(uint8(0), getMaxOutput(p, 0x100), "Disabled pulse should be silent")
+}
+
+func TestPulseLengthCounter(t *testing.T) {
+	a := assert.New(t)
+	p := apu.NewPulse(false)
+	p.SetEnabled(true)
+	p.WriteControl(0b00011111)
+	// Length index 1 - 254
+	p.WriteTimerHigh(0b00001000)
+
+	for i := 0; i < 253; i++ {
+		p.ClockHalfFrame()
+	}
+
+	a.True(p.IsActive(), "Length counter should not reach 0 yet")
+	p.ClockHalfFrame()
+	a.False(p.IsActive(), "Length counter should reach 0")
+}
+
+func TestPulseSweepNegate(t *testing.T) {
+	a := assert.New(t)
+
+	// Enabled, period 0, negate, shift 1
+	// Pulse 1 uses ones' complement: 0x10 - 0x08 - 1 = 0x07 which mutes the channel
+	p1 := createPulse(true, 0x10)
+	p1.WriteSweep(0b10001001)
+	p1.ClockHalfFrame()
+	a.Equal(uint8(0), getMaxOutput(p1, 0x07), "Pulse 1 should be muted after sweep")
+
+	// Pulse 2 uses two's complement: 0x10 - 0x08 = 0x08
+	p2 := createPulse(false, 0x10)
+	p2.WriteSweep(0b10001001)
+	p2.ClockHalfFrame()
+	a.Equal(uint8(15), getMaxOutput(p2, 0x08), "Pulse 2 should not be muted after sweep")
+}
@@ -0,0 +1,109 @@
+package apu
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// https://wiki.nesdev.com/w/index.php/APU_Triangle
+var triangleTable = [32]uint8{
+	15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1, 0,
+	0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+}
+
+type Triangle struct {
+	length lengthCounter
+
+	control             bool
+	linearReloadValue   uint8
+	linearCounter       uint8
+	linearCounterReload bool
+
+	sequencePosition uint8
+
+	timerPeriod uint16
+	timerValue  uint16
+}
+
+// WriteControl - $4008 CRRR RRRR
+func (t *Triangle) WriteControl(data uint8) {
+	t.control = data&0b10000000 != 0
+	t.length.halt = t.control
+	t.linearReloadValue = data & 0b01111111
+}
+
+// WriteTimerLow - $400A TTTT TTTT
+func (t *Triangle) WriteTimerLow(data uint8) {
+	t.timerPeriod = (t.timerPeriod & 0xFF00) | uint16(data)
+}
+
+// WriteTimerHigh - $400B LLLL LTTT
+func (t *Triangle) WriteTimerHigh(data uint8) {
+	t.timerPeriod = (t.timerPeriod & 0x00FF) | (uint16(data&0b00000111) << 8)
+	t.length.load(data >> 3)
+	t.linearCounterReload = true
+}
+
+func (t *Triangle) SetEnabled(enabled bool) {
+	t.length.setEnabled(enabled)
+}
+
+func (t *Triangle) IsActive() bool {
+	return t.length.value > 0
+}
+
+// ClockTimer - triangle timer is clocked every CPU cycle
+func (t *Triangle) ClockTimer() {
+	if t.timerValue == 0 {
+		t.timerValue = t.timerPeriod
+
+		// Sequencer is stepped only when both counters are non zero
+		if t.length.value > 0 && t.linearCounter > 0 {
+			t.sequencePosition = (t.sequencePosition + 1) & 0x1F
+		}
+	} else {
+		t.timerValue--
+	}
+}
+
+// ClockQuarterFrame - clocked by frame counter
+func (t *Triangle) ClockQuarterFrame() {
+	if t.linearCounterReload {
+		t.linearCounter = t.linearReloadValue
+	} else if t.linearCounter > 0 {
+		t.linearCounter--
+	}
+
+	if !t.control {
+		t.linearCounterReload = false
+	}
+}
+
+// ClockHalfFrame - clocked by frame counter
+func (t *Triangle) ClockHalfFrame() {
+	t.length.clock()
+}
+
+func (t *Triangle) Output() uint8 {
+	// Triangle is not silenced when counters reach 0 - it just holds its current value.
+	return triangleTable[t.sequencePosition]
+}
+
+func (t *Triangle) Serialize(w *state.Writer) {
+	t.length.serialize(w)
+	w.Bool(t.control)
+	w.Uint8(t.linearReloadValue)
+	w.Uint8(t.linearCounter)
+	w.Bool(t.linearCounterReload)
+	w.Uint8(t.sequencePosition)
+	w.Uint16(t.timerPeriod)
+	w.Uint16(t.timerValue)
+}
+
+func (t *Triangle) Deserialize(r *state.Reader) {
+	t.length.deserialize(r)
+	t.control = r.Bool()
+	t.linearReloadValue = r.Uint8()
+	t.linearCounter = r.Uint8()
+	t.linearCounterReload = r.Bool()
+	t.sequencePosition = r.Uint8()
+	t.timerPeriod = r.Uint16()
+	t.timerValue = r.Uint16()
+}
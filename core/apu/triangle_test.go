@@ -0,0 +1,85 @@
+package apu_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/apu"
+	"testing"
+)
+
+// Triangle with period 0, so sequencer is stepped on every timer clock while both counters are non zero
+func createTriangle(control uint8) *apu.Triangle {
+	t := new(apu.Triangle)
+	t.SetEnabled(true)
+	t.WriteControl(control)
+	t.WriteTimerLow(0)
+	t.WriteTimerHigh(0b00001000)
+
+	return t
+}
+
+// Returns true when one timer clock moved the sequencer
+func isSequencerStepping(t *apu.Triangle) bool {
+	before := t.Output()
+	t.ClockTimer()
+
+	return t.Output() != before
+}
+
+func TestTriangleSequence(t *testing.T) {
+	a := assert.New(t)
+	triangle := createTriangle(0b10000001)
+	triangle.ClockQuarterFrame()
+
+	var outputs []uint8
+	for i := 0; i < 32; i++ {
+		triangle.ClockTimer()
+		outputs = append(outputs, triangle.Output())
+	}
+
+	a.Equal([]uint8{
+		14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1, 0, 0,
+		1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 15,
+	}, outputs, "Triangle should go through 32 step sequence")
+}
+
+func TestTriangleLinearCounter(t *testing.T) {
+	a := assert.New(t)
+	triangle := createTriangle(0b00000010)
+
+	a.False(isSequencerStepping(triangle), "Sequencer should not step before linear counter is reloaded")
+
+	// Reload flag is cleared after reload when control flag is clear
+	triangle.ClockQuarterFrame()
+	a.True(isSequencerStepping(triangle), "Sequencer should step after linear counter is reloaded")
+
+	triangle.ClockQuarterFrame()
+	a.True(isSequencerStepping(triangle), "Linear counter should be decremented to 1")
+
+	triangle.ClockQuarterFrame()
+	a.False(isSequencerStepping(triangle), "Sequencer should stop when linear counter reaches 0")
+
+	// Writing $400B sets reload flag again
+	triangle.WriteTimerHigh(0b00001000)
+	triangle.ClockQuarterFrame()
+	a.True(isSequencerStepping(triangle), "Linear counter should be reloaded after $400B write")
+}
+
+func TestTriangleLinearCounterHalt(t *testing.T) {
+	a := assert.New(t)
+
+	// Control flag keeps reload flag set, so counter is reloaded on every quarter frame
+	triangle := createTriangle(0b10000001)
+
+	for i := 0; i < 10; i++ {
+		triangle.ClockQuarterFrame()
+	}
+
+	a.True(isSequencerStepping(triangle), "Linear counter should be halted by control flag")
+
+	// Control flag also halts length counter
+	for i := 0; i < 300; i++ {
+		triangle.ClockHalfFrame()
+	}
+
+	a.True(triangle.IsActive(), "Length counter should be halted by control flag")
+}
@@ -0,0 +1,49 @@
+package core_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Clocks APU once when CPU is about to dispatch next instruction and returns number of CPU cycles until it is dispatched
+func clockAPUBeforeInstruction(s *testSystem) uint64 {
+	for s.cpu.GetCyclesLeft() != 0 {
+		s.cpu.Clock()
+	}
+
+	s.apu.Clock()
+
+	pc := s.cpu.GetPC()
+	start := s.cpu.GetCycles()
+
+	for s.cpu.GetPC() == pc {
+		s.cpu.Clock()
+	}
+
+	return s.cpu.GetCycles() - start
+}
+
+func TestAPUDMCFetch(t *testing.T) {
+	a := assert.New(t)
+
+	// NOPs
+	s := createTestSystem(t, []uint8{0xEA, 0xEA, 0xEA, 0xEA, 0xEA, 0xEA})
+	a.Equal(uint64(1), clockAPUBeforeInstruction(s), "Instruction should be dispatched without DMC fetch")
+
+	// 1 byte sample at $C000 with IRQ enabled
+	s.cpu.Write(0x4010, 0b10000000)
+	s.cpu.Write(0x4012, 0x00)
+	s.cpu.Write(0x4013, 0x00)
+	s.cpu.Write(0x4015, 0b00010000)
+	a.Equal(uint8(0b00010000), s.cpu.Read(0x4015)&0b10010000, "DMC should be active before sample is fetched")
+
+	a.Equal(uint64(1+4), clockAPUBeforeInstruction(s), "DMC fetch should stall CPU for 4 cycles")
+	a.Equal(uint64(1), clockAPUBeforeInstruction(s), "Empty DMC should not stall CPU")
+
+	a.Equal(uint8(0b10000000), s.cpu.Read(0x4015)&0b10010000, "DMC IRQ flag should be set after sample ends")
+	a.True(s.cpu.IsIRQAsserted(), "DMC should assert IRQ")
+
+	s.cpu.Write(0x4015, 0x00)
+	a.Equal(uint8(0), s.cpu.Read(0x4015)&0b10000000, "Writing $4015 should clear DMC IRQ flag")
+	a.False(s.cpu.IsIRQAsserted(), "DMC should release IRQ after $4015 write")
+}
@@ -1,37 +1,107 @@
 package core
 
 import (
+	"bytes"
+	"crypto/sha1"
 	"encoding/binary"
-	"fmt"
 	"github.com/szymonkups/nesgo/core/mappers"
+	"github.com/szymonkups/nesgo/core/state"
 	"io"
 	"os"
 )
 
 const (
-	MirroringVertical = iota
-	MirroringHorizontal
+	MirroringVertical         = mappers.MirroringVertical
+	MirroringHorizontal       = mappers.MirroringHorizontal
+	MirroringSingleScreenLow  = mappers.MirroringSingleScreenLow
+	MirroringSingleScreenHigh = mappers.MirroringSingleScreenHigh
+	MirroringFourScreen       = mappers.MirroringFourScreen
 )
 
 type Cartridge struct {
-	mapper    mappers.Mapper
-	chrMem    []uint8
-	mirroring uint8
+	mapper mappers.Mapper
+	chrMem []uint8
+	info   RomInfo
+
+	// SHA-1 of PRG and CHR ROM, save states are valid only for the same game
+	romHash [sha1.Size]uint8
+
+	// Mappers with IRQ counters drive CPU IRQ line
+	cpu *CPU
+
+	// Additional 2KB of VRAM for nametables 2 and 3 on four-screen boards
+	fourScreen    bool
+	fourScreenRam [2][0x400]uint8
+
+	// Battery backed memory of the board and its contents when it was last loaded or flushed
+	saveRam   *mappers.SaveRAM
+	savedData []uint8
 }
 
-var allMappers = map[uint8]mappers.Mapper{
+var allMappers = map[uint16]mappers.Mapper{
 	0x00: &mappers.Mapper0{},
+	0x01: &mappers.Mapper1{},
+	0x02: &mappers.Mapper2{},
+	0x03: &mappers.Mapper3{},
+	0x04: &mappers.Mapper4{},
+	0x05: &mappers.Mapper5{},
+	0x07: &mappers.Mapper7{},
+	0x09: &mappers.Mapper9{},
+	0x0A: &mappers.Mapper10{},
+	0x10: &mappers.Mapper16{},
+	0x13: &mappers.Mapper19{},
+	0x15: &mappers.Mapper21{},
+	0x16: &mappers.Mapper22{},
+	0x17: &mappers.Mapper23{},
+	0x18: &mappers.Mapper24{},
+	0x19: &mappers.Mapper25{},
+	0x1A: &mappers.Mapper26{},
+	0x42: &mappers.Mapper66{},
+	0x45: &mappers.Mapper69{},
+	0x55: &mappers.Mapper85{},
+	0x9F: &mappers.Mapper159{},
 }
 
 func (crt *Cartridge) GetMirroring() uint8 {
-	return crt.mirroring
+	// Four-screen boards have nametables wired to their own memory regardless of mapper
+	if crt.fourScreen {
+		return MirroringFourScreen
+	}
+
+	if crt.mapper != nil {
+		return crt.mapper.GetMirroring()
+	}
+
+	return MirroringHorizontal
+}
+
+// Console VRAM page for nametable index (0-3) when mapper selects pages for each nametable separately
+func (crt *Cartridge) getNameTablePage(index uint16) (uint8, bool) {
+	if nameTableMapper, ok := crt.mapper.(mappers.NameTableMapper); ok && !crt.fourScreen {
+		return nameTableMapper.GetNameTablePage(uint8(index)), true
+	}
+
+	return 0, false
+}
+
+// Nametable memory provided by four-screen cartridge, index is 0 for $2800 and 1 for $2C00
+func (crt *Cartridge) getFourScreenNameTable(index uint16) *[0x400]uint8 {
+	return &crt.fourScreenRam[index&0x01]
 }
 
 func (crt *Cartridge) Read(busId string, addr uint16, debug bool) (uint8, bool) {
 	if crt.mapper != nil {
 		// TODO: don't pass reading writing to the mapper - just map the address
 		// TODO: let's decide it when more mappers will be in place
-		return crt.mapper.Read(busId, addr, debug)
+		data, handled := crt.mapper.Read(busId, addr, debug)
+
+		// Reading status register can acknowledge mapper IRQ (MMC5 $5204), PRG ROM reads never do, so instruction
+		// fetches don't pay for it
+		if busId == "cpu" && !debug && handled && addr < 0x8000 {
+			crt.updateIRQ()
+		}
+
+		return data, handled
 	}
 
 	return 0x00, false
@@ -39,27 +109,65 @@ func (crt *Cartridge) Read(busId string, addr uint16, debug bool) (uint8, bool)
 
 func (crt *Cartridge) Write(busId string, addr uint16, data uint8, debug bool) bool {
 	if crt.mapper != nil {
-		return crt.mapper.Write(busId, addr, data, debug)
+		handled := crt.mapper.Write(busId, addr, data, debug)
+		crt.updateIRQ()
+
+		return handled
 	}
 
 	return false
 }
 
-// https://wiki.nesdev.com/w/index.php/INES
-type fileHeader struct {
-	Name        [4]uint8
-	PrgRomBanks uint8
-	ChrRomBanks uint8
-	Flags6      uint8
-	Flags7      uint8
-	Flags8      uint8
-	Flags9      uint8
-	Flags10     uint8
-	Unused      [5]uint8
+// Clock - should be called on every CPU cycle, clocks mapper counters
+func (crt *Cartridge) Clock() {
+	if clocked, ok := crt.mapper.(mappers.CPUClocked); ok {
+		clocked.ClockCPU()
+		crt.updateIRQ()
+	}
+}
+
+// AudioOutput - level of mapper's expansion audio, mixed with APU output
+func (crt *Cartridge) AudioOutput() float32 {
+	if expansion, ok := crt.mapper.(mappers.ExpansionAudio); ok {
+		return expansion.AudioOutput()
+	}
+
+	return 0
+}
+
+// ConnectCPU - connects CPU IRQ line to the mapper
+func (crt *Cartridge) ConnectCPU(cpu *CPU) {
+	crt.cpu = cpu
+	crt.updateIRQ()
+}
+
+// NotifyPPUAddress - called by PPU for every address it puts on its bus
+func (crt *Cartridge) NotifyPPUAddress(addr uint16, cycle uint64) {
+	if listener, ok := crt.mapper.(mappers.PPUAddressListener); ok {
+		listener.OnPPUAddress(addr, cycle)
+		crt.updateIRQ()
+	}
+}
+
+// NotifyRenderingFetch - called by PPU before each rendering fetch, see mappers.RenderingListener
+func (crt *Cartridge) NotifyRenderingFetch(kind uint8, scanLine int16, tile uint8) {
+	if listener, ok := crt.mapper.(mappers.RenderingListener); ok {
+		listener.OnRenderingFetch(kind, scanLine, tile)
+		crt.updateIRQ()
+	}
+}
+
+func (crt *Cartridge) updateIRQ() {
+	if crt.cpu == nil {
+		return
+	}
+
+	generator, ok := crt.mapper.(mappers.IRQGenerator)
+	crt.cpu.SetIRQ(IRQSourceMapper, ok && generator.IsIRQAsserted())
 }
 
+// LoadFile - loads ROM file, see Load
 func (crt *Cartridge) LoadFile(fileName string) error {
-	// TODO: extract file parsing to some utility function
 	f, err := os.Open(fileName)
 
 	if err != nil {
@@ -68,59 +176,129 @@ func (crt *Cartridge) LoadFile(fileName string) error {
 
 	defer f.Close()
 
+	return crt.Load(f)
+}
+
+// LoadBytes - loads ROM file contents from memory, see Load
+func (crt *Cartridge) LoadBytes(data []uint8) error {
+	return crt.Load(bytes.NewReader(data))
+}
+
+// Load - reads ROM in iNES or NES 2.0 format. Invalid files are reported with ErrInvalidMagic, ErrNoPrgRom,
+// ErrRomTooLarge, *TruncatedRomError or *UnsupportedMapperError, cartridge is left unchanged then.
+func (crt *Cartridge) Load(r io.Reader) error {
+	data, err := readRomSection(r, "header", binary.Size(fileHeader{}))
+
+	if err != nil {
+		return err
+	}
+
 	header := fileHeader{}
-	err = binary.Read(f, binary.BigEndian, &header)
+	err = binary.Read(bytes.NewReader(data), binary.BigEndian, &header)
 
 	if err != nil {
 		return err
 	}
 
-	// If trainer data is present - skip it
-	if header.Flags6&0b00000100 != 0 {
-		_, err := f.Seek(512, io.SeekCurrent)
+	if string(header.Name[:]) != "NES\x1A" {
+		return ErrInvalidMagic
+	}
 
-		if err != nil {
-			return err
-		}
+	info := parseHeader(&header)
+
+	if info.PrgRomSize == 0 {
+		return ErrNoPrgRom
 	}
 
-	// Mapper number is spread across flags 6 and 7
-	mapperNumber := ((header.Flags7 >> 4) << 4) | (header.Flags6 >> 4)
+	if info.PrgRomSize > maxRomSize || info.ChrRomSize > maxRomSize {
+		return ErrRomTooLarge
+	}
+
+	mapper, ok := allMappers[info.Mapper]
 
-	// Mirroring
-	crt.mirroring = MirroringHorizontal
-	if header.Flags6&0b00000001 > 0 {
-		crt.mirroring = MirroringVertical
+	if !ok {
+		return &UnsupportedMapperError{Mapper: info.Mapper}
 	}
 
-	// Load PRG ROM data
-	prgMem := make([]uint8, int(header.PrgRomBanks)*0x4000)
-	_, err = f.Read(prgMem)
+	// Trainer is not used - skip it
+	if info.HasTrainer {
+		_, err = readRomSection(r, "trainer", 512)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	prgMem, err := readRomSection(r, "PRG ROM", info.PrgRomSize)
 
 	if err != nil {
 		return err
 	}
 
-	// Load CHR ROM data
-	crt.chrMem = make([]uint8, int(header.ChrRomBanks)*0x2000)
-	_, err = f.Read(crt.chrMem)
+	chrMem, err := readRomSection(r, "CHR ROM", info.ChrRomSize)
 
 	if err != nil {
 		return err
 	}
 
-	mapper, ok := allMappers[mapperNumber]
+	romHash := sha1.Sum(append(append([]uint8{}, prgMem...), chrMem...))
 
-	if !ok {
-		return fmt.Errorf("mapper 0x%X not supported, yet", mapperNumber)
-	}
+	// Mappers work on whole banks
+	prgMem = padRom(prgMem, 0x4000)
+	chrMem = padRom(chrMem, 0x2000)
+
+	saveRam := new(mappers.SaveRAM)
+
+	// RAM sizes are passed as declared, mappers round CHR RAM up to whole 8KB banks
+	mapper.Initialize(mappers.Board{
+		PrgMem:       prgMem,
+		ChrMem:       chrMem,
+		Mirroring:    info.Mirroring,
+		Submapper:    info.Submapper,
+		HasBattery:   info.HasBattery,
+		SaveRAM:      saveRam,
+		HasRamSizes:  info.Format == RomFormatNES20,
+		PrgRamSize:   info.PrgRamSize,
+		PrgNvRamSize: info.PrgNvRamSize,
+		ChrRamSize:   info.ChrRamSize,
+		ChrNvRamSize: info.ChrNvRamSize,
+	})
 
-	mapper.Initialize(header.PrgRomBanks, header.ChrRomBanks, prgMem, crt.chrMem)
 	crt.mapper = mapper
+	crt.chrMem = chrMem
+	crt.fourScreen = info.IsFourScreen
+	crt.info = info
+	crt.romHash = romHash
+	crt.saveRam = saveRam
+	crt.savedData = saveRam.GetData()
 
 	return nil
 }
 
+// GetRomHash - SHA-1 of loaded PRG and CHR ROM
+func (crt *Cartridge) GetRomHash() [sha1.Size]uint8 {
+	return crt.romHash
+}
+
+// Serialize - saves mapper registers, cartridge RAM and four-screen VRAM
+func (crt *Cartridge) Serialize(w *state.Writer) {
+	crt.mapper.Serialize(w)
+	w.Bytes(crt.fourScreenRam[0][:])
+	w.Bytes(crt.fourScreenRam[1][:])
+}
+
+func (crt *Cartridge) Deserialize(r *state.Reader) {
+	crt.mapper.Deserialize(r)
+	r.Bytes(crt.fourScreenRam[0][:])
+	r.Bytes(crt.fourScreenRam[1][:])
+	crt.updateIRQ()
+}
+
+// GetRomInfo - metadata from header of loaded file
+func (crt *Cartridge) GetRomInfo() RomInfo {
+	return crt.info
+}
+
 func (crt *Cartridge) GetCHRMem() []uint8 {
 	return crt.chrMem
 }
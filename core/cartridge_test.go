@@ -0,0 +1,184 @@
+package core_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// ROM with given header followed by PRG and CHR data of given sizes, each byte holds number of its 8KB bank
+func createTestRom(header []uint8, prgSize int, chrSize int) []uint8 {
+	data := append([]uint8{}, header...)
+
+	for i := 0; i < prgSize; i++ {
+		data = append(data, uint8(i/0x2000))
+	}
+
+	return append(data, make([]uint8, chrSize)...)
+}
+
+func writeTestRom(t *testing.T, header []uint8, prgSize int, chrSize int) string {
+	f, err := ioutil.TempFile("", "nesgo-*.nes")
+	assert.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.Write(createTestRom(header, prgSize, chrSize))
+	assert.NoError(t, err)
+
+	return f.Name()
+}
+
+func TestCartridgeINESHeader(t *testing.T) {
+	a := assert.New(t)
+	fileName := writeTestRom(t, []uint8{'N', 'E', 'S', 0x1A, 2, 1, 0x23, 0x40, 0, 0, 0, 0, 0, 0, 0, 0}, 0x8000, 0x2000)
+	defer os.Remove(fileName)
+
+	crt := new(core.Cartridge)
+	a.NoError(crt.LoadFile(fileName))
+
+	info := crt.GetRomInfo()
+	a.Equal(uint8(core.RomFormatINES), info.Format)
+	a.Equal(uint16(0x42), info.Mapper, "Mapper number should be read from flags 6 and 7")
+	a.Equal(0x8000, info.PrgRomSize)
+	a.Equal(0x2000, info.ChrRomSize)
+	a.Equal(uint8(core.MirroringVertical), info.Mirroring)
+	a.True(info.HasBattery)
+}
+
+func TestCartridgeNES20Header(t *testing.T) {
+	a := assert.New(t)
+	header := []uint8{
+		'N', 'E', 'S', 0x1A,
+		2, 0,
+		0x10, // mapper 1
+		0x09, // NES 2.0, Vs. System
+		0x50, // submapper 5
+		0x00, // ROM size MSB
+		0x07, // 8KB PRG RAM
+		0x70, // 8KB CHR NVRAM
+		0x01, // PAL
+		0x23, // Vs. hardware and PPU type
+		0x01, // misc ROMs
+		0x08, // Zapper
+	}
+	fileName := writeTestRom(t, header, 0x8000, 0)
+	defer os.Remove(fileName)
+
+	crt := new(core.Cartridge)
+	a.NoError(crt.LoadFile(fileName))
+
+	info := crt.GetRomInfo()
+	a.Equal(uint8(core.RomFormatNES20), info.Format)
+	a.Equal(uint16(1), info.Mapper)
+	a.Equal(uint8(5), info.Submapper)
+	a.Equal(0x8000, info.PrgRomSize)
+	a.Equal(0, info.ChrRomSize)
+	a.Equal(0x2000, info.PrgRamSize)
+	a.Equal(0, info.PrgNvRamSize)
+	a.Equal(0, info.ChrRamSize)
+	a.Equal(0x2000, info.ChrNvRamSize)
+	a.Equal(uint8(core.ConsoleVsSystem), info.ConsoleType)
+	a.Equal(uint8(core.TimingPAL), info.Timing)
+	a.Equal(uint8(3), info.VsPPUType)
+	a.Equal(uint8(2), info.VsHardwareType)
+	a.Equal(uint8(1), info.MiscRoms)
+	a.Equal(uint8(core.ExpansionDeviceZapper), info.ExpansionDevice)
+}
+
+func TestCartridgeNES20ExponentRomSize(t *testing.T) {
+	a := assert.New(t)
+
+	// 2^14 * 3 = 48KB of PRG ROM
+	header := []uint8{'N', 'E', 'S', 0x1A, 14<<2 | 1, 0, 0x00, 0x08, 0, 0x0F, 0, 0x07, 0, 0, 0, 0}
+	fileName := writeTestRom(t, header, 0xC000, 0)
+	defer os.Remove(fileName)
+
+	crt := new(core.Cartridge)
+	a.NoError(crt.LoadFile(fileName))
+	a.Equal(0xC000, crt.GetRomInfo().PrgRomSize)
+}
+
+func TestCartridgeSmallChrRam(t *testing.T) {
+	a := assert.New(t)
+	crt := new(core.Cartridge)
+
+	for _, mapper := range []uint8{0, 1, 2, 3, 4, 5, 7, 9, 10, 16, 19, 21, 24, 66, 69, 85} {
+		// 512 bytes of CHR RAM declared, which is less than any mapper can bank
+		header := []uint8{'N', 'E', 'S', 0x1A, 2, 0, mapper << 4, mapper&0xF0 | 0x08, 0, 0, 0, 0x03, 0, 0, 0, 0}
+		a.NoError(crt.LoadBytes(createTestRom(header, 0x8000, 0)))
+
+		crt.Write("ppu", 0x1FFF, 0x42, false)
+		data, _ := crt.Read("ppu", 0x1FFF, true)
+		a.Equal(uint8(0x42), data, "Mapper %d should have at least 8KB of CHR RAM", mapper)
+	}
+}
+
+func TestCartridgeInvalidFiles(t *testing.T) {
+	a := assert.New(t)
+	crt := new(core.Cartridge)
+	header := []uint8{'N', 'E', 'S', 0x1A, 2, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+
+	a.Equal(core.ErrInvalidMagic, crt.LoadBytes(createTestRom([]uint8("NSF\x1A000000000000"), 0x8000, 0)))
+
+	var truncated *core.TruncatedRomError
+	err := crt.LoadBytes(header[:10])
+	a.True(errors.As(err, &truncated), "Short header should be reported")
+	a.Equal("header", truncated.Section)
+
+	err = crt.LoadBytes(createTestRom(header, 0x6000, 0))
+	a.True(errors.As(err, &truncated), "Missing PRG ROM data should be reported")
+	a.Equal(core.TruncatedRomError{Section: "PRG ROM", Expected: 0x8000, Actual: 0x6000}, *truncated)
+
+	err = crt.LoadBytes(createTestRom(header, 0x8000, 0x1000))
+	a.True(errors.As(err, &truncated), "Missing CHR ROM data should be reported")
+	a.Equal("CHR ROM", truncated.Section)
+
+	noPrg := append([]uint8{}, header...)
+	noPrg[4] = 0
+	a.Equal(core.ErrNoPrgRom, crt.LoadBytes(createTestRom(noPrg, 0, 0x2000)))
+
+	// Exponent notation with 2^31, 2^32 and 2^63 bytes, which overflow int on 32 bit platforms
+	for _, exponent := range []uint8{31, 32, 63} {
+		tooLarge := []uint8{'N', 'E', 'S', 0x1A, exponent << 2, 0, 0, 0x08, 0, 0x0F, 0, 0, 0, 0, 0, 0}
+		a.Equal(core.ErrRomTooLarge, crt.LoadBytes(createTestRom(tooLarge, 0, 0)), "2^%d bytes should be too large", exponent)
+	}
+
+	var unsupported *core.UnsupportedMapperError
+	noMapper := append([]uint8{}, header...)
+	noMapper[6] = 0xF0
+	noMapper[7] = 0xF0
+	err = crt.LoadBytes(createTestRom(noMapper, 0x8000, 0x2000))
+	a.True(errors.As(err, &unsupported), "Unsupported mapper should be reported")
+	a.Equal(uint16(0xFF), unsupported.Mapper)
+}
+
+func TestCartridgeArchaicHeader(t *testing.T) {
+	a := assert.New(t)
+	crt := new(core.Cartridge)
+
+	// Mapper 2 with bytes 7-15 overwritten by dumping tool
+	header := append([]uint8{'N', 'E', 'S', 0x1A, 2, 1, 0x20}, []uint8("DiskDude!")...)
+	a.NoError(crt.LoadBytes(createTestRom(header, 0x8000, 0x2000)))
+
+	info := crt.GetRomInfo()
+	a.Equal(uint8(core.RomFormatArchaicINES), info.Format)
+	a.True(info.IsDiskDude)
+	a.Equal(uint16(2), info.Mapper, "Upper nibble of mapper number should be ignored")
+}
+
+func TestCartridgeSmallPrgRom(t *testing.T) {
+	a := assert.New(t)
+	crt := new(core.Cartridge)
+
+	// 2^13 = 8KB of PRG ROM is mirrored in the whole $8000-$FFFF
+	header := []uint8{'N', 'E', 'S', 0x1A, 13 << 2, 0, 0x00, 0x08, 0, 0x0F, 0, 0x07, 0, 0, 0, 0}
+	rom := createTestRom(header, 0x2000, 0)
+	rom[16+0x1FFF] = 0x42
+	a.NoError(crt.LoadBytes(rom))
+
+	data, _ := crt.Read("cpu", 0xFFFF, true)
+	a.Equal(uint8(0x42), data, "PRG ROM should be mirrored")
+}
@@ -1,15 +1,19 @@
 package core
 
+import "github.com/szymonkups/nesgo/core/state"
+
+// Controller - standard NES controller with 8 buttons, plugged into one of InputPorts
+// https://wiki.nesdev.com/w/index.php/Standard_controller
 type Controller struct {
 	buttons [8]bool
-	index   byte
-	strobe  byte
+	index   uint8
+	strobe  bool
 }
 
-type button uint8
+type Button uint8
 
 const (
-	ButtonA button = iota
+	ButtonA Button = iota
 	ButtonB
 	ButtonSelect
 	ButtonStart
@@ -19,40 +23,62 @@ const (
 	ButtonRight
 )
 
-func (c *Controller) Read(_ string, addr uint16, _ bool) (uint8, bool) {
-	if addr == 0x4016 {
-		value := byte(0)
-		if c.index < 8 && c.buttons[c.index] {
-			value = 1
-		}
-		c.index++
-		if c.strobe&1 == 1 {
-			c.index = 0
-		}
-		return value, true
-	}
+// SetStrobe - buttons are reloaded into the shift register while strobe is high
+func (c *Controller) SetStrobe(strobe bool) {
+	c.strobe = strobe
 
-	return 0x00, false
+	if strobe {
+		c.index = 0
+	}
 }
 
-func (c *Controller) Write(_ string, addr uint16, data uint8, _ bool) bool {
-	if addr == 0x4016 {
-		c.strobe = data
+// Read - state of next button in bit 0, official controllers return 1 after all 8 buttons are read
+func (c *Controller) Read() uint8 {
+	if c.index >= 8 {
+		return 0x01
+	}
 
-		if c.strobe&1 == 1 {
-			c.index = 0
-		}
+	value := uint8(0)
+	if c.buttons[c.index] {
+		value = 0x01
+	}
 
-		return true
+	// Shift register is constantly reloaded while strobe is high, so first button is returned
+	if !c.strobe {
+		c.index++
 	}
 
-	return false
+	return value
 }
 
-func (c *Controller) PressButton(button button) {
+// Serialize - saves shift register position, pressed buttons are live input and are not a part of the state
+func (c *Controller) Serialize(w *state.Writer) {
+	w.Uint8(c.index)
+	w.Bool(c.strobe)
+}
+
+func (c *Controller) Deserialize(r *state.Reader) {
+	c.index = r.Uint8()
+	c.strobe = r.Bool()
+}
+
+func (c *Controller) PressButton(button Button) {
 	c.buttons[button] = true
 }
 
-func (c *Controller) ReleaseButton(button button) {
+func (c *Controller) ReleaseButton(button Button) {
 	c.buttons[button] = false
 }
+
+// Pressed buttons as a byte in the order they are read, A in bit 0
+func (c *Controller) getState() uint8 {
+	value := uint8(0)
+
+	for i, pressed := range c.buttons {
+		if pressed {
+			value |= 1 << uint(i)
+		}
+	}
+
+	return value
+}
@@ -3,8 +3,12 @@ package core
 import (
 	"github.com/szymonkups/nesgo/core/flags"
 	"github.com/szymonkups/nesgo/core/instructions"
+	"github.com/szymonkups/nesgo/core/state"
 )
 
+// CPUFrequency - NTSC CPU clock rate in Hz
+const CPUFrequency = 1789773
+
 // CPU represents 6502 processor
 type CPU struct {
 	// Program Counter
@@ -28,16 +32,52 @@ type CPU struct {
 	// Additional cycles to perform on current instruction
 	cyclesLeft uint8
 
+	// Cycles for which CPU is halted by external device (e.g. DMA)
+	stallCycles uint16
+
+	// Number of cycles executed since power up
+	cycles uint64
+
 	// Data bus to which CPU is connected
 	bus *bus
 
-	// If true IRQ will be scheduled
-	isIRQScheduled bool
+	// Sources currently holding IRQ line low. Line is level triggered so it stays active
+	// until all sources release it.
+	irqSources IRQSource
 
-	// If true NMI will be scheduled
+	// If true NMI edge was detected and NMI will be handled after next interrupt poll
 	isNMIScheduled bool
+
+	// Interrupts detected during last poll, handled before next instruction
+	isNMIPending bool
+	isIRQPending bool
+
+	// CLI, SEI and PLP change I flag after interrupts are polled, so their effect is delayed by one
+	// instruction. Previous value of the flag is used when polling if true.
+	isIFlagDelayed bool
+	previousIFlag  bool
+
+	// True during BRK and IRQ sequences before interrupt vector is fetched
+	isHijackable bool
 }
 
+// IRQSource - device that can hold CPU IRQ line
+type IRQSource uint8
+
+const (
+	IRQSourceFrameCounter IRQSource = 1 << iota
+	IRQSourceDMC
+	IRQSourceMapper
+)
+
+// Opcodes that change I flag with one instruction latency
+const (
+	opCodeBRK = 0x00
+	opCodePLP = 0x28
+	opCodeCLI = 0x58
+	opCodeSEI = 0x78
+)
+
 func NewCPU(bus *bus) *CPU {
 	cpu := new(CPU)
 	cpu.bus = bus
@@ -61,6 +101,13 @@ func (cpu *CPU) Reset() {
 	// Where start address is stored
 	cpu.pc = cpu.bus.Read16(0xFFFC)
 
+	// Clear pending interrupts
+	cpu.isNMIScheduled = false
+	cpu.isNMIPending = false
+	cpu.isIRQPending = false
+	cpu.isHijackable = false
+	cpu.isIFlagDelayed = false
+
 	// Assuming that resetting takes time
 	cpu.cyclesLeft = 8
 }
@@ -160,42 +207,116 @@ func (cpu *CPU) AddCycles(c uint8) {
 	cpu.cyclesLeft += c
 }
 
+// Stall - halts CPU for given number of cycles
+func (cpu *CPU) Stall(c uint16) {
+	cpu.stallCycles += c
+}
+
+func (cpu *CPU) GetCycles() uint64 {
+	return cpu.cycles
+}
+
 // Clock - execute single clock cycle
 func (cpu *CPU) Clock() {
+	cpu.cycles++
+
+	if cpu.stallCycles > 0 {
+		cpu.stallCycles--
+		return
+	}
+
+	// NMI hijacking - NMI detected before BRK or IRQ sequence fetches its vector takes over the sequence.
+	// https://wiki.nesdev.com/w/index.php/CPU_interrupts#Interrupt_hijacking
+	if cpu.isHijackable && cpu.isNMIScheduled && cpu.cyclesLeft >= 4 {
+		cpu.isNMIScheduled = false
+		cpu.isHijackable = false
+		cpu.pc = cpu.bus.Read16(0xFFFA)
+	}
+
 	if cpu.cyclesLeft == 0 {
-		// Check for scheduled interrupts
-		if cpu.isNMIScheduled {
+		cpu.isHijackable = false
+
+		switch {
+		case cpu.isNMIPending:
+			cpu.isNMIPending = false
 			cpu.handleInterrupt(0xFFFA)
-			cpu.isNMIScheduled = false
-			return
-		}
 
-		if cpu.isIRQScheduled {
+		case cpu.isIRQPending:
+			cpu.isIRQPending = false
 			cpu.handleInterrupt(0xFFFE)
-			cpu.isIRQScheduled = false
-			return
-		}
+			cpu.isHijackable = true
 
-		// Read opcode
-		opCode := cpu.bus.Read(cpu.pc)
-		err := instructions.ExecuteInstruction(opCode, cpu)
-
-		if err != nil {
-			// TODO: what to do now?
-			panic(err)
+		default:
+			cpu.executeInstruction()
 		}
 	}
 
 	// One cycle done
 	cpu.cyclesLeft--
+
+	// Interrupts are polled during second to last cycle of each instruction
+	// https://wiki.nesdev.com/w/index.php/CPU_interrupts#Detailed_interrupt_behavior
+	if cpu.cyclesLeft == 1 {
+		cpu.pollInterrupts()
+	}
+}
+
+func (cpu *CPU) executeInstruction() {
+	iFlag := cpu.p.Get(flags.I)
+
+	// Read opcode
+	opCode := cpu.bus.Read(cpu.pc)
+	err := instructions.ExecuteInstruction(opCode, cpu)
+
+	if err != nil {
+		// TODO: what to do now?
+		panic(err)
+	}
+
+	cpu.previousIFlag = iFlag
+	cpu.isIFlagDelayed = opCode == opCodeCLI || opCode == opCodeSEI || opCode == opCodePLP
+
+	cpu.isHijackable = opCode == opCodeBRK
+}
+
+func (cpu *CPU) pollInterrupts() {
+	if cpu.isNMIScheduled {
+		cpu.isNMIScheduled = false
+		cpu.isNMIPending = true
+	}
+
+	iFlag := cpu.p.Get(flags.I)
+	if cpu.isIFlagDelayed {
+		iFlag = cpu.previousIFlag
+	}
+
+	cpu.isIRQPending = cpu.irqSources != 0 && !iFlag
+}
+
+// AssertIRQ - source starts holding IRQ line
+func (cpu *CPU) AssertIRQ(source IRQSource) {
+	cpu.irqSources |= source
 }
 
-func (cpu *CPU) scheduleIRQ() {
-	if !cpu.p.Get(flags.I) {
-		cpu.isIRQScheduled = true
+// ReleaseIRQ - source stops holding IRQ line
+func (cpu *CPU) ReleaseIRQ(source IRQSource) {
+	cpu.irqSources &^= source
+}
+
+// SetIRQ - asserts or releases IRQ line for given source
+func (cpu *CPU) SetIRQ(source IRQSource, active bool) {
+	if active {
+		cpu.AssertIRQ(source)
+	} else {
+		cpu.ReleaseIRQ(source)
 	}
 }
 
+func (cpu *CPU) IsIRQAsserted() bool {
+	return cpu.irqSources != 0
+}
+
+// ScheduleNMI - signals NMI edge
 func (cpu *CPU) ScheduleNMI() {
 	cpu.isNMIScheduled = true
 }
@@ -211,6 +332,7 @@ func (cpu *CPU) handleInterrupt(addr uint16) {
 
 	// Disable interrupts
 	cpu.p.Set(flags.I, true)
+	cpu.isIFlagDelayed = false
 
 	// Get new PC
 	cpu.pc = cpu.bus.Read16(addr)
@@ -251,3 +373,42 @@ func (cpu *CPU) Clone() CPU {
 	cpu2 := *cpu
 	return cpu2
 }
+
+// Serialize - saves registers and interrupt state, bus and connected devices are serialized separately
+func (cpu *CPU) Serialize(w *state.Writer) {
+	w.Uint16(cpu.pc)
+	w.Uint8(cpu.sp)
+	w.Uint8(cpu.a)
+	w.Uint8(cpu.x)
+	w.Uint8(cpu.y)
+	w.Uint8(cpu.p.GetByte())
+	w.Uint8(cpu.cyclesLeft)
+	w.Uint16(cpu.stallCycles)
+	w.Uint64(cpu.cycles)
+	w.Uint8(uint8(cpu.irqSources))
+	w.Bool(cpu.isNMIScheduled)
+	w.Bool(cpu.isNMIPending)
+	w.Bool(cpu.isIRQPending)
+	w.Bool(cpu.isIFlagDelayed)
+	w.Bool(cpu.previousIFlag)
+	w.Bool(cpu.isHijackable)
+}
+
+func (cpu *CPU) Deserialize(r *state.Reader) {
+	cpu.pc = r.Uint16()
+	cpu.sp = r.Uint8()
+	cpu.a = r.Uint8()
+	cpu.x = r.Uint8()
+	cpu.y = r.Uint8()
+	cpu.p.SetByte(r.Uint8())
+	cpu.cyclesLeft = r.Uint8()
+	cpu.stallCycles = r.Uint16()
+	cpu.cycles = r.Uint64()
+	cpu.irqSources = IRQSource(r.Uint8())
+	cpu.isNMIScheduled = r.Bool()
+	cpu.isNMIPending = r.Bool()
+	cpu.isIRQPending = r.Bool()
+	cpu.isIFlagDelayed = r.Bool()
+	cpu.previousIFlag = r.Bool()
+	cpu.isHijackable = r.Bool()
+}
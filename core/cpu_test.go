@@ -0,0 +1,178 @@
+package core_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core"
+	"testing"
+)
+
+const (
+	testResetAddr = 0x8000
+	testNMIAddr   = 0x9000
+	testIRQAddr   = 0xA000
+)
+
+// Simple 32KB memory mapped under $8000-$FFFF with interrupt vectors set.
+type testRom struct {
+	data [0x8000]uint8
+}
+
+func (rom *testRom) Read(_ string, addr uint16, _ bool) (uint8, bool) {
+	if addr >= 0x8000 {
+		return rom.data[addr-0x8000], true
+	}
+
+	return 0x00, false
+}
+
+func (rom *testRom) Write(_ string, addr uint16, data uint8, _ bool) bool {
+	return false
+}
+
+func createTestCPU(program ...uint8) *core.CPU {
+	rom := new(testRom)
+
+	// Fill memory with NOPs
+	for i := range rom.data {
+		rom.data[i] = 0xEA
+	}
+
+	copy(rom.data[:], program)
+
+	vectors := map[uint16]uint16{0xFFFA: testNMIAddr, 0xFFFC: testResetAddr, 0xFFFE: testIRQAddr}
+	for addr, v := range vectors {
+		rom.data[addr-0x8000] = uint8(v & 0x00FF)
+		rom.data[addr-0x8000+1] = uint8(v >> 8)
+	}
+
+	bus := core.NewCPUBus()
+	bus.ConnectDevice(new(core.Ram))
+	bus.ConnectDevice(rom)
+
+	return core.NewCPU(bus)
+}
+
+// Runs CPU until next instruction (or interrupt sequence) is finished
+func step(cpu *core.CPU) {
+	cpu.Clock()
+
+	for cpu.GetCyclesLeft() > 0 {
+		cpu.Clock()
+	}
+}
+
+func TestIRQMasked(t *testing.T) {
+	a := assert.New(t)
+	cpu := createTestCPU()
+	cpu.GetStatusFlags().SetByte(0b00100100)
+	cpu.AssertIRQ(core.IRQSourceMapper)
+
+	// Reset sequence
+	step(cpu)
+
+	step(cpu)
+	step(cpu)
+	a.Equal(uint16(testResetAddr+2), cpu.GetPC(), "IRQ should not be handled when I flag is set")
+}
+
+func TestIRQLevelTriggered(t *testing.T) {
+	a := assert.New(t)
+	cpu := createTestCPU()
+	cpu.GetStatusFlags().SetByte(0b00100000)
+	cpu.AssertIRQ(core.IRQSourceMapper)
+	cpu.AssertIRQ(core.IRQSourceDMC)
+
+	// Reset sequence
+	step(cpu)
+	step(cpu)
+	a.Equal(uint16(testIRQAddr), cpu.GetPC(), "IRQ should be handled")
+
+	// Line is still held by both sources
+	cpu.ReleaseIRQ(core.IRQSourceMapper)
+	a.True(cpu.IsIRQAsserted(), "IRQ line should be held until all sources release it")
+
+	cpu.ReleaseIRQ(core.IRQSourceDMC)
+	a.False(cpu.IsIRQAsserted(), "IRQ line should be released")
+}
+
+func TestCLILatency(t *testing.T) {
+	a := assert.New(t)
+	// CLI, NOP, NOP
+	cpu := createTestCPU(0x58, 0xEA, 0xEA)
+	cpu.GetStatusFlags().SetByte(0b00100100)
+	cpu.AssertIRQ(core.IRQSourceMapper)
+
+	// Reset sequence
+	step(cpu)
+
+	// CLI
+	step(cpu)
+	a.Equal(uint16(testResetAddr+1), cpu.GetPC(), "IRQ should not be handled directly after CLI")
+
+	// NOP, then IRQ
+	step(cpu)
+	a.Equal(uint16(testResetAddr+2), cpu.GetPC(), "Instruction after CLI should be executed")
+	step(cpu)
+	a.Equal(uint16(testIRQAddr), cpu.GetPC(), "IRQ should be handled one instruction after CLI")
+}
+
+func TestSEILatency(t *testing.T) {
+	a := assert.New(t)
+	// SEI, NOP
+	cpu := createTestCPU(0x78, 0xEA)
+	cpu.GetStatusFlags().SetByte(0b00100000)
+
+	// Reset sequence
+	step(cpu)
+	cpu.AssertIRQ(core.IRQSourceMapper)
+
+	// SEI, IRQ should still be handled
+	step(cpu)
+	step(cpu)
+	a.Equal(uint16(testIRQAddr), cpu.GetPC(), "IRQ should be handled directly after SEI")
+}
+
+func TestNMIHijacksBRK(t *testing.T) {
+	a := assert.New(t)
+	// BRK
+	cpu := createTestCPU(0x00)
+
+	// Reset sequence
+	step(cpu)
+
+	// First two cycles of BRK then NMI edge
+	cpu.Clock()
+	cpu.Clock()
+	cpu.ScheduleNMI()
+
+	for cpu.GetCyclesLeft() > 0 {
+		cpu.Clock()
+	}
+
+	a.Equal(uint16(testNMIAddr), cpu.GetPC(), "NMI should hijack BRK")
+	step(cpu)
+	a.Equal(uint16(testNMIAddr+1), cpu.GetPC(), "NMI should not be handled twice")
+}
+
+func TestNMIAfterBRK(t *testing.T) {
+	a := assert.New(t)
+	// BRK
+	cpu := createTestCPU(0x00)
+
+	// Reset sequence
+	step(cpu)
+
+	// NMI edge during last cycles of BRK
+	for i := 0; i < 5; i++ {
+		cpu.Clock()
+	}
+	cpu.ScheduleNMI()
+
+	for cpu.GetCyclesLeft() > 0 {
+		cpu.Clock()
+	}
+
+	a.Equal(uint16(testIRQAddr), cpu.GetPC(), "Late NMI should not hijack BRK")
+	step(cpu)
+	a.Equal(uint16(testNMIAddr), cpu.GetPC(), "NMI should be handled after BRK")
+}
@@ -0,0 +1,45 @@
+package core
+
+// DMA - OAM DMA unit exposed on CPU bus under $4014
+// https://wiki.nesdev.com/w/index.php/PPU_registers#OAMDMA
+type DMA struct {
+	bus *bus
+	cpu *CPU
+}
+
+func NewDMA(bus *bus, cpu *CPU) *DMA {
+	return &DMA{bus: bus, cpu: cpu}
+}
+
+func (dma *DMA) Read(_ string, _ uint16, _ bool) (uint8, bool) {
+	// $4014 is write only - let other devices handle reads
+	return 0x00, false
+}
+
+func (dma *DMA) Write(_ string, addr uint16, data uint8, debug bool) bool {
+	if addr != 0x4014 {
+		return false
+	}
+
+	if debug {
+		return true
+	}
+
+	// Copy 256 bytes from $XX00-$XXFF page to OAM trough OAMDATA register
+	page := uint16(data) << 8
+	for i := uint16(0); i < 0x100; i++ {
+		dma.bus.Write(0x2004, dma.bus.Read(page|i))
+	}
+
+	// Transfer takes 513 cycles, plus one alignment cycle when $4014 is written on even CPU cycle. CPU executes
+	// whole instruction on its first cycle, so GetCycles is the cycle STA $4014 was dispatched in and the write happens
+	// on its last cycle, 3 cycles later - odd dispatch cycle means even write cycle.
+	stall := uint16(513)
+	if dma.cpu.GetCycles()%2 == 1 {
+		stall++
+	}
+
+	dma.cpu.Stall(stall)
+
+	return true
+}
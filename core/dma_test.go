@@ -0,0 +1,47 @@
+package core_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Runs program ending with STA $4014 at given address and returns cycle the store was written in and number of cycles
+// until next instruction is dispatched
+func runOAMDMA(t *testing.T, program []uint8, staAddr uint16) (uint64, uint64) {
+	s := createTestSystem(t, program)
+
+	for s.cpu.GetPC() != staAddr || s.cpu.GetCyclesLeft() != 0 {
+		s.cpu.Clock()
+	}
+
+	// Whole instruction is executed on its first cycle, absolute store writes on its 4th cycle
+	s.cpu.Clock()
+	dispatch := s.cpu.GetCycles()
+
+	for s.cpu.GetPC() == staAddr+3 {
+		s.cpu.Clock()
+	}
+
+	return dispatch + 3, s.cpu.GetCycles() - dispatch
+}
+
+func TestOAMDMAStall(t *testing.T) {
+	a := assert.New(t)
+
+	// LDA #$02, STA $4014 and the same preceded by 3 cycle LDA $00, which changes cycle parity
+	first := []uint8{0xA9, 0x02, 0x8D, 0x14, 0x40, 0xEA}
+	second := []uint8{0xA5, 0x00, 0xA9, 0x02, 0x8D, 0x14, 0x40, 0xEA}
+
+	writeCycle1, cycles1 := runOAMDMA(t, first, 0xC002)
+	writeCycle2, cycles2 := runOAMDMA(t, second, 0xC004)
+	a.NotEqual(writeCycle1%2, writeCycle2%2, "Stores should be written on cycles of different parity")
+
+	for writeCycle, cycles := range map[uint64]uint64{writeCycle1: cycles1, writeCycle2: cycles2} {
+		// STA takes 4 cycles, transfer takes 513 cycles and needs alignment cycle after write on even cycle
+		if writeCycle%2 == 0 {
+			a.Equal(uint64(4+514), cycles, "DMA written on even cycle should stall CPU for 514 cycles")
+		} else {
+			a.Equal(uint64(4+513), cycles, "DMA written on odd cycle should stall CPU for 513 cycles")
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package core
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Number of bits read from each Four Score port: two controllers followed by signature
+const fourScoreReportBits = 24
+
+// FourScore - NES Four Score adapter plugged into both controller ports. Each port reports two controllers, first
+// port controllers 1 and 3 and second port controllers 2 and 4, followed by a signature identifying the port.
+// https://wiki.nesdev.com/w/index.php/Four_player_adapters
+type FourScore struct {
+	ports [2]*fourScorePort
+}
+
+type fourScorePort struct {
+	first  *Controller
+	second *Controller
+
+	// Bits 16-23 of the report, in read order 0001 0000 for first port and 0010 0000 for second one
+	signature uint8
+
+	report uint32
+	index  uint8
+	strobe bool
+}
+
+func NewFourScore(controllers [4]*Controller) *FourScore {
+	return &FourScore{
+		ports: [2]*fourScorePort{
+			{first: controllers[0], second: controllers[2], signature: 0b00001000},
+			{first: controllers[1], second: controllers[3], signature: 0b00000100},
+		},
+	}
+}
+
+// GetPort - device which should be connected to InputPorts port 0 or 1
+func (fs *FourScore) GetPort(port int) InputDevice {
+	return fs.ports[port]
+}
+
+func (p *fourScorePort) SetStrobe(strobe bool) {
+	p.strobe = strobe
+
+	if strobe {
+		p.reload()
+	}
+}
+
+// Read - next bit of the report in bit 0, 1 is returned after whole report is read
+func (p *fourScorePort) Read() uint8 {
+	if p.strobe {
+		p.reload()
+	}
+
+	if p.index >= fourScoreReportBits {
+		return 0x01
+	}
+
+	value := uint8(p.report>>p.index) & 0x01
+
+	if !p.strobe {
+		p.index++
+	}
+
+	return value
+}
+
+func (p *fourScorePort) reload() {
+	p.index = 0
+	p.report = uint32(p.first.getState()) | uint32(p.second.getState())<<8 | uint32(p.signature)<<16
+}
+
+// Serialize - latched report is saved too, so interrupted read continues with the same buttons
+func (p *fourScorePort) Serialize(w *state.Writer) {
+	w.Uint32(p.report)
+	w.Uint8(p.index)
+	w.Bool(p.strobe)
+}
+
+func (p *fourScorePort) Deserialize(r *state.Reader) {
+	p.report = r.Uint32()
+	p.index = r.Uint8()
+	p.strobe = r.Bool()
+}
+
+// FamicomFourPlayers - Famicom adapter in simple mode, controllers 3 and 4 are plugged into expansion port and read
+// in bit 1 of $4016 and $4017
+type FamicomFourPlayers struct {
+	controllers [2]*Controller
+}
+
+func NewFamicomFourPlayers(third *Controller, fourth *Controller) *FamicomFourPlayers {
+	return &FamicomFourPlayers{controllers: [2]*Controller{third, fourth}}
+}
+
+func (f *FamicomFourPlayers) SetStrobe(strobe bool) {
+	for _, controller := range f.controllers {
+		controller.SetStrobe(strobe)
+	}
+}
+
+func (f *FamicomFourPlayers) Read(port int) uint8 {
+	return f.controllers[port].Read() << 1
+}
+
+func (f *FamicomFourPlayers) Serialize(w *state.Writer) {
+	for _, controller := range f.controllers {
+		controller.Serialize(w)
+	}
+}
+
+func (f *FamicomFourPlayers) Deserialize(r *state.Reader) {
+	for _, controller := range f.controllers {
+		controller.Deserialize(r)
+	}
+}
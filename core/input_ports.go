@@ -0,0 +1,169 @@
+package core
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// InputDevice - device plugged into one of controller ports
+type InputDevice interface {
+	// SetStrobe - OUT0 line, set by bit 0 of $4016 writes and shared by both ports
+	SetStrobe(strobe bool)
+
+	// Read - data lines D0-D4 of the port, called on every non-debug read of $4016 or $4017
+	Read() uint8
+}
+
+// ExpansionDevice - device plugged into Famicom expansion port, which is wired to both $4016 and $4017
+type ExpansionDevice interface {
+	// SetStrobe - OUT0 line, the same one controller ports get
+	SetStrobe(strobe bool)
+
+	// Read - data lines D1-D4 returned by $4016 (port 0) or $4017 (port 1) reads
+	Read(port int) uint8
+}
+
+// InputPorts - two controller ports exposed on CPU bus, $4016 reads the first one and $4017 the second one.
+// Writes to $4016 are sent to both ports, writes to $4017 belong to APU frame counter.
+// https://wiki.nesdev.com/w/index.php/Input_devices
+type InputPorts struct {
+	devices   [2]InputDevice
+	expansion ExpansionDevice
+	strobe    bool
+}
+
+// Connect - plugs device into port 0 or 1, nil disconnects the port
+func (p *InputPorts) Connect(port int, device InputDevice) {
+	p.devices[port] = device
+
+	if device != nil {
+		device.SetStrobe(p.strobe)
+	}
+}
+
+// GetDevice - device plugged into the port, nil when port is empty
+func (p *InputPorts) GetDevice(port int) InputDevice {
+	return p.devices[port]
+}
+
+// ConnectExpansion - plugs device into expansion port, nil disconnects it
+func (p *InputPorts) ConnectExpansion(device ExpansionDevice) {
+	p.expansion = device
+
+	if device != nil {
+		device.SetStrobe(p.strobe)
+	}
+}
+
+func (p *InputPorts) Read(_ string, addr uint16, debug bool) (uint8, bool) {
+	if addr != 0x4016 && addr != 0x4017 {
+		return 0x00, false
+	}
+
+	// Only lowest 5 bits are driven, the rest keeps last value on data bus, which is high byte of the address
+	// for usual absolute reads
+	data := uint8(addr>>8) & 0b11100000
+
+	if debug {
+		return data, true
+	}
+
+	port := int(addr - 0x4016)
+	if device := p.devices[port]; device != nil {
+		data |= device.Read() & 0b00011111
+	}
+
+	// Expansion port has no D0 line
+	if p.expansion != nil {
+		data |= p.expansion.Read(port) & 0b00011110
+	}
+
+	return data, true
+}
+
+func (p *InputPorts) Write(_ string, addr uint16, data uint8, debug bool) bool {
+	if addr != 0x4016 {
+		return false
+	}
+
+	if !debug {
+		p.strobe = data&0x01 != 0
+
+		for _, device := range p.devices {
+			if device != nil {
+				device.SetStrobe(p.strobe)
+			}
+		}
+
+		if p.expansion != nil {
+			p.expansion.SetStrobe(p.strobe)
+		}
+	}
+
+	return true
+}
+
+// Serialize - saves strobe, kinds of connected devices and state of the ones which keep any
+func (p *InputPorts) Serialize(w *state.Writer) {
+	w.Bool(p.strobe)
+
+	for _, kind := range p.getDeviceKinds() {
+		w.Uint8(kind)
+	}
+
+	for _, device := range p.devices {
+		if serializable, ok := device.(Serializable); ok {
+			serializable.Serialize(w)
+		}
+	}
+
+	if serializable, ok := p.expansion.(Serializable); ok {
+		serializable.Serialize(w)
+	}
+}
+
+// Deserialize - state of devices is restored only when the same kinds of devices are connected, otherwise
+// ErrSaveStateInputDevices is reported
+func (p *InputPorts) Deserialize(r *state.Reader) {
+	p.strobe = r.Bool()
+
+	for _, kind := range p.getDeviceKinds() {
+		if r.Uint8() != kind {
+			r.Fail(ErrSaveStateInputDevices)
+			return
+		}
+	}
+
+	for _, device := range p.devices {
+		if serializable, ok := device.(Serializable); ok {
+			serializable.Deserialize(r)
+		}
+	}
+
+	if serializable, ok := p.expansion.(Serializable); ok {
+		serializable.Deserialize(r)
+	}
+}
+
+// Kinds of devices connected to both ports and expansion port, 0 is an empty port
+func (p *InputPorts) getDeviceKinds() [3]uint8 {
+	return [3]uint8{getDeviceKind(p.devices[0]), getDeviceKind(p.devices[1]), getDeviceKind(p.expansion)}
+}
+
+func getDeviceKind(device interface{}) uint8 {
+	switch device.(type) {
+	case nil:
+		return 0
+	case *Controller:
+		return 1
+	case *fourScorePort:
+		return 2
+	case *Zapper:
+		return 3
+	case *Vaus:
+		return 4
+	case *FamicomFourPlayers:
+		return 5
+	case *FamicomVaus:
+		return 6
+	default:
+		return 0xFF
+	}
+}
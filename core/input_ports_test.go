@@ -0,0 +1,183 @@
+package core_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core"
+	"testing"
+)
+
+func readPort(ports *core.InputPorts, addr uint16) uint8 {
+	data, _ := ports.Read("cpu", addr, false)
+	return data
+}
+
+func TestInputPortsControllers(t *testing.T) {
+	a := assert.New(t)
+	ports := new(core.InputPorts)
+	controller1 := new(core.Controller)
+	controller2 := new(core.Controller)
+	ports.Connect(0, controller1)
+	ports.Connect(1, controller2)
+
+	controller1.PressButton(core.ButtonA)
+	controller2.PressButton(core.ButtonB)
+
+	// Strobe is shared by both ports
+	ports.Write("cpu", 0x4016, 1, false)
+	a.Equal(uint8(0x41), readPort(ports, 0x4016), "A should be read while strobe is high")
+	a.Equal(uint8(0x41), readPort(ports, 0x4016), "Shift register should not move while strobe is high")
+	ports.Write("cpu", 0x4016, 0, false)
+
+	var buttons1, buttons2 []uint8
+	for i := 0; i < 8; i++ {
+		buttons1 = append(buttons1, readPort(ports, 0x4016)&0x01)
+		buttons2 = append(buttons2, readPort(ports, 0x4017)&0x01)
+	}
+
+	a.Equal([]uint8{1, 0, 0, 0, 0, 0, 0, 0}, buttons1, "First controller should report A")
+	a.Equal([]uint8{0, 1, 0, 0, 0, 0, 0, 0}, buttons2, "Second controller should report B")
+	a.Equal(uint8(0x41), readPort(ports, 0x4017), "Controller should return 1 after all buttons are read")
+}
+
+func TestInputPortsOpenBus(t *testing.T) {
+	a := assert.New(t)
+	ports := new(core.InputPorts)
+
+	a.Equal(uint8(0x40), readPort(ports, 0x4016), "Empty port should leave open bus")
+	a.Equal(uint8(0x40), readPort(ports, 0x4017), "Empty port should leave open bus")
+	a.False(ports.Write("cpu", 0x4017, 0, false), "$4017 writes should be left for APU")
+}
+
+func readBits(ports *core.InputPorts, addr uint16, mask uint8, count int) []uint8 {
+	var bits []uint8
+	for i := 0; i < count; i++ {
+		if readPort(ports, addr)&mask != 0 {
+			bits = append(bits, 1)
+		} else {
+			bits = append(bits, 0)
+		}
+	}
+
+	return bits
+}
+
+func TestInputPortsFourScore(t *testing.T) {
+	a := assert.New(t)
+	ports := new(core.InputPorts)
+	controllers := [4]*core.Controller{new(core.Controller), new(core.Controller), new(core.Controller), new(core.Controller)}
+	fourScore := core.NewFourScore(controllers)
+	ports.Connect(0, fourScore.GetPort(0))
+	ports.Connect(1, fourScore.GetPort(1))
+
+	controllers[0].PressButton(core.ButtonA)
+	controllers[1].PressButton(core.ButtonB)
+	controllers[2].PressButton(core.ButtonStart)
+	controllers[3].PressButton(core.ButtonRight)
+
+	ports.Write("cpu", 0x4016, 1, false)
+	ports.Write("cpu", 0x4016, 0, false)
+
+	a.Equal([]uint8{
+		1, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 1, 0, 0, 0, 0,
+		0, 0, 0, 1, 0, 0, 0, 0,
+		1, 1,
+	}, readBits(ports, 0x4016, 0x01, 26), "First port should report controllers 1 and 3 followed by signature")
+
+	a.Equal([]uint8{
+		0, 1, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 1,
+		0, 0, 1, 0, 0, 0, 0, 0,
+		1, 1,
+	}, readBits(ports, 0x4017, 0x01, 26), "Second port should report controllers 2 and 4 followed by signature")
+}
+
+func TestInputPortsFamicomFourPlayers(t *testing.T) {
+	a := assert.New(t)
+	ports := new(core.InputPorts)
+	controllers := [4]*core.Controller{new(core.Controller), new(core.Controller), new(core.Controller), new(core.Controller)}
+	ports.Connect(0, controllers[0])
+	ports.Connect(1, controllers[1])
+	ports.ConnectExpansion(core.NewFamicomFourPlayers(controllers[2], controllers[3]))
+
+	controllers[0].PressButton(core.ButtonA)
+	controllers[2].PressButton(core.ButtonB)
+	controllers[3].PressButton(core.ButtonSelect)
+
+	ports.Write("cpu", 0x4016, 1, false)
+	ports.Write("cpu", 0x4016, 0, false)
+
+	a.Equal(uint8(0x41), readPort(ports, 0x4016), "A of controller 1 should be read in bit 0")
+	a.Equal(uint8(0x42), readPort(ports, 0x4016), "B of controller 3 should be read in bit 1")
+	a.Equal([]uint8{0, 0, 1, 0, 0, 0, 0, 0}, readBits(ports, 0x4017, 0x02, 8), "Controller 4 should be read in bit 1")
+
+	data, _ := ports.Read("cpu", 0x4017, true)
+	a.Equal(uint8(0x40), data, "Debug reads should not shift expansion controllers")
+}
+
+func TestInputPortsZapper(t *testing.T) {
+	a := assert.New(t)
+	ppu := core.NewPPU(core.NewPPUBus())
+	ppu.SetDrawMethod(func(x, y int16, pixel *core.PPUColor) {})
+	zapper := core.NewZapper(ppu)
+	ports := new(core.InputPorts)
+	ports.Connect(1, zapper)
+
+	runToScanLine := func(scanLine int16) {
+		for ppu.GetCurrentScanLine() != scanLine {
+			ppu.Clock()
+		}
+	}
+
+	zapper.Aim(100, 50)
+	zapper.DrawPixel(100, 50, &core.PPUColor{R: 0xFF, G: 0xFF, B: 0xFF})
+
+	runToScanLine(40)
+	a.Equal(uint8(0x48), readPort(ports, 0x4017), "Light should not be sensed before beam draws aimed pixel")
+
+	runToScanLine(55)
+	a.Equal(uint8(0x40), readPort(ports, 0x4017), "Light should be sensed after beam draws aimed pixel")
+
+	zapper.SetTrigger(true)
+	a.Equal(uint8(0x50), readPort(ports, 0x4017), "Trigger should be reported in bit 4")
+
+	runToScanLine(100)
+	a.Equal(uint8(0x58), readPort(ports, 0x4017), "Light should fade out")
+
+	zapper.SetTrigger(false)
+	zapper.Aim(100, 300)
+	runToScanLine(55)
+	a.Equal(uint8(0x48), readPort(ports, 0x4017), "Light should not be sensed when gun points away from the screen")
+
+	zapper.Aim(100, 50)
+	zapper.DrawPixel(100, 50, &core.PPUColor{R: 0x20, G: 0x20, B: 0x20})
+	a.Equal(uint8(0x48), readPort(ports, 0x4017), "Dark pixels should not be sensed")
+}
+
+func TestInputPortsVaus(t *testing.T) {
+	a := assert.New(t)
+	ports := new(core.InputPorts)
+	vaus := new(core.Vaus)
+	ports.Connect(1, vaus)
+
+	vaus.SetPosition(0)
+	vaus.SetButton(true)
+	ports.Write("cpu", 0x4016, 1, false)
+	ports.Write("cpu", 0x4016, 0, false)
+	vaus.SetPosition(0xFF)
+
+	// Leftmost position 0x62 is sent inverted, MSB first
+	a.Equal([]uint8{1, 0, 0, 1, 1, 1, 0, 1, 1, 1}, readBits(ports, 0x4017, 0x08, 10), "Latched position should be read in bit 3")
+	a.Equal(uint8(0x58), readPort(ports, 0x4017), "Button should be read in bit 4")
+
+	famicomVaus := new(core.FamicomVaus)
+	ports.Connect(1, nil)
+	ports.ConnectExpansion(famicomVaus)
+
+	famicomVaus.SetPosition(0xFF)
+	ports.Write("cpu", 0x4016, 1, false)
+	ports.Write("cpu", 0x4016, 0, false)
+
+	a.Equal(uint8(0x40), readPort(ports, 0x4016), "Released button should be read in bit 1 of $4016")
+	a.Equal([]uint8{0, 0, 0, 0, 1, 1, 0, 1}, readBits(ports, 0x4017, 0x02, 8), "Rightmost position 0xF2 should be read in bit 1 of $4017")
+}
@@ -0,0 +1,148 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Board - cartridge memory and configuration read from file header, passed to mapper on initialization
+type Board struct {
+	PrgMem []uint8
+
+	// CHR ROM, empty when board uses CHR RAM
+	ChrMem []uint8
+
+	// Mirroring set in file header, mappers controlling mirroring can ignore it
+	Mirroring uint8
+
+	// Board variant from NES 2.0 header, 0 means variant is unknown
+	Submapper uint8
+
+	// Memory kept in save file, mappers attach to it PRG RAM when board has battery and EEPROM.
+	// It's nil when nothing is saved (e.g. in tests).
+	HasBattery bool
+	SaveRAM    *SaveRAM
+
+	// RAM sizes in bytes are known only from NES 2.0 header, otherwise mappers use usual sizes of their boards
+	HasRamSizes  bool
+	PrgRamSize   int
+	PrgNvRamSize int
+	ChrRamSize   int
+	ChrNvRamSize int
+}
+
+// Number of 16KB PRG ROM banks
+func (b Board) prgRomBanks() int {
+	return len(b.PrgMem) / 0x4000
+}
+
+// Number of 8KB CHR ROM banks, 0 when board uses CHR RAM
+func (b Board) chrRomBanks() int {
+	return len(b.ChrMem) / 0x2000
+}
+
+// PRG RAM including its battery backed part, defaultSize is used when header doesn't specify it. Battery backed part
+// comes first and only it is saved, whole RAM is saved when header doesn't tell which part is volatile.
+func (b Board) newPrgRam(defaultSize int) prgRam {
+	size := defaultSize
+	nvSize := defaultSize
+	if b.HasRamSizes {
+		size = b.PrgRamSize + b.PrgNvRamSize
+		nvSize = b.PrgNvRamSize
+	}
+
+	ram := make(prgRam, size)
+
+	if b.HasBattery && nvSize > 0 {
+		b.attachSaveRAM(ram[:nvSize])
+	}
+
+	return ram
+}
+
+func (b Board) attachSaveRAM(memory []uint8) {
+	if b.SaveRAM != nil {
+		b.SaveRAM.regions = append(b.SaveRAM.regions, memory)
+	}
+}
+
+// CHR ROM or new CHR RAM when board has no CHR ROM, 8KB unless header says otherwise. Mappers bank CHR memory in
+// 8KB units at most, so declared size is rounded up to whole 8KB banks.
+func (b Board) getChrMem() []uint8 {
+	if len(b.ChrMem) > 0 {
+		return b.ChrMem
+	}
+
+	size := 0x2000
+	if b.HasRamSizes && b.ChrRamSize+b.ChrNvRamSize > size {
+		size = (b.ChrRamSize + b.ChrNvRamSize + 0x1FFF) &^ 0x1FFF
+	}
+
+	return make([]uint8, size)
+}
+
+// CHR RAM is a part of saved state, CHR ROM is not
+func writeChrRam(w *state.Writer, chrMem []uint8, chrRomBanks int) {
+	if chrRomBanks == 0 {
+		w.Bytes(chrMem)
+	}
+}
+
+func readChrRam(r *state.Reader, chrMem []uint8, chrRomBanks int) {
+	if chrRomBanks == 0 {
+		r.Bytes(chrMem)
+	}
+}
+
+// PRG RAM mapped at $6000-$7FFF, mirrored when smaller than 8KB. Boards without PRG RAM leave open bus there.
+type prgRam []uint8
+
+func (r prgRam) read(addr uint16) (uint8, bool) {
+	if len(r) == 0 {
+		return 0, false
+	}
+
+	return r[int(addr&0x1FFF)%len(r)], true
+}
+
+func (r prgRam) write(addr uint16, data uint8) bool {
+	if len(r) == 0 {
+		return false
+	}
+
+	r[int(addr&0x1FFF)%len(r)] = data
+	return true
+}
+
+// SaveRAM - memory which keeps its content when console is off (battery backed RAM or EEPROM), made of all memory
+// regions mappers attached to it
+type SaveRAM struct {
+	regions [][]uint8
+}
+
+// Size - total size of attached memory, 0 when board has nothing to save
+func (s *SaveRAM) Size() int {
+	size := 0
+
+	for _, region := range s.regions {
+		size += len(region)
+	}
+
+	return size
+}
+
+// GetData - copy of attached memory
+func (s *SaveRAM) GetData() []uint8 {
+	data := make([]uint8, 0, s.Size())
+
+	for _, region := range s.regions {
+		data = append(data, region...)
+	}
+
+	return data
+}
+
+// SetData - restores attached memory, data of different size is cut or left partially restored
+func (s *SaveRAM) SetData(data []uint8) {
+	for _, region := range s.regions {
+		n := copy(region, data)
+		data = data[n:]
+	}
+}
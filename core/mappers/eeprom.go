@@ -0,0 +1,234 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+const (
+	eepromIdle = iota
+	eepromDeviceAddress
+	eepromWordAddress
+	eepromWrite
+	eepromRead
+)
+
+// Serial EEPROM (24C01 or 24C02) used by Bandai boards for saves, accessed by bit banging I2C lines.
+// 24C01 has no device address, address byte holds read/write bit instead and bits are sent LSB first.
+// https://wiki.nesdev.com/w/index.php/Bandai_FCG_board#Serial_EEPROM
+type eeprom struct {
+	data     []uint8
+	is24C01  bool
+	pageMask uint8
+
+	// Lines as last driven by the CPU, output is SDA driven by EEPROM (true when released)
+	scl    bool
+	sda    bool
+	output bool
+
+	state     uint8
+	nextState uint8
+	bit       uint8
+	value     uint8
+	address   uint8
+	masterAck bool
+}
+
+func newEEPROM24C01() *eeprom {
+	return &eeprom{data: make([]uint8, 0x80), is24C01: true, pageMask: 0x03, output: true}
+}
+
+func newEEPROM24C02() *eeprom {
+	return &eeprom{data: make([]uint8, 0x100), pageMask: 0x07, output: true}
+}
+
+// Updates lines driven by the CPU
+func (e *eeprom) write(scl, sda bool) {
+	if e.scl && scl && e.sda != sda {
+		if !sda {
+			e.start()
+		} else {
+			e.stop()
+		}
+	} else if !e.scl && scl {
+		e.clockRise(sda)
+	} else if e.scl && !scl {
+		e.clockFall()
+	}
+
+	e.scl = scl
+	e.sda = sda
+}
+
+func (e *eeprom) read() bool {
+	return e.output
+}
+
+// Start condition - SDA falls while SCL is high
+func (e *eeprom) start() {
+	e.state = eepromDeviceAddress
+	if e.is24C01 {
+		e.state = eepromWordAddress
+	}
+
+	e.bit = 0
+	e.value = 0
+	e.output = true
+}
+
+// Stop condition - SDA rises while SCL is high
+func (e *eeprom) stop() {
+	e.state = eepromIdle
+	e.output = true
+}
+
+// Master samples SDA on rising edge of SCL
+func (e *eeprom) clockRise(sda bool) {
+	switch e.state {
+	case eepromIdle:
+		return
+
+	case eepromRead:
+		if e.bit < 8 {
+			e.bit++
+		} else if e.bit == 8 {
+			// Master acknowledges with low SDA when it wants next byte
+			e.masterAck = !sda
+			e.bit++
+		}
+
+	default:
+		if e.bit < 8 {
+			if e.is24C01 {
+				if sda {
+					e.value |= 1 << e.bit
+				}
+			} else {
+				e.value <<= 1
+				if sda {
+					e.value |= 0x01
+				}
+			}
+
+			e.bit++
+		}
+	}
+}
+
+// Data changes on falling edge of SCL
+func (e *eeprom) clockFall() {
+	switch e.state {
+	case eepromIdle:
+		return
+
+	case eepromRead:
+		switch {
+		case e.bit < 8:
+			e.output = e.getOutputBit()
+		case e.bit == 8:
+			// Release SDA for master's acknowledge
+			e.output = true
+		case e.masterAck:
+			e.address = e.nextAddress(e.address, 0xFF)
+			e.bit = 0
+			e.output = e.getOutputBit()
+		default:
+			e.state = eepromIdle
+		}
+
+	default:
+		switch e.bit {
+		case 8:
+			if !e.processByte() {
+				e.state = eepromIdle
+				return
+			}
+
+			// Acknowledge received byte
+			e.output = false
+			e.bit = 9
+		case 9:
+			e.output = true
+			e.bit = 0
+			e.value = 0
+			e.state = e.nextState
+
+			if e.state == eepromRead {
+				e.output = e.getOutputBit()
+			}
+		}
+	}
+}
+
+// Returns false when byte is not acknowledged
+func (e *eeprom) processByte() bool {
+	switch e.state {
+	case eepromDeviceAddress:
+		if e.value&0xF0 != 0xA0 {
+			return false
+		}
+
+		e.nextState = eepromWordAddress
+		if e.value&0x01 != 0 {
+			e.nextState = eepromRead
+		}
+
+	case eepromWordAddress:
+		e.nextState = eepromWrite
+
+		if e.is24C01 {
+			e.address = e.value & 0x7F
+
+			if e.value&0x80 != 0 {
+				e.nextState = eepromRead
+			}
+		} else {
+			e.address = e.value
+		}
+
+	case eepromWrite:
+		// Address wraps within a page during writes
+		e.data[int(e.address)%len(e.data)] = e.value
+		e.address = e.nextAddress(e.address, e.pageMask)
+		e.nextState = eepromWrite
+	}
+
+	return true
+}
+
+func (e *eeprom) nextAddress(address uint8, mask uint8) uint8 {
+	return address&^mask | (address+1)&mask
+}
+
+func (e *eeprom) getOutputBit() bool {
+	data := e.data[int(e.address)%len(e.data)]
+
+	if e.is24C01 {
+		return data&(1<<e.bit) != 0
+	}
+
+	return data&(0x80>>e.bit) != 0
+}
+
+func (e *eeprom) serialize(w *state.Writer) {
+	w.Bytes(e.data)
+	w.Bool(e.scl)
+	w.Bool(e.sda)
+	w.Bool(e.output)
+	w.Uint8(e.state)
+	w.Uint8(e.nextState)
+	w.Uint8(e.bit)
+	w.Uint8(e.value)
+	w.Uint8(e.address)
+	w.Bool(e.masterAck)
+}
+
+func (e *eeprom) deserialize(r *state.Reader) {
+	r.Bytes(e.data)
+	e.scl = r.Bool()
+	e.sda = r.Bool()
+	e.output = r.Bool()
+	e.state = r.Uint8()
+	e.nextState = r.Uint8()
+	e.bit = r.Uint8()
+	e.value = r.Uint8()
+	e.address = r.Uint8()
+	e.masterAck = r.Bool()
+}
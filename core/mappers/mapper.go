@@ -1,8 +1,60 @@
 package mappers
 
+import "github.com/szymonkups/nesgo/core/state"
+
 type Mapper interface {
-	Initialize(prgRomBanks uint8, chrRomBanks uint8, prgMem []uint8, chrMem []uint8)
+	Initialize(board Board)
 
 	Read(busId string, addr uint16, debug bool) (uint8, bool)
 	Write(busId string, addr uint16, data uint8, debug bool) bool
+
+	// GetMirroring - current nametable mirroring, can change at runtime
+	GetMirroring() uint8
+
+	// Serialize and Deserialize - save and restore registers and RAM of the board, ROM is not a part of the state
+	Serialize(w *state.Writer)
+	Deserialize(r *state.Reader)
+}
+
+// PPUAddressListener - optional, implemented by mappers watching PPU address lines (e.g. MMC3 scanline counter).
+// Cycle is number of PPU cycles since power up, so mappers can filter out short pulses.
+type PPUAddressListener interface {
+	OnPPUAddress(addr uint16, cycle uint64)
+}
+
+// IRQGenerator - optional, implemented by mappers which can assert CPU IRQ line
+type IRQGenerator interface {
+	IsIRQAsserted() bool
+}
+
+// Kinds of PPU rendering fetches reported to RenderingListener
+const (
+	FetchNameTable = iota
+	FetchAttribute
+	FetchBackgroundPattern
+	FetchSpritePattern
+)
+
+// RenderingListener - optional, implemented by mappers substituting rendering data depending on what PPU fetches (MMC5).
+// It's called before each rendering fetch, scan line is the one fetched data will be displayed on and tile is
+// background tile column (0-33) or sprite slot (0-7).
+type RenderingListener interface {
+	OnRenderingFetch(kind uint8, scanLine int16, tile uint8)
+}
+
+// NameTableMapper - optional, implemented by mappers which select console VRAM page for each nametable separately.
+// Mapper can still provide nametable data by itself by handling PPU bus reads of $2000-$2FFF.
+type NameTableMapper interface {
+	GetNameTablePage(index uint8) uint8
+}
+
+// CPUClocked - optional, implemented by mappers with counters clocked by CPU (e.g. VRC IRQ), called every CPU cycle
+type CPUClocked interface {
+	ClockCPU()
+}
+
+// ExpansionAudio - optional, implemented by mappers with additional sound channels mixed with APU output
+type ExpansionAudio interface {
+	// AudioOutput - current level on the same scale as APU output, where 1.0 is APU maximum
+	AudioOutput() float32
 }
@@ -1,29 +1,28 @@
 package mappers
 
+import "github.com/szymonkups/nesgo/core/state"
+
 type Mapper0 struct {
-	prgRomBanks uint8
-	chrRomBanks uint8
+	prgRomBanks int
+	chrRomBanks int
 	prgMem      []uint8
 	chrMem      []uint8
-	sRam        [0x2000]uint8
+	sRam        prgRam
+	mirroring   uint8
 }
 
-func (mpr *Mapper0) Initialize(prgRomSize uint8, chrRomSize uint8, prgMem []uint8, chrMem []uint8) {
-	mpr.prgRomBanks = prgRomSize
-	mpr.chrRomBanks = chrRomSize
-	mpr.prgMem = prgMem
-
-	if chrRomSize > 0 {
-		mpr.chrMem = chrMem
-	} else {
-		mpr.chrMem = make([]uint8, 0x2000)
-	}
-
+func (mpr *Mapper0) Initialize(board Board) {
+	mpr.mirroring = board.Mirroring
+	mpr.prgRomBanks = board.prgRomBanks()
+	mpr.chrRomBanks = board.chrRomBanks()
+	mpr.prgMem = board.PrgMem
+	mpr.chrMem = board.getChrMem()
+	mpr.sRam = board.newPrgRam(0x2000)
 }
 
 func (mpr *Mapper0) Read(busId string, addr uint16, _ bool) (uint8, bool) {
 	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 {
-		return mpr.sRam[addr-0x6000], true
+		return mpr.sRam.read(addr)
 	}
 
 	if busId == "cpu" && addr >= 0x8000 {
@@ -39,8 +38,7 @@ func (mpr *Mapper0) Read(busId string, addr uint16, _ bool) (uint8, bool) {
 
 func (mpr *Mapper0) Write(busId string, addr uint16, data uint8, _ bool) bool {
 	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 {
-		mpr.sRam[addr-0x6000] = data
-		return true
+		return mpr.sRam.write(addr, data)
 	}
 
 	if busId == "cpu" && addr >= 0x8000 {
@@ -58,6 +56,21 @@ func (mpr *Mapper0) Write(busId string, addr uint16, data uint8, _ bool) bool {
 	return false
 }
 
+// GetMirroring - mirroring is hardwired on the board
+func (mpr *Mapper0) GetMirroring() uint8 {
+	return mpr.mirroring
+}
+
+func (mpr *Mapper0) Serialize(w *state.Writer) {
+	w.Bytes(mpr.sRam)
+	writeChrRam(w, mpr.chrMem, mpr.chrRomBanks)
+}
+
+func (mpr *Mapper0) Deserialize(r *state.Reader) {
+	r.Bytes(mpr.sRam)
+	readChrRam(r, mpr.chrMem, mpr.chrRomBanks)
+}
+
 func (mpr *Mapper0) getMappedAddress(addr uint16) uint16 {
 	if mpr.prgRomBanks > 1 {
 		// 32KB
@@ -0,0 +1,57 @@
+package mappers_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"testing"
+)
+
+func TestMapper0PrgRam(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper0)
+
+	// iNES header doesn't say, board gets 8KB
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(1)})
+	mpr.Write("cpu", 0x7FFF, 0x55, false)
+	a.Equal(uint8(0x55), readCPU(mpr, 0x7FFF), "PRG RAM should be mapped at $6000-$7FFF")
+
+	// NES 2.0 header with 2KB of PRG RAM - mirrored
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(1), HasRamSizes: true, PrgRamSize: 0x800})
+	mpr.Write("cpu", 0x6001, 0x42, false)
+	a.Equal(uint8(0x42), readCPU(mpr, 0x7801), "PRG RAM should be mirrored")
+
+	// NES 2.0 header without PRG RAM - open bus
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(1), HasRamSizes: true})
+	a.False(mpr.Write("cpu", 0x6000, 0x42, false), "Write without PRG RAM should not be handled")
+	_, handled := mpr.Read("cpu", 0x6000, false)
+	a.False(handled, "Read without PRG RAM should not be handled")
+}
+
+func TestMapper0BatteryBackedRam(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper0)
+
+	saveRam := new(mappers.SaveRAM)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(1), SaveRAM: saveRam})
+	a.Equal(0, saveRam.Size(), "PRG RAM without battery should not be saved")
+
+	saveRam = new(mappers.SaveRAM)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(1), HasBattery: true, SaveRAM: saveRam})
+	a.Equal(0x2000, saveRam.Size(), "Battery backed PRG RAM should be saved")
+
+	mpr.Write("cpu", 0x6001, 0x42, false)
+	a.Equal(uint8(0x42), saveRam.GetData()[1], "Save RAM should reflect PRG RAM")
+
+	saveRam.SetData([]uint8{0x01, 0x02})
+	a.Equal(uint8(0x02), readCPU(mpr, 0x6001), "Restored save should be visible in PRG RAM")
+
+	// NES 2.0 header with 2KB of PRG NVRAM followed by 4KB of volatile PRG RAM
+	saveRam = new(mappers.SaveRAM)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(1), HasBattery: true, SaveRAM: saveRam, HasRamSizes: true, PrgRamSize: 0x1000, PrgNvRamSize: 0x800})
+	a.Equal(0x800, saveRam.Size(), "Only battery backed part of PRG RAM should be saved")
+
+	mpr.Write("cpu", 0x6001, 0x42, false)
+	mpr.Write("cpu", 0x6801, 0x43, false)
+	a.Equal(uint8(0x42), saveRam.GetData()[1], "Save RAM should reflect battery backed PRG RAM")
+	a.NotContains(saveRam.GetData(), uint8(0x43), "Volatile PRG RAM should not be saved")
+}
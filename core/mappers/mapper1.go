@@ -0,0 +1,208 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Mapper1 - MMC1
+// https://wiki.nesdev.com/w/index.php/MMC1
+type Mapper1 struct {
+	prgRomBanks int
+	chrRomBanks int
+	prgMem      []uint8
+	chrMem      []uint8
+	sRam        prgRam
+
+	// Serial port - value is written bit by bit, bit 4 is set when register is empty
+	shiftRegister uint8
+
+	control  uint8
+	chrBank0 uint8
+	chrBank1 uint8
+	prgBank  uint8
+}
+
+func (mpr *Mapper1) Initialize(board Board) {
+	mpr.prgRomBanks = board.prgRomBanks()
+	mpr.chrRomBanks = board.chrRomBanks()
+	mpr.prgMem = board.PrgMem
+	mpr.chrMem = board.getChrMem()
+	mpr.sRam = board.newPrgRam(0x2000)
+
+	mpr.shiftRegister = 0b10000
+
+	// Power up in PRG mode 3 - last bank fixed at $C000
+	mpr.control = 0b01100
+	mpr.chrBank0 = 0
+	mpr.chrBank1 = 0
+	mpr.prgBank = 0
+}
+
+func (mpr *Mapper1) Read(busId string, addr uint16, _ bool) (uint8, bool) {
+	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 {
+		if !mpr.isPrgRamEnabled() {
+			return 0, false
+		}
+
+		return mpr.sRam.read(addr)
+	}
+
+	if busId == "cpu" && addr >= 0x8000 {
+		return mpr.prgMem[mpr.getPrgAddress(addr)], true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		return mpr.chrMem[mpr.getChrAddress(addr)], true
+	}
+
+	return 0, false
+}
+
+func (mpr *Mapper1) Write(busId string, addr uint16, data uint8, debug bool) bool {
+	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 {
+		if !mpr.isPrgRamEnabled() {
+			return false
+		}
+
+		return mpr.sRam.write(addr, data)
+	}
+
+	if busId == "cpu" && addr >= 0x8000 {
+		if !debug {
+			mpr.writeShiftRegister(addr, data)
+		}
+
+		return true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		if mpr.chrRomBanks == 0 {
+			mpr.chrMem[mpr.getChrAddress(addr)] = data
+			return true
+		}
+	}
+
+	return false
+}
+
+func (mpr *Mapper1) writeShiftRegister(addr uint16, data uint8) {
+	// Writing value with bit 7 set clears shift register and locks last PRG bank at $C000
+	if data&0b10000000 != 0 {
+		mpr.shiftRegister = 0b10000
+		mpr.control |= 0b01100
+		return
+	}
+
+	// Shift register is full when initial bit reached bit 0
+	isFull := mpr.shiftRegister&0b00001 != 0
+	mpr.shiftRegister = (mpr.shiftRegister >> 1) | ((data & 0b00001) << 4)
+
+	if !isFull {
+		return
+	}
+
+	// Register is selected by bits 13 and 14 of address on fifth write
+	value := mpr.shiftRegister
+	switch (addr >> 13) & 0x03 {
+	case 0:
+		mpr.control = value
+	case 1:
+		mpr.chrBank0 = value
+	case 2:
+		mpr.chrBank1 = value
+	case 3:
+		mpr.prgBank = value
+	}
+
+	mpr.shiftRegister = 0b10000
+}
+
+// GetMirroring - mirroring is controlled by two lowest bits of control register
+func (mpr *Mapper1) GetMirroring() uint8 {
+	switch mpr.control & 0b00011 {
+	case 0:
+		return MirroringSingleScreenLow
+	case 1:
+		return MirroringSingleScreenHigh
+	case 2:
+		return MirroringVertical
+	default:
+		return MirroringHorizontal
+	}
+}
+
+func (mpr *Mapper1) Serialize(w *state.Writer) {
+	w.Bytes(mpr.sRam)
+	writeChrRam(w, mpr.chrMem, mpr.chrRomBanks)
+	w.Uint8(mpr.shiftRegister)
+	w.Uint8(mpr.control)
+	w.Uint8(mpr.chrBank0)
+	w.Uint8(mpr.chrBank1)
+	w.Uint8(mpr.prgBank)
+}
+
+func (mpr *Mapper1) Deserialize(r *state.Reader) {
+	r.Bytes(mpr.sRam)
+	readChrRam(r, mpr.chrMem, mpr.chrRomBanks)
+	mpr.shiftRegister = r.Uint8()
+	mpr.control = r.Uint8()
+	mpr.chrBank0 = r.Uint8()
+	mpr.chrBank1 = r.Uint8()
+	mpr.prgBank = r.Uint8()
+}
+
+func (mpr *Mapper1) isPrgRamEnabled() bool {
+	return mpr.prgBank&0b10000 == 0
+}
+
+func (mpr *Mapper1) getPrgAddress(addr uint16) int {
+	bankCount := mpr.prgRomBanks
+	bank := int(mpr.prgBank & 0b01111)
+
+	// 512KB boards (SUROM) use bit 4 of CHR bank register to select 256KB PRG half
+	outer := 0
+	if bankCount > 16 {
+		outer = int(mpr.chrBank0 & 0b10000)
+		bankCount = 16
+	}
+
+	var selected int
+	switch (mpr.control >> 2) & 0b11 {
+	case 0, 1:
+		// 32KB mode - lowest bit of bank number is ignored
+		selected = bank&0b11110 | int(addr>>14)&0x01
+	case 2:
+		// First bank fixed at $8000, switch bank at $C000
+		if addr < 0xC000 {
+			selected = 0
+		} else {
+			selected = bank
+		}
+	case 3:
+		// Switch bank at $8000, last bank fixed at $C000
+		if addr < 0xC000 {
+			selected = bank
+		} else {
+			selected = bankCount - 1
+		}
+	}
+
+	selected = outer + selected%bankCount
+
+	return selected*0x4000 + int(addr&0x3FFF)
+}
+
+func (mpr *Mapper1) getChrAddress(addr uint16) int {
+	// Number of 4KB banks
+	bankCount := len(mpr.chrMem) / 0x1000
+
+	var bank int
+	if mpr.control&0b10000 == 0 {
+		// 8KB mode - lowest bit of bank number is ignored
+		bank = int(mpr.chrBank0&0b11110) | int(addr>>12)&0x01
+	} else if addr < 0x1000 {
+		bank = int(mpr.chrBank0)
+	} else {
+		bank = int(mpr.chrBank1)
+	}
+
+	return (bank%bankCount)*0x1000 + int(addr&0x0FFF)
+}
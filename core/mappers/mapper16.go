@@ -0,0 +1,264 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Mapper16 - Bandai FCG boards, FCG-1/2 (submapper 4) has registers at $6000-$7FFF and LZ93D50 (submapper 5) has them
+// at $8000-$FFFF together with 24C02 EEPROM. Registers are mirrored in both ranges when submapper is unknown.
+// https://wiki.nesdev.com/w/index.php/INES_Mapper_016
+type Mapper16 struct {
+	bandaiFCG
+}
+
+func (mpr *Mapper16) Initialize(board Board) {
+	mpr.initialize(board)
+
+	switch board.Submapper {
+	case 4:
+		mpr.hasLowRegisters = true
+		mpr.hasIRQLatch = false
+	case 5:
+		mpr.hasHighRegisters = true
+		mpr.eeprom = newEEPROM24C02()
+	default:
+		mpr.hasLowRegisters = true
+		mpr.hasHighRegisters = true
+		mpr.eeprom = newEEPROM24C02()
+	}
+
+	if mpr.eeprom != nil {
+		board.attachSaveRAM(mpr.eeprom.data)
+	}
+}
+
+// Mapper159 - Bandai LZ93D50 with 24C01 EEPROM
+type Mapper159 struct {
+	bandaiFCG
+}
+
+func (mpr *Mapper159) Initialize(board Board) {
+	mpr.initialize(board)
+	mpr.hasHighRegisters = true
+	mpr.eeprom = newEEPROM24C01()
+	board.attachSaveRAM(mpr.eeprom.data)
+}
+
+type bandaiFCG struct {
+	prgRomBanks int
+	chrRomBanks int
+	prgMem      []uint8
+	chrMem      []uint8
+
+	// Register ranges at $6000-$7FFF and $8000-$FFFF
+	hasLowRegisters  bool
+	hasHighRegisters bool
+
+	// 16KB bank at $8000, last bank is fixed at $C000
+	prgBank  uint8
+	chrBanks [8]uint8
+
+	mirroring uint8
+
+	// LZ93D50 loads counter from latch when IRQ control is written, FCG-1/2 writes counter directly
+	hasIRQLatch bool
+	irqEnabled  bool
+	irqLatch    uint16
+	irqCounter  uint16
+	irqPending  bool
+
+	// Serial EEPROM, nil on boards without one
+	eeprom *eeprom
+
+	// RDS. .... - EEPROM read enable, SDA and SCL lines
+	eepromControl uint8
+}
+
+func (mpr *bandaiFCG) initialize(board Board) {
+	mpr.prgRomBanks = board.prgRomBanks()
+	mpr.chrRomBanks = board.chrRomBanks()
+	mpr.prgMem = board.PrgMem
+	mpr.chrMem = board.getChrMem()
+
+	mpr.hasLowRegisters = false
+	mpr.hasHighRegisters = false
+	mpr.prgBank = 0
+	mpr.chrBanks = [8]uint8{}
+	mpr.mirroring = board.Mirroring
+	mpr.hasIRQLatch = true
+	mpr.irqEnabled = false
+	mpr.irqLatch = 0
+	mpr.irqCounter = 0
+	mpr.irqPending = false
+	mpr.eeprom = nil
+	mpr.eepromControl = 0
+}
+
+func (mpr *bandaiFCG) Read(busId string, addr uint16, _ bool) (uint8, bool) {
+	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 {
+		if mpr.eeprom == nil {
+			return 0, false
+		}
+
+		// EEPROM data is returned in bit 4
+		if mpr.eepromControl&0b10000000 != 0 && mpr.eeprom.read() {
+			return 0x10, true
+		}
+
+		return 0x00, true
+	}
+
+	if busId == "cpu" && addr >= 0x8000 {
+		return mpr.prgMem[mpr.getPrgAddress(addr)], true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		return mpr.chrMem[mpr.getChrAddress(addr)], true
+	}
+
+	return 0, false
+}
+
+func (mpr *bandaiFCG) Write(busId string, addr uint16, data uint8, debug bool) bool {
+	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 && mpr.hasLowRegisters {
+		if !debug {
+			mpr.writeRegister(addr&0x0F, data)
+		}
+
+		return true
+	}
+
+	if busId == "cpu" && addr >= 0x8000 {
+		if !debug && mpr.hasHighRegisters {
+			mpr.writeRegister(addr&0x0F, data)
+		}
+
+		return true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		if mpr.chrRomBanks == 0 {
+			mpr.chrMem[mpr.getChrAddress(addr)] = data
+			return true
+		}
+	}
+
+	return false
+}
+
+func (mpr *bandaiFCG) writeRegister(register uint16, data uint8) {
+	switch {
+	case register < 0x08:
+		mpr.chrBanks[register] = data
+	case register == 0x08:
+		mpr.prgBank = data & 0x0F
+	case register == 0x09:
+		switch data & 0b11 {
+		case 0:
+			mpr.mirroring = MirroringVertical
+		case 1:
+			mpr.mirroring = MirroringHorizontal
+		case 2:
+			mpr.mirroring = MirroringSingleScreenLow
+		case 3:
+			mpr.mirroring = MirroringSingleScreenHigh
+		}
+	case register == 0x0A:
+		mpr.irqEnabled = data&0x01 != 0
+		mpr.irqPending = false
+
+		if mpr.hasIRQLatch {
+			mpr.irqCounter = mpr.irqLatch
+		}
+	case register == 0x0B:
+		mpr.writeIRQValue(0xFF00, uint16(data))
+	case register == 0x0C:
+		mpr.writeIRQValue(0x00FF, uint16(data)<<8)
+	case register == 0x0D:
+		mpr.eepromControl = data
+
+		if mpr.eeprom != nil {
+			mpr.eeprom.write(data&0b00100000 != 0, data&0b01000000 != 0)
+		}
+	}
+}
+
+func (mpr *bandaiFCG) writeIRQValue(keepMask uint16, value uint16) {
+	if mpr.hasIRQLatch {
+		mpr.irqLatch = mpr.irqLatch&keepMask | value
+	} else {
+		mpr.irqCounter = mpr.irqCounter&keepMask | value
+	}
+}
+
+// GetMirroring - mirroring is selected by register $9
+func (mpr *bandaiFCG) GetMirroring() uint8 {
+	return mpr.mirroring
+}
+
+func (mpr *bandaiFCG) Serialize(w *state.Writer) {
+	writeChrRam(w, mpr.chrMem, mpr.chrRomBanks)
+	w.Uint8(mpr.prgBank)
+	w.Bytes(mpr.chrBanks[:])
+	w.Uint8(mpr.mirroring)
+	w.Bool(mpr.irqEnabled)
+	w.Uint16(mpr.irqLatch)
+	w.Uint16(mpr.irqCounter)
+	w.Bool(mpr.irqPending)
+	w.Uint8(mpr.eepromControl)
+
+	if mpr.eeprom != nil {
+		mpr.eeprom.serialize(w)
+	}
+}
+
+func (mpr *bandaiFCG) Deserialize(r *state.Reader) {
+	readChrRam(r, mpr.chrMem, mpr.chrRomBanks)
+	mpr.prgBank = r.Uint8()
+	r.Bytes(mpr.chrBanks[:])
+	mpr.mirroring = r.Uint8()
+	mpr.irqEnabled = r.Bool()
+	mpr.irqLatch = r.Uint16()
+	mpr.irqCounter = r.Uint16()
+	mpr.irqPending = r.Bool()
+	mpr.eepromControl = r.Uint8()
+
+	if mpr.eeprom != nil {
+		mpr.eeprom.deserialize(r)
+	}
+}
+
+// ClockCPU - counter decrements every cycle when enabled and IRQ fires when it reaches zero
+func (mpr *bandaiFCG) ClockCPU() {
+	if !mpr.irqEnabled {
+		return
+	}
+
+	mpr.irqCounter--
+
+	if mpr.irqCounter == 0 {
+		mpr.irqPending = true
+	}
+}
+
+func (mpr *bandaiFCG) IsIRQAsserted() bool {
+	return mpr.irqPending
+}
+
+func (mpr *bandaiFCG) getPrgAddress(addr uint16) int {
+	// Number of 16KB banks
+	bankCount := len(mpr.prgMem) / 0x4000
+
+	bank := bankCount - 1
+	if addr < 0xC000 {
+		bank = int(mpr.prgBank)
+	}
+
+	return (bank%bankCount)*0x4000 + int(addr&0x3FFF)
+}
+
+func (mpr *bandaiFCG) getChrAddress(addr uint16) int {
+	// Number of 1KB banks
+	bankCount := len(mpr.chrMem) / 0x0400
+	bank := int(mpr.chrBanks[addr/0x0400])
+
+	return (bank%bankCount)*0x0400 + int(addr&0x03FF)
+}
@@ -0,0 +1,174 @@
+package mappers_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"testing"
+)
+
+// Drives EEPROM lines trough $800D with reading enabled
+type eepromBus struct {
+	mpr      mappers.Mapper
+	lsbFirst bool
+}
+
+func (b *eepromBus) set(scl, sda bool) {
+	data := uint8(0b10000000)
+	if scl {
+		data |= 0b00100000
+	}
+	if sda {
+		data |= 0b01000000
+	}
+
+	b.mpr.Write("cpu", 0x800D, data, false)
+}
+
+func (b *eepromBus) start() {
+	b.set(false, true)
+	b.set(true, true)
+	b.set(true, false)
+	b.set(false, false)
+}
+
+func (b *eepromBus) stop() {
+	b.set(false, false)
+	b.set(true, false)
+	b.set(true, true)
+}
+
+func (b *eepromBus) clockBit(sda bool) bool {
+	b.set(false, sda)
+	b.set(true, sda)
+	data, _ := b.mpr.Read("cpu", 0x6000, false)
+	b.set(false, sda)
+
+	return data&0x10 != 0
+}
+
+// Sends byte and returns true when EEPROM acknowledges it
+func (b *eepromBus) send(value uint8) bool {
+	for i := uint(0); i < 8; i++ {
+		bit := value&(0x80>>i) != 0
+		if b.lsbFirst {
+			bit = value&(1<<i) != 0
+		}
+
+		b.clockBit(bit)
+	}
+
+	return !b.clockBit(true)
+}
+
+func (b *eepromBus) receive(ack bool) uint8 {
+	value := uint8(0)
+
+	for i := uint(0); i < 8; i++ {
+		if !b.clockBit(true) {
+			continue
+		}
+
+		if b.lsbFirst {
+			value |= 1 << i
+		} else {
+			value |= 0x80 >> i
+		}
+	}
+
+	b.clockBit(!ack)
+	return value
+}
+
+func TestMapper16Banking(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper16)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: createChrMem(4), Mirroring: mappers.MirroringVertical, Submapper: 5})
+
+	mpr.Write("cpu", 0x8008, 3, false)
+	a.Equal(uint8(3), readCPU(mpr, 0x8000), "16KB bank should be mapped at $8000")
+	a.Equal(uint8(7), readCPU(mpr, 0xC000), "Last bank should be fixed at $C000")
+
+	// Registers are mirrored every 16 bytes
+	mpr.Write("cpu", 0xC011, 5, false)
+	a.Equal(uint8(1), readPPU(mpr, 0x0400), "Second 1KB CHR bank should be switched")
+
+	mpr.Write("cpu", 0x8009, 1, false)
+	a.Equal(uint8(mappers.MirroringHorizontal), mpr.GetMirroring(), "Mirroring should be horizontal")
+
+	// LZ93D50 ignores writes to $6000-$7FFF
+	mpr.Write("cpu", 0x6008, 1, false)
+	a.Equal(uint8(3), readCPU(mpr, 0x8000), "PRG bank should not be changed")
+}
+
+func TestMapper16IRQ(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper16)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: createChrMem(4), Mirroring: mappers.MirroringVertical, Submapper: 5})
+
+	// Latch is copied to counter when IRQ is enabled
+	mpr.Write("cpu", 0x800B, 3, false)
+	mpr.Write("cpu", 0x800C, 0, false)
+	mpr.Write("cpu", 0x800A, 1, false)
+
+	mpr.ClockCPU()
+	mpr.ClockCPU()
+	a.False(mpr.IsIRQAsserted(), "IRQ should not be asserted before counter reaches zero")
+
+	mpr.ClockCPU()
+	a.True(mpr.IsIRQAsserted(), "IRQ should be asserted when counter reaches zero")
+
+	mpr.Write("cpu", 0x800A, 0, false)
+	a.False(mpr.IsIRQAsserted(), "Writing IRQ control should acknowledge IRQ")
+}
+
+func TestMapper16EEPROM(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper16)
+	saveRam := new(mappers.SaveRAM)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: createChrMem(4), Submapper: 5, SaveRAM: saveRam})
+	bus := &eepromBus{mpr: mpr}
+
+	bus.start()
+	a.True(bus.send(0xA0), "Device address should be acknowledged")
+	a.True(bus.send(0x10), "Word address should be acknowledged")
+	a.True(bus.send(0x42), "Data should be acknowledged")
+	a.True(bus.send(0x43), "Data should be acknowledged")
+	bus.stop()
+
+	a.Equal([]uint8{0x42, 0x43}, saveRam.GetData()[0x10:0x12], "Data should be stored in EEPROM")
+
+	// Random read - dummy write of address followed by repeated start
+	bus.start()
+	bus.send(0xA0)
+	bus.send(0x10)
+	bus.start()
+	a.True(bus.send(0xA1), "Read command should be acknowledged")
+	a.Equal(uint8(0x42), bus.receive(true), "First byte should be read")
+	a.Equal(uint8(0x43), bus.receive(false), "Sequential byte should be read")
+	bus.stop()
+
+	bus.start()
+	a.False(bus.send(0x50), "Other devices should not be acknowledged")
+	bus.stop()
+}
+
+func TestMapper159EEPROM(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper159)
+	saveRam := new(mappers.SaveRAM)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: createChrMem(4), SaveRAM: saveRam})
+	bus := &eepromBus{mpr: mpr, lsbFirst: true}
+
+	a.Equal(128, saveRam.Size(), "24C01 should have 128 bytes")
+
+	// 24C01 has no device address, read/write bit follows 7 bit address
+	bus.start()
+	a.True(bus.send(0x05), "Address should be acknowledged")
+	a.True(bus.send(0x81), "Data should be acknowledged")
+	bus.stop()
+
+	bus.start()
+	bus.send(0x85)
+	a.Equal(uint8(0x81), bus.receive(false), "Written byte should be read")
+	bus.stop()
+}
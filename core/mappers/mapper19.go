@@ -0,0 +1,224 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Mapper19 - Namco 163, can use CHR ROM pages as nametables
+// https://wiki.nesdev.com/w/index.php/INES_Mapper_019
+type Mapper19 struct {
+	prgRomBanks int
+	chrRomBanks int
+	prgMem      []uint8
+	chrMem      []uint8
+	sRam        prgRam
+
+	// 8KB banks at $8000, $A000 and $C000
+	prgBanks [3]uint8
+
+	// 1KB pattern table banks and nametable banks, values $E0 and above select console VRAM page
+	chrBanks       [8]uint8
+	nameTableBanks [4]uint8
+
+	// 15 bit counter counting up every CPU cycle, enable bit is kept in bit 15
+	irqCounter uint16
+	irqPending bool
+
+	audio n163Audio
+}
+
+func (mpr *Mapper19) Initialize(board Board) {
+	mpr.prgRomBanks = board.prgRomBanks()
+	mpr.chrRomBanks = board.chrRomBanks()
+	mpr.prgMem = board.PrgMem
+	mpr.chrMem = board.getChrMem()
+	mpr.sRam = board.newPrgRam(0x2000)
+
+	mpr.prgBanks = [3]uint8{}
+	mpr.chrBanks = [8]uint8{}
+	mpr.nameTableBanks = [4]uint8{0xE0, 0xE1, 0xE0, 0xE1}
+	mpr.irqCounter = 0
+	mpr.irqPending = false
+	mpr.audio = n163Audio{}
+
+	// Internal RAM holding wavetables is battery backed as well, some games keep saves there
+	if board.HasBattery {
+		board.attachSaveRAM(mpr.audio.ram[:])
+	}
+}
+
+func (mpr *Mapper19) Read(busId string, addr uint16, debug bool) (uint8, bool) {
+	if busId == "cpu" {
+		switch {
+		case addr >= 0x4800 && addr < 0x5000:
+			return mpr.audio.readData(debug), true
+		case addr >= 0x5000 && addr < 0x5800:
+			return uint8(mpr.irqCounter), true
+		case addr >= 0x5800 && addr < 0x6000:
+			return uint8(mpr.irqCounter >> 8), true
+		case addr >= 0x6000 && addr < 0x8000:
+			return mpr.sRam.read(addr)
+		case addr >= 0x8000:
+			return mpr.prgMem[mpr.getPrgAddress(addr)], true
+		}
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		return mpr.chrMem[mpr.getChrAddress(mpr.chrBanks[addr/0x0400], addr)], true
+	}
+
+	if busId == "ppu" && addr >= 0x2000 && addr < 0x3F00 {
+		bank := mpr.nameTableBanks[(addr>>10)&0b11]
+
+		// Nametable from console VRAM, page is selected by GetNameTablePage
+		if bank >= 0xE0 {
+			return 0, false
+		}
+
+		return mpr.chrMem[mpr.getChrAddress(bank, addr)], true
+	}
+
+	return 0, false
+}
+
+func (mpr *Mapper19) Write(busId string, addr uint16, data uint8, debug bool) bool {
+	if busId == "cpu" && addr >= 0x4800 && addr < 0x6000 {
+		if !debug {
+			switch {
+			case addr < 0x5000:
+				mpr.audio.writeData(data)
+			case addr < 0x5800:
+				mpr.irqCounter = mpr.irqCounter&0xFF00 | uint16(data)
+				mpr.irqPending = false
+			default:
+				mpr.irqCounter = mpr.irqCounter&0x00FF | uint16(data)<<8
+				mpr.irqPending = false
+			}
+		}
+
+		return true
+	}
+
+	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 {
+		return mpr.sRam.write(addr, data)
+	}
+
+	if busId == "cpu" && addr >= 0x8000 {
+		if !debug {
+			mpr.writeRegister(addr, data)
+		}
+
+		return true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		if mpr.chrRomBanks == 0 {
+			mpr.chrMem[mpr.getChrAddress(mpr.chrBanks[addr/0x0400], addr)] = data
+			return true
+		}
+	}
+
+	// Nametables mapped to CHR ROM are read only
+	if busId == "ppu" && addr >= 0x2000 && addr < 0x3F00 {
+		return mpr.nameTableBanks[(addr>>10)&0b11] < 0xE0
+	}
+
+	return false
+}
+
+func (mpr *Mapper19) writeRegister(addr uint16, data uint8) {
+	switch {
+	case addr < 0xC000:
+		mpr.chrBanks[(addr-0x8000)/0x0800] = data
+	case addr < 0xE000:
+		mpr.nameTableBanks[(addr-0xC000)/0x0800] = data
+	case addr < 0xE800:
+		mpr.prgBanks[0] = data & 0x3F
+		mpr.audio.isDisabled = data&0b01000000 != 0
+	case addr < 0xF000:
+		// Bits 6 and 7 would allow console VRAM in pattern tables, which is not supported
+		mpr.prgBanks[1] = data & 0x3F
+	case addr < 0xF800:
+		mpr.prgBanks[2] = data & 0x3F
+	default:
+		mpr.audio.writeAddress(data)
+	}
+}
+
+// GetMirroring - approximation of nametable mapping, console VRAM pages are selected by GetNameTablePage
+func (mpr *Mapper19) GetMirroring() uint8 {
+	switch mpr.GetNameTablePage(0) | mpr.GetNameTablePage(1)<<1 | mpr.GetNameTablePage(2)<<2 | mpr.GetNameTablePage(3)<<3 {
+	case 0b1010:
+		return MirroringVertical
+	case 0b0000:
+		return MirroringSingleScreenLow
+	case 0b1111:
+		return MirroringSingleScreenHigh
+	default:
+		return MirroringHorizontal
+	}
+}
+
+func (mpr *Mapper19) Serialize(w *state.Writer) {
+	w.Bytes(mpr.sRam)
+	writeChrRam(w, mpr.chrMem, mpr.chrRomBanks)
+	w.Bytes(mpr.prgBanks[:])
+	w.Bytes(mpr.chrBanks[:])
+	w.Bytes(mpr.nameTableBanks[:])
+	w.Uint16(mpr.irqCounter)
+	w.Bool(mpr.irqPending)
+	mpr.audio.serialize(w)
+}
+
+func (mpr *Mapper19) Deserialize(r *state.Reader) {
+	r.Bytes(mpr.sRam)
+	readChrRam(r, mpr.chrMem, mpr.chrRomBanks)
+	r.Bytes(mpr.prgBanks[:])
+	r.Bytes(mpr.chrBanks[:])
+	r.Bytes(mpr.nameTableBanks[:])
+	mpr.irqCounter = r.Uint16()
+	mpr.irqPending = r.Bool()
+	mpr.audio.deserialize(r)
+}
+
+// GetNameTablePage - console VRAM page for nametable mapped to VRAM, CHR ROM nametables are handled by the mapper
+func (mpr *Mapper19) GetNameTablePage(index uint8) uint8 {
+	return mpr.nameTableBanks[index&0b11] & 0x01
+}
+
+func (mpr *Mapper19) ClockCPU() {
+	if mpr.irqCounter&0x8000 != 0 && mpr.irqCounter&0x7FFF != 0x7FFF {
+		mpr.irqCounter++
+
+		if mpr.irqCounter&0x7FFF == 0x7FFF {
+			mpr.irqPending = true
+		}
+	}
+
+	mpr.audio.clock()
+}
+
+func (mpr *Mapper19) AudioOutput() float32 {
+	return mpr.audio.output()
+}
+
+func (mpr *Mapper19) IsIRQAsserted() bool {
+	return mpr.irqPending
+}
+
+func (mpr *Mapper19) getPrgAddress(addr uint16) int {
+	// Number of 8KB banks
+	bankCount := len(mpr.prgMem) / 0x2000
+
+	bank := bankCount - 1
+	if addr < 0xE000 {
+		bank = int(mpr.prgBanks[(addr-0x8000)/0x2000])
+	}
+
+	return (bank%bankCount)*0x2000 + int(addr&0x1FFF)
+}
+
+func (mpr *Mapper19) getChrAddress(bank uint8, addr uint16) int {
+	// Number of 1KB banks
+	bankCount := len(mpr.chrMem) / 0x0400
+
+	return (int(bank)%bankCount)*0x0400 + int(addr&0x03FF)
+}
@@ -0,0 +1,70 @@
+package mappers_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"testing"
+)
+
+func TestMapper19Banking(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper19)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: createChrMem(4), Mirroring: mappers.MirroringVertical})
+
+	mpr.Write("cpu", 0xE000, 2, false)
+	mpr.Write("cpu", 0xF000, 9, false)
+	a.Equal(uint8(1), readCPU(mpr, 0x8000), "8KB bank should be mapped at $8000")
+	a.Equal(uint8(4), readCPU(mpr, 0xC000), "8KB bank should be mapped at $C000")
+	a.Equal(uint8(7), readCPU(mpr, 0xE000), "Last bank should be fixed at $E000")
+
+	mpr.Write("cpu", 0x8800, 12, false)
+	a.Equal(uint8(3), readPPU(mpr, 0x0400), "Second 1KB CHR bank should be switched")
+}
+
+func TestMapper19NameTables(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper19)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: createChrMem(4), Mirroring: mappers.MirroringVertical})
+
+	// Values $E0 and above select console VRAM pages
+	mpr.Write("cpu", 0xC000, 0xE1, false)
+	mpr.Write("cpu", 0xC800, 0xE0, false)
+	mpr.Write("cpu", 0xD000, 0xE1, false)
+	mpr.Write("cpu", 0xD800, 0xE0, false)
+	a.Equal(uint8(1), mpr.GetNameTablePage(0), "First nametable should use second VRAM page")
+	a.Equal(uint8(0), mpr.GetNameTablePage(1), "Second nametable should use first VRAM page")
+
+	_, handled := mpr.Read("ppu", 0x2000, false)
+	a.False(handled, "VRAM nametable should be left to console VRAM")
+
+	// Other values select CHR ROM pages, which can't be written
+	mpr.Write("cpu", 0xC800, 20, false)
+	data, handled := mpr.Read("ppu", 0x2400, false)
+	a.True(handled, "CHR ROM nametable should be provided by the mapper")
+	a.Equal(uint8(5), data, "Nametable should be read from CHR ROM")
+	a.True(mpr.Write("ppu", 0x2400, 0xFF, false), "Write to CHR ROM nametable should be ignored")
+	a.Equal(uint8(5), readPPU(mpr, 0x2400), "CHR ROM nametable should not be modified")
+}
+
+func TestMapper19IRQ(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper19)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: createChrMem(4), Mirroring: mappers.MirroringVertical})
+
+	// Counter counts up to $7FFF, enable is bit 7 of high byte
+	mpr.Write("cpu", 0x5000, 0xFD, false)
+	mpr.Write("cpu", 0x5800, 0xFF, false)
+
+	mpr.ClockCPU()
+	a.False(mpr.IsIRQAsserted(), "IRQ should not be asserted before counter reaches $7FFF")
+	a.Equal(uint8(0xFE), readCPU(mpr, 0x5000), "Counter should be readable")
+
+	mpr.ClockCPU()
+	a.True(mpr.IsIRQAsserted(), "IRQ should be asserted when counter reaches $7FFF")
+
+	mpr.ClockCPU()
+	a.Equal(uint8(0xFF), readCPU(mpr, 0x5000), "Counter should stop at $7FFF")
+
+	mpr.Write("cpu", 0x5800, 0x00, false)
+	a.False(mpr.IsIRQAsserted(), "Writing counter should acknowledge IRQ")
+}
@@ -0,0 +1,104 @@
+package mappers_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"testing"
+)
+
+// Creates PRG memory where every byte holds number of its 16KB bank
+func createPrgMem(banks uint8) []uint8 {
+	mem := make([]uint8, int(banks)*0x4000)
+
+	for i := range mem {
+		mem[i] = uint8(i / 0x4000)
+	}
+
+	return mem
+}
+
+// Writes 5 bit value trough MMC1 serial port
+func writeMMC1(mpr *mappers.Mapper1, addr uint16, value uint8) {
+	for i := 0; i < 5; i++ {
+		mpr.Write("cpu", addr, (value>>i)&0x01, false)
+	}
+}
+
+func readCPU(mpr mappers.Mapper, addr uint16) uint8 {
+	data, _ := mpr.Read("cpu", addr, false)
+	return data
+}
+
+func TestMapper1PowerUp(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper1)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringHorizontal})
+
+	a.Equal(uint8(0), readCPU(mpr, 0x8000), "First bank should be at $8000")
+	a.Equal(uint8(7), readCPU(mpr, 0xC000), "Last bank should be fixed at $C000")
+}
+
+func TestMapper1PrgBanking(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper1)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringHorizontal})
+
+	writeMMC1(mpr, 0xE000, 3)
+	a.Equal(uint8(3), readCPU(mpr, 0x8000), "Bank 3 should be switched at $8000")
+	a.Equal(uint8(7), readCPU(mpr, 0xC000), "Last bank should stay at $C000")
+
+	// PRG mode 2 - first bank fixed at $8000
+	writeMMC1(mpr, 0x8000, 0b01000)
+	a.Equal(uint8(0), readCPU(mpr, 0x8000), "First bank should be fixed at $8000")
+	a.Equal(uint8(3), readCPU(mpr, 0xC000), "Bank 3 should be switched at $C000")
+
+	// PRG mode 0 - 32KB, lowest bit ignored
+	writeMMC1(mpr, 0x8000, 0b00000)
+	a.Equal(uint8(2), readCPU(mpr, 0x8000), "Bank 2 should be at $8000 in 32KB mode")
+	a.Equal(uint8(3), readCPU(mpr, 0xC000), "Bank 3 should be at $C000 in 32KB mode")
+}
+
+func TestMapper1ShiftRegisterReset(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper1)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringHorizontal})
+
+	// Partial write followed by reset
+	mpr.Write("cpu", 0xE000, 1, false)
+	mpr.Write("cpu", 0xE000, 1, false)
+	mpr.Write("cpu", 0xE000, 0x80, false)
+
+	writeMMC1(mpr, 0xE000, 5)
+	a.Equal(uint8(5), readCPU(mpr, 0x8000), "Reset should clear partially written value")
+}
+
+func TestMapper1Mirroring(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper1)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringHorizontal})
+
+	expected := []uint8{
+		mappers.MirroringSingleScreenLow,
+		mappers.MirroringSingleScreenHigh,
+		mappers.MirroringVertical,
+		mappers.MirroringHorizontal,
+	}
+
+	for value, mirroring := range expected {
+		writeMMC1(mpr, 0x8000, 0b01100|uint8(value))
+		a.Equal(mirroring, mpr.GetMirroring(), "Mirroring should be controlled by control register")
+	}
+}
+
+func TestMapper1PrgRamDisable(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper1)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringHorizontal})
+
+	a.True(mpr.Write("cpu", 0x6000, 0x42, false), "PRG RAM should be enabled on power up")
+	a.Equal(uint8(0x42), readCPU(mpr, 0x6000))
+
+	writeMMC1(mpr, 0xE000, 0b10000)
+	_, ok := mpr.Read("cpu", 0x6000, false)
+	a.False(ok, "PRG RAM should be disabled")
+}
@@ -0,0 +1,82 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Mapper2 - UxROM
+// https://wiki.nesdev.com/w/index.php/UxROM
+type Mapper2 struct {
+	prgRomBanks int
+	chrRomBanks int
+	prgMem      []uint8
+	chrMem      []uint8
+	mirroring   uint8
+
+	prgBank uint8
+}
+
+func (mpr *Mapper2) Initialize(board Board) {
+	mpr.mirroring = board.Mirroring
+	mpr.prgRomBanks = board.prgRomBanks()
+	mpr.chrRomBanks = board.chrRomBanks()
+	mpr.prgMem = board.PrgMem
+	mpr.chrMem = board.getChrMem()
+
+	mpr.prgBank = 0
+}
+
+func (mpr *Mapper2) Read(busId string, addr uint16, _ bool) (uint8, bool) {
+	if busId == "cpu" && addr >= 0x8000 {
+		return mpr.prgMem[mpr.getPrgAddress(addr)], true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		return mpr.chrMem[addr], true
+	}
+
+	return 0, false
+}
+
+func (mpr *Mapper2) Write(busId string, addr uint16, data uint8, debug bool) bool {
+	if busId == "cpu" && addr >= 0x8000 {
+		if !debug {
+			// Bus conflict - ROM drives the bus at the same time, so only bits set in both values win
+			mpr.prgBank = data & mpr.prgMem[mpr.getPrgAddress(addr)]
+		}
+
+		return true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		if mpr.chrRomBanks == 0 {
+			mpr.chrMem[addr] = data
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetMirroring - mirroring is hardwired on the board
+func (mpr *Mapper2) GetMirroring() uint8 {
+	return mpr.mirroring
+}
+
+func (mpr *Mapper2) Serialize(w *state.Writer) {
+	writeChrRam(w, mpr.chrMem, mpr.chrRomBanks)
+	w.Uint8(mpr.prgBank)
+}
+
+func (mpr *Mapper2) Deserialize(r *state.Reader) {
+	readChrRam(r, mpr.chrMem, mpr.chrRomBanks)
+	mpr.prgBank = r.Uint8()
+}
+
+func (mpr *Mapper2) getPrgAddress(addr uint16) int {
+	// Switchable 16KB bank at $8000, last bank fixed at $C000
+	bank := int(mpr.prgBank) % mpr.prgRomBanks
+	if addr >= 0xC000 {
+		bank = mpr.prgRomBanks - 1
+	}
+
+	return bank*0x4000 + int(addr&0x3FFF)
+}
@@ -0,0 +1,320 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Konami VRC2 and VRC4 boards differ in which CPU address lines are connected to chip's A0 and A1 inputs.
+// https://wiki.nesdev.com/w/index.php/VRC2_and_VRC4
+type vrcWiring struct {
+	a0 uint8
+	a1 uint8
+}
+
+// Mapper21 - VRC4a (submapper 1) and VRC4c (submapper 2)
+type Mapper21 struct {
+	vrc4
+}
+
+func (mpr *Mapper21) Initialize(board Board) {
+	switch board.Submapper {
+	case 1:
+		mpr.setVariant(false, vrcWiring{1, 2})
+	case 2:
+		mpr.setVariant(false, vrcWiring{6, 7})
+	default:
+		mpr.setVariant(false, vrcWiring{1, 2}, vrcWiring{6, 7})
+	}
+
+	mpr.initialize(board)
+}
+
+// Mapper22 - VRC2a, CHR bank numbers are shifted by one bit
+type Mapper22 struct {
+	vrc4
+}
+
+func (mpr *Mapper22) Initialize(board Board) {
+	mpr.setVariant(true, vrcWiring{1, 0})
+	mpr.isChrShifted = true
+	mpr.initialize(board)
+}
+
+// Mapper23 - VRC4f (submapper 1), VRC4e (submapper 2) and VRC2b (submapper 3)
+type Mapper23 struct {
+	vrc4
+}
+
+func (mpr *Mapper23) Initialize(board Board) {
+	switch board.Submapper {
+	case 1:
+		mpr.setVariant(false, vrcWiring{0, 1})
+	case 2:
+		mpr.setVariant(false, vrcWiring{2, 3})
+	case 3:
+		mpr.setVariant(true, vrcWiring{0, 1})
+	default:
+		mpr.setVariant(false, vrcWiring{0, 1}, vrcWiring{2, 3})
+	}
+
+	mpr.initialize(board)
+}
+
+// Mapper25 - VRC4b (submapper 1), VRC4d (submapper 2) and VRC2c (submapper 3)
+type Mapper25 struct {
+	vrc4
+}
+
+func (mpr *Mapper25) Initialize(board Board) {
+	switch board.Submapper {
+	case 1:
+		mpr.setVariant(false, vrcWiring{1, 0})
+	case 2:
+		mpr.setVariant(false, vrcWiring{3, 2})
+	case 3:
+		mpr.setVariant(true, vrcWiring{1, 0})
+	default:
+		mpr.setVariant(false, vrcWiring{1, 0}, vrcWiring{3, 2})
+	}
+
+	mpr.initialize(board)
+}
+
+// Shared implementation of VRC2 and VRC4, VRC2 is a subset without IRQ and PRG swap mode
+type vrc4 struct {
+	prgRomBanks int
+	chrRomBanks int
+	prgMem      []uint8
+	chrMem      []uint8
+	sRam        prgRam
+
+	isVRC2       bool
+	isChrShifted bool
+
+	// When submapper is unknown both wirings are combined
+	wirings []vrcWiring
+
+	prgBanks  [2]uint8
+	isPrgSwap bool
+	chrBanks  [8]uint16
+	mirroring uint8
+
+	irq vrcIRQ
+}
+
+func (mpr *vrc4) setVariant(isVRC2 bool, wirings ...vrcWiring) {
+	mpr.isVRC2 = isVRC2
+	mpr.isChrShifted = false
+	mpr.wirings = wirings
+}
+
+func (mpr *vrc4) initialize(board Board) {
+	mpr.mirroring = board.Mirroring
+	mpr.prgRomBanks = board.prgRomBanks()
+	mpr.chrRomBanks = board.chrRomBanks()
+	mpr.prgMem = board.PrgMem
+	mpr.chrMem = board.getChrMem()
+	mpr.sRam = board.newPrgRam(0x2000)
+
+	mpr.prgBanks = [2]uint8{}
+	mpr.isPrgSwap = false
+	mpr.chrBanks = [8]uint16{}
+	mpr.irq.reset()
+}
+
+func (mpr *vrc4) Read(busId string, addr uint16, _ bool) (uint8, bool) {
+	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 {
+		return mpr.sRam.read(addr)
+	}
+
+	if busId == "cpu" && addr >= 0x8000 {
+		return mpr.prgMem[mpr.getPrgAddress(addr)], true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		return mpr.chrMem[mpr.getChrAddress(addr)], true
+	}
+
+	return 0, false
+}
+
+func (mpr *vrc4) Write(busId string, addr uint16, data uint8, debug bool) bool {
+	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 {
+		return mpr.sRam.write(addr, data)
+	}
+
+	if busId == "cpu" && addr >= 0x8000 {
+		if !debug {
+			mpr.writeRegister(addr&0xF000|mpr.getRegisterIndex(addr), data)
+		}
+
+		return true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		if mpr.chrRomBanks == 0 {
+			mpr.chrMem[mpr.getChrAddress(addr)] = data
+			return true
+		}
+	}
+
+	return false
+}
+
+// Translates CPU address lines to chip's A0 and A1 inputs
+func (mpr *vrc4) getRegisterIndex(addr uint16) uint16 {
+	index := uint16(0)
+
+	for _, wiring := range mpr.wirings {
+		index |= (addr >> wiring.a0) & 0x01
+		index |= ((addr >> wiring.a1) & 0x01) << 1
+	}
+
+	return index
+}
+
+func (mpr *vrc4) writeRegister(register uint16, data uint8) {
+	switch {
+	case register < 0x9000:
+		mpr.prgBanks[0] = data & 0x1F
+
+	case register < 0xA000:
+		// VRC4 has PRG swap mode at $9002, VRC2 mirrors mirroring control there
+		if register >= 0x9002 && !mpr.isVRC2 {
+			if register == 0x9002 {
+				mpr.isPrgSwap = data&0b10 != 0
+			}
+
+			return
+		}
+
+		mpr.writeMirroring(data)
+
+	case register < 0xB000:
+		mpr.prgBanks[1] = data & 0x1F
+
+	case register < 0xF000:
+		// $B000-$E003 - low and high nibbles of eight 1KB CHR banks
+		index := (register-0xB000)>>11 | (register&0x02)>>1
+		if register&0x01 == 0 {
+			mpr.chrBanks[index] = mpr.chrBanks[index]&0x1F0 | uint16(data&0x0F)
+		} else {
+			mpr.chrBanks[index] = mpr.chrBanks[index]&0x00F | uint16(data&0x1F)<<4
+		}
+
+	default:
+		if mpr.isVRC2 {
+			return
+		}
+
+		switch register {
+		case 0xF000:
+			mpr.irq.writeLatchLow(data)
+		case 0xF001:
+			mpr.irq.writeLatchHigh(data)
+		case 0xF002:
+			mpr.irq.writeControl(data)
+		case 0xF003:
+			mpr.irq.acknowledge()
+		}
+	}
+}
+
+func (mpr *vrc4) writeMirroring(data uint8) {
+	// VRC2 supports only vertical and horizontal mirroring
+	if mpr.isVRC2 {
+		data &= 0b01
+	}
+
+	switch data & 0b11 {
+	case 0:
+		mpr.mirroring = MirroringVertical
+	case 1:
+		mpr.mirroring = MirroringHorizontal
+	case 2:
+		mpr.mirroring = MirroringSingleScreenLow
+	case 3:
+		mpr.mirroring = MirroringSingleScreenHigh
+	}
+}
+
+// GetMirroring - mirroring is selected by $9000 register
+func (mpr *vrc4) GetMirroring() uint8 {
+	return mpr.mirroring
+}
+
+func (mpr *vrc4) Serialize(w *state.Writer) {
+	w.Bytes(mpr.sRam)
+	writeChrRam(w, mpr.chrMem, mpr.chrRomBanks)
+	w.Bytes(mpr.prgBanks[:])
+	w.Bool(mpr.isPrgSwap)
+
+	for _, bank := range mpr.chrBanks {
+		w.Uint16(bank)
+	}
+
+	w.Uint8(mpr.mirroring)
+	mpr.irq.serialize(w)
+}
+
+func (mpr *vrc4) Deserialize(r *state.Reader) {
+	r.Bytes(mpr.sRam)
+	readChrRam(r, mpr.chrMem, mpr.chrRomBanks)
+	r.Bytes(mpr.prgBanks[:])
+	mpr.isPrgSwap = r.Bool()
+
+	for i := range mpr.chrBanks {
+		mpr.chrBanks[i] = r.Uint16()
+	}
+
+	mpr.mirroring = r.Uint8()
+	mpr.irq.deserialize(r)
+}
+
+func (mpr *vrc4) ClockCPU() {
+	mpr.irq.clock()
+}
+
+func (mpr *vrc4) IsIRQAsserted() bool {
+	return mpr.irq.isPending
+}
+
+func (mpr *vrc4) getPrgAddress(addr uint16) int {
+	// Number of 8KB banks
+	bankCount := len(mpr.prgMem) / 0x2000
+	secondLast := bankCount - 2
+
+	var bank int
+	switch (addr - 0x8000) / 0x2000 {
+	case 0:
+		// PRG swap mode exchanges $8000 and $C000
+		if mpr.isPrgSwap {
+			bank = secondLast
+		} else {
+			bank = int(mpr.prgBanks[0])
+		}
+	case 1:
+		bank = int(mpr.prgBanks[1])
+	case 2:
+		if mpr.isPrgSwap {
+			bank = int(mpr.prgBanks[0])
+		} else {
+			bank = secondLast
+		}
+	case 3:
+		bank = bankCount - 1
+	}
+
+	return (bank%bankCount)*0x2000 + int(addr&0x1FFF)
+}
+
+func (mpr *vrc4) getChrAddress(addr uint16) int {
+	// Number of 1KB banks
+	bankCount := len(mpr.chrMem) / 0x0400
+	bank := int(mpr.chrBanks[addr/0x0400])
+
+	// VRC2a ignores lowest bit of bank number
+	if mpr.isChrShifted {
+		bank >>= 1
+	}
+
+	return (bank%bankCount)*0x0400 + int(addr&0x03FF)
+}
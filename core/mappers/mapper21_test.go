@@ -0,0 +1,101 @@
+package mappers_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"testing"
+)
+
+func TestMapper23Wiring(t *testing.T) {
+	a := assert.New(t)
+
+	// VRC4e - A2 and A3 are connected to chip's A0 and A1
+	mpr := new(mappers.Mapper23)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: createChrMem(4), Mirroring: mappers.MirroringVertical, Submapper: 2})
+
+	// CHR bank 1 (second 1KB bank) - low nibble at $B004, high nibble at $B00C
+	mpr.Write("cpu", 0xB008, 0x04, false)
+	mpr.Write("cpu", 0xB00C, 0x00, false)
+	a.Equal(uint8(1), readPPU(mpr, 0x0400), "Register at $B002 should be selected by A3")
+
+	// Unknown submapper combines both wirings
+	mpr = new(mappers.Mapper23)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: createChrMem(4), Mirroring: mappers.MirroringVertical, Submapper: 0})
+
+	mpr.Write("cpu", 0xB002, 0x04, false)
+	a.Equal(uint8(1), readPPU(mpr, 0x0400), "Register at $B002 should be selected by A1")
+
+	mpr.Write("cpu", 0xB008, 0x08, false)
+	a.Equal(uint8(2), readPPU(mpr, 0x0400), "Register at $B002 should be selected by A3")
+}
+
+func TestMapper22ChrBanking(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper22)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: create1KBChrMem(32), Mirroring: mappers.MirroringVertical})
+
+	// VRC2a has A0 and A1 swapped - low and high nibble of first bank are at $B000 and $B002
+	mpr.Write("cpu", 0xB000, 0x04, false)
+	mpr.Write("cpu", 0xB002, 0x01, false)
+	a.Equal(uint8(10), readPPU(mpr, 0x0000), "Bank number should be shifted right by one bit")
+
+	// Second bank is at $B001 and $B003
+	mpr.Write("cpu", 0xB001, 0x07, false)
+	mpr.Write("cpu", 0xB003, 0x00, false)
+	a.Equal(uint8(3), readPPU(mpr, 0x0400), "Lowest bit of bank number should be ignored")
+}
+
+func TestMapper21PrgBanking(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper21)
+	// 8 16KB banks = 16 8KB banks, value read is number of 16KB bank
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringVertical, Submapper: 1})
+
+	mpr.Write("cpu", 0x8000, 4, false)
+	mpr.Write("cpu", 0xA000, 3, false)
+	a.Equal(uint8(2), readCPU(mpr, 0x8000), "PRG bank 0 should be mapped at $8000")
+	a.Equal(uint8(1), readCPU(mpr, 0xA000), "PRG bank 1 should be mapped at $A000")
+	a.Equal(uint8(7), readCPU(mpr, 0xC000), "Second last bank should be mapped at $C000")
+
+	// PRG swap mode - VRC4a register $9002 is at $9004
+	mpr.Write("cpu", 0x9004, 0b10, false)
+	a.Equal(uint8(7), readCPU(mpr, 0x8000), "Second last bank should be mapped at $8000")
+	a.Equal(uint8(2), readCPU(mpr, 0xC000), "PRG bank 0 should be mapped at $C000")
+
+	mpr.Write("cpu", 0x9000, 3, false)
+	a.Equal(uint8(mappers.MirroringSingleScreenHigh), mpr.GetMirroring(), "Mirroring should be single screen")
+}
+
+func TestMapper21IRQ(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper21)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringVertical, Submapper: 1})
+
+	// Latch $FD, cycle mode, enabled - IRQ after 3 CPU cycles
+	mpr.Write("cpu", 0xF000, 0x0D, false)
+	mpr.Write("cpu", 0xF002, 0x0F, false)
+	mpr.Write("cpu", 0xF004, 0b111, false)
+
+	mpr.ClockCPU()
+	mpr.ClockCPU()
+	a.False(mpr.IsIRQAsserted(), "IRQ should not be asserted before counter overflows")
+
+	mpr.ClockCPU()
+	a.True(mpr.IsIRQAsserted(), "IRQ should be asserted when counter overflows")
+
+	mpr.Write("cpu", 0xF006, 0, false)
+	a.False(mpr.IsIRQAsserted(), "IRQ should be acknowledged")
+
+	// Scan line mode - counter is clocked every 341/3 CPU cycles
+	mpr.Write("cpu", 0xF000, 0x0F, false)
+	mpr.Write("cpu", 0xF004, 0b010, false)
+
+	for i := 0; i < 113; i++ {
+		mpr.ClockCPU()
+	}
+
+	a.False(mpr.IsIRQAsserted(), "IRQ should not be asserted before prescaler expires")
+
+	mpr.ClockCPU()
+	a.True(mpr.IsIRQAsserted(), "IRQ should be asserted after one scan line")
+}
@@ -0,0 +1,238 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Mapper24 - VRC6a
+// https://wiki.nesdev.com/w/index.php/VRC6
+type Mapper24 struct {
+	vrc6
+}
+
+func (mpr *Mapper24) Initialize(board Board) {
+	mpr.initialize(board, vrcWiring{0, 1})
+}
+
+// Mapper26 - VRC6b, has A0 and A1 lines swapped
+type Mapper26 struct {
+	vrc6
+}
+
+func (mpr *Mapper26) Initialize(board Board) {
+	mpr.initialize(board, vrcWiring{1, 0})
+}
+
+type vrc6 struct {
+	prgRomBanks int
+	chrRomBanks int
+	prgMem      []uint8
+	chrMem      []uint8
+	sRam        prgRam
+	wiring      vrcWiring
+
+	// 16KB bank at $8000 and 8KB bank at $C000
+	prgBanks [2]uint8
+	chrBanks [8]uint8
+
+	// W.NM MMCC - PRG RAM enable, nametable and CHR modes
+	ppuMode   uint8
+	mirroring uint8
+
+	irq   vrcIRQ
+	audio vrc6Audio
+}
+
+func (mpr *vrc6) initialize(board Board, wiring vrcWiring) {
+	mpr.mirroring = board.Mirroring
+	mpr.wiring = wiring
+	mpr.prgRomBanks = board.prgRomBanks()
+	mpr.chrRomBanks = board.chrRomBanks()
+	mpr.prgMem = board.PrgMem
+	mpr.chrMem = board.getChrMem()
+	mpr.sRam = board.newPrgRam(0x2000)
+
+	mpr.prgBanks = [2]uint8{}
+	mpr.chrBanks = [8]uint8{}
+	mpr.ppuMode = 0
+	mpr.irq.reset()
+	mpr.audio = vrc6Audio{}
+}
+
+func (mpr *vrc6) Read(busId string, addr uint16, _ bool) (uint8, bool) {
+	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 {
+		if !mpr.isPrgRamEnabled() {
+			return 0, false
+		}
+
+		return mpr.sRam.read(addr)
+	}
+
+	if busId == "cpu" && addr >= 0x8000 {
+		return mpr.prgMem[mpr.getPrgAddress(addr)], true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		return mpr.chrMem[mpr.getChrAddress(addr)], true
+	}
+
+	return 0, false
+}
+
+func (mpr *vrc6) Write(busId string, addr uint16, data uint8, debug bool) bool {
+	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 {
+		if !mpr.isPrgRamEnabled() {
+			return false
+		}
+
+		return mpr.sRam.write(addr, data)
+	}
+
+	if busId == "cpu" && addr >= 0x8000 {
+		if !debug {
+			index := (addr>>mpr.wiring.a0)&0x01 | ((addr>>mpr.wiring.a1)&0x01)<<1
+			mpr.writeRegister(addr&0xF000|index, data)
+		}
+
+		return true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		if mpr.chrRomBanks == 0 {
+			mpr.chrMem[mpr.getChrAddress(addr)] = data
+			return true
+		}
+	}
+
+	return false
+}
+
+func (mpr *vrc6) writeRegister(register uint16, data uint8) {
+	switch {
+	case register < 0x9000:
+		mpr.prgBanks[0] = data & 0x0F
+	case register == 0xB003:
+		mpr.ppuMode = data
+		mpr.writeMirroring(data)
+	case register < 0xC000:
+		mpr.audio.write(register, data)
+	case register < 0xD000:
+		mpr.prgBanks[1] = data & 0x1F
+	case register < 0xF000:
+		mpr.chrBanks[(register-0xD000)>>10|register&0x03] = data
+	case register == 0xF000:
+		mpr.irq.writeLatch(data)
+	case register == 0xF001:
+		mpr.irq.writeControl(data)
+	case register == 0xF002:
+		mpr.irq.acknowledge()
+	}
+}
+
+// Nametable mode is interpreted the way all VRC6 games use it, with nametables in console VRAM
+func (mpr *vrc6) writeMirroring(data uint8) {
+	switch (data >> 2) & 0b11 {
+	case 0:
+		mpr.mirroring = MirroringVertical
+	case 1:
+		mpr.mirroring = MirroringHorizontal
+	case 2:
+		mpr.mirroring = MirroringSingleScreenLow
+	case 3:
+		mpr.mirroring = MirroringSingleScreenHigh
+	}
+}
+
+// GetMirroring - mirroring is selected by $B003 register
+func (mpr *vrc6) GetMirroring() uint8 {
+	return mpr.mirroring
+}
+
+func (mpr *vrc6) Serialize(w *state.Writer) {
+	w.Bytes(mpr.sRam)
+	writeChrRam(w, mpr.chrMem, mpr.chrRomBanks)
+	w.Bytes(mpr.prgBanks[:])
+	w.Bytes(mpr.chrBanks[:])
+	w.Uint8(mpr.ppuMode)
+	w.Uint8(mpr.mirroring)
+	mpr.irq.serialize(w)
+	mpr.audio.serialize(w)
+}
+
+func (mpr *vrc6) Deserialize(r *state.Reader) {
+	r.Bytes(mpr.sRam)
+	readChrRam(r, mpr.chrMem, mpr.chrRomBanks)
+	r.Bytes(mpr.prgBanks[:])
+	r.Bytes(mpr.chrBanks[:])
+	mpr.ppuMode = r.Uint8()
+	mpr.mirroring = r.Uint8()
+	mpr.irq.deserialize(r)
+	mpr.audio.deserialize(r)
+}
+
+func (mpr *vrc6) ClockCPU() {
+	mpr.irq.clock()
+	mpr.audio.clock()
+}
+
+func (mpr *vrc6) AudioOutput() float32 {
+	return mpr.audio.output()
+}
+
+func (mpr *vrc6) IsIRQAsserted() bool {
+	return mpr.irq.isPending
+}
+
+func (mpr *vrc6) isPrgRamEnabled() bool {
+	return mpr.ppuMode&0b10000000 != 0
+}
+
+func (mpr *vrc6) getPrgAddress(addr uint16) int {
+	// Number of 8KB banks
+	bankCount := len(mpr.prgMem) / 0x2000
+
+	var bank int
+	switch {
+	case addr < 0xC000:
+		bank = int(mpr.prgBanks[0])<<1 | int(addr>>13)&0x01
+	case addr < 0xE000:
+		bank = int(mpr.prgBanks[1])
+	default:
+		bank = bankCount - 1
+	}
+
+	return (bank%bankCount)*0x2000 + int(addr&0x1FFF)
+}
+
+func (mpr *vrc6) getChrAddress(addr uint16) int {
+	// Number of 1KB banks
+	bankCount := len(mpr.chrMem) / 0x0400
+	slot := addr / 0x0400
+
+	var bank int
+	switch mpr.ppuMode & 0b11 {
+	case 0:
+		// Eight 1KB banks
+		bank = int(mpr.chrBanks[slot])
+	case 1:
+		// Four 2KB windows selected by R0-R3
+		bank = mpr.get2KBChrBank(mpr.chrBanks[slot>>1], slot)
+	default:
+		// 1KB banks R0-R3 at $0000 and 2KB windows R4-R5 at $1000
+		if slot < 4 {
+			bank = int(mpr.chrBanks[slot])
+		} else {
+			bank = mpr.get2KBChrBank(mpr.chrBanks[4+(slot-4)>>1], slot)
+		}
+	}
+
+	return (bank%bankCount)*0x0400 + int(addr&0x03FF)
+}
+
+// Registers hold 1KB bank numbers also in 2KB windows. When bit 5 of $B003 is set, PPU A10 replaces lowest bit of
+// the register, otherwise the same 1KB bank is mapped in both halves of the window.
+func (mpr *vrc6) get2KBChrBank(register uint8, slot uint16) int {
+	if mpr.ppuMode&0b00100000 != 0 {
+		return int(register&^0x01) | int(slot&0x01)
+	}
+
+	return int(register)
+}
@@ -0,0 +1,102 @@
+package mappers_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"testing"
+)
+
+// CHR memory of given number of 1KB banks, each byte holds number of its bank
+func create1KBChrMem(banks int) []uint8 {
+	mem := make([]uint8, banks*0x0400)
+
+	for i := range mem {
+		mem[i] = uint8(i / 0x0400)
+	}
+
+	return mem
+}
+
+// Numbers of 1KB banks mapped in all CHR slots
+func readChrPages(mpr mappers.Mapper) []uint8 {
+	var pages []uint8
+	for addr := uint16(0); addr < 0x2000; addr += 0x0400 {
+		pages = append(pages, readPPU(mpr, addr))
+	}
+
+	return pages
+}
+
+func TestMapper26Banking(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper26)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: createChrMem(4), Mirroring: mappers.MirroringVertical})
+
+	mpr.Write("cpu", 0x8000, 2, false)
+	mpr.Write("cpu", 0xC000, 9, false)
+	a.Equal(uint8(2), readCPU(mpr, 0x8000), "16KB bank should be mapped at $8000")
+	a.Equal(uint8(4), readCPU(mpr, 0xC000), "8KB bank should be mapped at $C000")
+	a.Equal(uint8(7), readCPU(mpr, 0xE000), "Last bank should be fixed at $E000")
+
+	// VRC6b has A0 and A1 swapped - $D001 is at $D002
+	mpr.Write("cpu", 0xD002, 8, false)
+	a.Equal(uint8(2), readPPU(mpr, 0x0400), "Second 1KB CHR bank should be switched")
+
+	mpr.Write("cpu", 0xB003, 0b10100100, false)
+	a.Equal(uint8(mappers.MirroringHorizontal), mpr.GetMirroring(), "Mirroring should be horizontal")
+
+	mpr.Write("cpu", 0x6000, 0x55, false)
+	a.Equal(uint8(0x55), readCPU(mpr, 0x6000), "PRG RAM should be enabled")
+
+	// CHR modes 1-3
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: create1KBChrMem(32), Mirroring: mappers.MirroringVertical})
+
+	// R0-R5, VRC6b has A0 and A1 swapped
+	mpr.Write("cpu", 0xD000, 3, false)
+	mpr.Write("cpu", 0xD002, 6, false)
+	mpr.Write("cpu", 0xD001, 5, false)
+	mpr.Write("cpu", 0xD003, 7, false)
+	mpr.Write("cpu", 0xE000, 9, false)
+	mpr.Write("cpu", 0xE002, 12, false)
+
+	// 2KB windows use registers as 1KB bank numbers, the same bank is mapped in both halves
+	mpr.Write("cpu", 0xB003, 0b00000001, false)
+	a.Equal([]uint8{3, 3, 6, 6, 5, 5, 7, 7}, readChrPages(mpr), "Mode 1 should map R0-R3 to both halves of 2KB windows")
+
+	// With bit 5 set PPU A10 replaces lowest bit of the register
+	mpr.Write("cpu", 0xB003, 0b00100001, false)
+	a.Equal([]uint8{2, 3, 6, 7, 4, 5, 6, 7}, readChrPages(mpr), "Mode 1 should map R0-R3 as 2KB banks")
+
+	mpr.Write("cpu", 0xB003, 0b00000010, false)
+	a.Equal([]uint8{3, 6, 5, 7, 9, 9, 12, 12}, readChrPages(mpr), "Mode 2 should map R0-R3 as 1KB banks and R4-R5 to 2KB windows")
+
+	mpr.Write("cpu", 0xB003, 0b00100011, false)
+	a.Equal([]uint8{3, 6, 5, 7, 8, 9, 12, 13}, readChrPages(mpr), "Mode 3 should map R4-R5 as 2KB banks")
+}
+
+func TestMapper24Audio(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper24)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: createChrMem(4), Mirroring: mappers.MirroringVertical})
+
+	a.Equal(float32(0), mpr.AudioOutput(), "Audio should be silent on power up")
+
+	// Pulse 1 in ignore duty mode, full volume
+	mpr.Write("cpu", 0x9000, 0b10001111, false)
+	mpr.Write("cpu", 0x9002, 0b10000000, false)
+	mpr.ClockCPU()
+	high := mpr.AudioOutput()
+	a.InDelta(0.1488, high, 0.001, "Pulse at full volume should be as loud as APU pulse")
+
+	// Duty 0 - high for one of 16 steps
+	mpr.Write("cpu", 0x9000, 0b00001111, false)
+	mpr.Write("cpu", 0x9001, 0, false)
+	levels := map[float32]int{}
+	for i := 0; i < 16; i++ {
+		mpr.ClockCPU()
+		levels[mpr.AudioOutput()]++
+	}
+
+	a.Equal(1, levels[high], "Pulse should be high for one step")
+	a.Equal(15, levels[0], "Pulse should be low for 15 steps")
+}
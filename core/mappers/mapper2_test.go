@@ -0,0 +1,32 @@
+package mappers_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"testing"
+)
+
+func TestMapper2PrgBanking(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper2)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringVertical})
+
+	a.Equal(uint8(0), readCPU(mpr, 0x8000), "First bank should be at $8000")
+	a.Equal(uint8(7), readCPU(mpr, 0xC000), "Last bank should be fixed at $C000")
+
+	// Write to $C000 where ROM holds 7 - no bits are lost
+	mpr.Write("cpu", 0xC000, 5, false)
+	a.Equal(uint8(5), readCPU(mpr, 0x8000), "Bank 5 should be switched at $8000")
+	a.Equal(uint8(7), readCPU(mpr, 0xC000), "Last bank should stay at $C000")
+}
+
+func TestMapper2BusConflict(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper2)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringVertical})
+
+	// Switch to bank 4 and write 3 into it - ROM value 4 drives bus at the same time
+	mpr.Write("cpu", 0xC000, 4, false)
+	mpr.Write("cpu", 0x8000, 3, false)
+	a.Equal(uint8(0), readCPU(mpr, 0x8000), "Written value should be ANDed with ROM value")
+}
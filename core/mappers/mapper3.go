@@ -0,0 +1,89 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Mapper3 - CNROM
+// https://wiki.nesdev.com/w/index.php/CNROM
+type Mapper3 struct {
+	prgRomBanks int
+	chrRomBanks int
+	prgMem      []uint8
+	chrMem      []uint8
+	mirroring   uint8
+
+	chrBank uint8
+}
+
+func (mpr *Mapper3) Initialize(board Board) {
+	mpr.mirroring = board.Mirroring
+	mpr.prgRomBanks = board.prgRomBanks()
+	mpr.chrRomBanks = board.chrRomBanks()
+	mpr.prgMem = board.PrgMem
+	mpr.chrMem = board.getChrMem()
+
+	mpr.chrBank = 0
+}
+
+func (mpr *Mapper3) Read(busId string, addr uint16, _ bool) (uint8, bool) {
+	if busId == "cpu" && addr >= 0x8000 {
+		return mpr.prgMem[mpr.getPrgAddress(addr)], true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		return mpr.chrMem[mpr.getChrAddress(addr)], true
+	}
+
+	return 0, false
+}
+
+func (mpr *Mapper3) Write(busId string, addr uint16, data uint8, debug bool) bool {
+	if busId == "cpu" && addr >= 0x8000 {
+		if !debug {
+			// Bus conflict - ROM drives the bus at the same time, so only bits set in both values win
+			mpr.chrBank = data & mpr.prgMem[mpr.getPrgAddress(addr)]
+		}
+
+		return true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		if mpr.chrRomBanks == 0 {
+			mpr.chrMem[mpr.getChrAddress(addr)] = data
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetMirroring - mirroring is hardwired on the board
+func (mpr *Mapper3) GetMirroring() uint8 {
+	return mpr.mirroring
+}
+
+func (mpr *Mapper3) Serialize(w *state.Writer) {
+	writeChrRam(w, mpr.chrMem, mpr.chrRomBanks)
+	w.Uint8(mpr.chrBank)
+}
+
+func (mpr *Mapper3) Deserialize(r *state.Reader) {
+	readChrRam(r, mpr.chrMem, mpr.chrRomBanks)
+	mpr.chrBank = r.Uint8()
+}
+
+func (mpr *Mapper3) getPrgAddress(addr uint16) int {
+	if mpr.prgRomBanks > 1 {
+		// 32KB
+		return int(addr & 0x7FFF)
+	}
+
+	// 16KB
+	return int(addr & 0x3FFF)
+}
+
+func (mpr *Mapper3) getChrAddress(addr uint16) int {
+	// Number of 8KB banks
+	bankCount := len(mpr.chrMem) / 0x2000
+
+	return (int(mpr.chrBank)%bankCount)*0x2000 + int(addr&0x1FFF)
+}
@@ -0,0 +1,33 @@
+package mappers_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"testing"
+)
+
+func TestMapper3ChrBanking(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper3)
+	prgMem := createPrgMem(2)
+	prgMem[0] = 0xFF
+	mpr.Initialize(mappers.Board{PrgMem: prgMem, ChrMem: create1KBChrMem(32), Mirroring: mappers.MirroringVertical})
+
+	a.Equal(uint8(0), readPPU(mpr, 0x0000), "First CHR bank should be mapped on power up")
+
+	// Write to $8000 where ROM holds $FF - no bits are lost
+	mpr.Write("cpu", 0x8000, 2, false)
+	a.Equal(uint8(16), readPPU(mpr, 0x0000), "8KB CHR bank 2 should be switched")
+	a.Equal(uint8(23), readPPU(mpr, 0x1C00), "Whole 8KB should be switched")
+	a.Equal(uint8(1), readCPU(mpr, 0xC000), "PRG ROM should not be switched")
+}
+
+func TestMapper3BusConflict(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper3)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(2), ChrMem: create1KBChrMem(32), Mirroring: mappers.MirroringVertical})
+
+	// ROM value 1 drives bus at the same time
+	mpr.Write("cpu", 0xC000, 3, false)
+	a.Equal(uint8(8), readPPU(mpr, 0x0000), "Written value should be ANDed with ROM value")
+}
@@ -0,0 +1,256 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Minimal number of PPU cycles A12 has to stay low for rising edge to clock IRQ counter. MMC3 filters A12 with
+// M2 falling edges, which ignores short low pulses between pattern fetches. PPU reports single address per fetch
+// so this is a bit longer than on hardware to also ignore the gap around the end of scan line.
+const mmc3A12LowCycles = 16
+
+// Mapper4 - MMC3
+// https://wiki.nesdev.com/w/index.php/MMC3
+type Mapper4 struct {
+	prgRomBanks int
+	chrRomBanks int
+	prgMem      []uint8
+	chrMem      []uint8
+	sRam        prgRam
+
+	// CPPP PRRR - CHR inversion, PRG mode, register selected by next bank data write
+	bankSelect uint8
+	registers  [8]uint8
+	mirroring  uint8
+
+	// RW-- ---- - chip enable and write protection
+	prgRamProtect uint8
+
+	irqLatch   uint8
+	irqCounter uint8
+	irqReload  bool
+	irqEnabled bool
+	irqPending bool
+
+	isA12High    bool
+	lastA12Cycle uint64
+}
+
+func (mpr *Mapper4) Initialize(board Board) {
+	mpr.mirroring = board.Mirroring
+	mpr.prgRomBanks = board.prgRomBanks()
+	mpr.chrRomBanks = board.chrRomBanks()
+	mpr.prgMem = board.PrgMem
+	mpr.chrMem = board.getChrMem()
+	mpr.sRam = board.newPrgRam(0x2000)
+
+	mpr.bankSelect = 0
+	mpr.registers = [8]uint8{0, 2, 4, 5, 6, 7, 0, 1}
+	mpr.prgRamProtect = 0b10000000
+
+	mpr.irqLatch = 0
+	mpr.irqCounter = 0
+	mpr.irqReload = false
+	mpr.irqEnabled = false
+	mpr.irqPending = false
+
+	mpr.isA12High = false
+	mpr.lastA12Cycle = 0
+}
+
+func (mpr *Mapper4) Read(busId string, addr uint16, _ bool) (uint8, bool) {
+	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 {
+		if mpr.prgRamProtect&0b10000000 == 0 {
+			return 0, false
+		}
+
+		return mpr.sRam.read(addr)
+	}
+
+	if busId == "cpu" && addr >= 0x8000 {
+		return mpr.prgMem[mpr.getPrgAddress(addr)], true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		return mpr.chrMem[mpr.getChrAddress(addr)], true
+	}
+
+	return 0, false
+}
+
+func (mpr *Mapper4) Write(busId string, addr uint16, data uint8, debug bool) bool {
+	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 {
+		if mpr.prgRamProtect&0b11000000 != 0b10000000 {
+			return false
+		}
+
+		return mpr.sRam.write(addr, data)
+	}
+
+	if busId == "cpu" && addr >= 0x8000 {
+		if !debug {
+			mpr.writeRegister(addr, data)
+		}
+
+		return true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		if mpr.chrRomBanks == 0 {
+			mpr.chrMem[mpr.getChrAddress(addr)] = data
+			return true
+		}
+	}
+
+	return false
+}
+
+// Registers are selected by address range and lowest bit of the address
+func (mpr *Mapper4) writeRegister(addr uint16, data uint8) {
+	isEven := addr&0x01 == 0
+
+	switch {
+	case addr < 0xA000 && isEven:
+		mpr.bankSelect = data
+	case addr < 0xA000:
+		mpr.registers[mpr.bankSelect&0b111] = data
+	case addr < 0xC000 && isEven:
+		if data&0x01 == 0 {
+			mpr.mirroring = MirroringVertical
+		} else {
+			mpr.mirroring = MirroringHorizontal
+		}
+	case addr < 0xC000:
+		mpr.prgRamProtect = data
+	case addr < 0xE000 && isEven:
+		mpr.irqLatch = data
+	case addr < 0xE000:
+		// Counter is reloaded on next clock
+		mpr.irqCounter = 0
+		mpr.irqReload = true
+	case isEven:
+		// Disabling also acknowledges pending interrupt
+		mpr.irqEnabled = false
+		mpr.irqPending = false
+	default:
+		mpr.irqEnabled = true
+	}
+}
+
+// GetMirroring - mirroring is selected by $A000 register
+func (mpr *Mapper4) GetMirroring() uint8 {
+	return mpr.mirroring
+}
+
+func (mpr *Mapper4) Serialize(w *state.Writer) {
+	w.Bytes(mpr.sRam)
+	writeChrRam(w, mpr.chrMem, mpr.chrRomBanks)
+	w.Uint8(mpr.bankSelect)
+	w.Bytes(mpr.registers[:])
+	w.Uint8(mpr.mirroring)
+	w.Uint8(mpr.prgRamProtect)
+	w.Uint8(mpr.irqLatch)
+	w.Uint8(mpr.irqCounter)
+	w.Bool(mpr.irqReload)
+	w.Bool(mpr.irqEnabled)
+	w.Bool(mpr.irqPending)
+	w.Bool(mpr.isA12High)
+	w.Uint64(mpr.lastA12Cycle)
+}
+
+func (mpr *Mapper4) Deserialize(r *state.Reader) {
+	r.Bytes(mpr.sRam)
+	readChrRam(r, mpr.chrMem, mpr.chrRomBanks)
+	mpr.bankSelect = r.Uint8()
+	r.Bytes(mpr.registers[:])
+	mpr.mirroring = r.Uint8()
+	mpr.prgRamProtect = r.Uint8()
+	mpr.irqLatch = r.Uint8()
+	mpr.irqCounter = r.Uint8()
+	mpr.irqReload = r.Bool()
+	mpr.irqEnabled = r.Bool()
+	mpr.irqPending = r.Bool()
+	mpr.isA12High = r.Bool()
+	mpr.lastA12Cycle = r.Uint64()
+}
+
+// OnPPUAddress - scan line counter is clocked on filtered rising edges of PPU A12 line
+func (mpr *Mapper4) OnPPUAddress(addr uint16, cycle uint64) {
+	isHigh := addr&0x1000 != 0
+
+	if isHigh && !mpr.isA12High && cycle-mpr.lastA12Cycle >= mmc3A12LowCycles {
+		mpr.clockIRQCounter()
+	}
+
+	if isHigh {
+		mpr.lastA12Cycle = cycle
+	}
+
+	mpr.isA12High = isHigh
+}
+
+func (mpr *Mapper4) IsIRQAsserted() bool {
+	return mpr.irqPending
+}
+
+func (mpr *Mapper4) clockIRQCounter() {
+	if mpr.irqCounter == 0 || mpr.irqReload {
+		mpr.irqCounter = mpr.irqLatch
+		mpr.irqReload = false
+	} else {
+		mpr.irqCounter--
+	}
+
+	if mpr.irqCounter == 0 && mpr.irqEnabled {
+		mpr.irqPending = true
+	}
+}
+
+func (mpr *Mapper4) getPrgAddress(addr uint16) int {
+	// Number of 8KB banks
+	bankCount := len(mpr.prgMem) / 0x2000
+	secondLast := bankCount - 2
+
+	var bank int
+	switch (addr - 0x8000) / 0x2000 {
+	case 0:
+		// PRG mode 1 swaps $8000 and $C000
+		if mpr.bankSelect&0b01000000 == 0 {
+			bank = int(mpr.registers[6])
+		} else {
+			bank = secondLast
+		}
+	case 1:
+		bank = int(mpr.registers[7])
+	case 2:
+		if mpr.bankSelect&0b01000000 == 0 {
+			bank = secondLast
+		} else {
+			bank = int(mpr.registers[6])
+		}
+	case 3:
+		bank = bankCount - 1
+	}
+
+	return (bank%bankCount)*0x2000 + int(addr&0x1FFF)
+}
+
+func (mpr *Mapper4) getChrAddress(addr uint16) int {
+	// Number of 1KB banks
+	bankCount := len(mpr.chrMem) / 0x0400
+
+	// CHR inversion swaps 2KB banks at $0000 with 1KB banks at $1000
+	if mpr.bankSelect&0b10000000 != 0 {
+		addr ^= 0x1000
+	}
+
+	var bank int
+	switch {
+	case addr < 0x0800:
+		bank = int(mpr.registers[0]&0xFE) | int(addr>>10)&0x01
+	case addr < 0x1000:
+		bank = int(mpr.registers[1]&0xFE) | int(addr>>10)&0x01
+	default:
+		bank = int(mpr.registers[2+(addr-0x1000)/0x0400])
+	}
+
+	return (bank%bankCount)*0x0400 + int(addr&0x03FF)
+}
@@ -0,0 +1,105 @@
+package mappers_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"github.com/szymonkups/nesgo/core/state"
+	"testing"
+)
+
+// Simulates A12 rising edge once per scan line (341 PPU cycles)
+func clockMMC3ScanLines(mpr *mappers.Mapper4, cycle *uint64, lines int) {
+	for i := 0; i < lines; i++ {
+		mpr.OnPPUAddress(0x0000, *cycle)
+		mpr.OnPPUAddress(0x1000, *cycle+260)
+		// Short low pulse between sprite fetches should be filtered out
+		mpr.OnPPUAddress(0x2000, *cycle+262)
+		mpr.OnPPUAddress(0x1000, *cycle+266)
+		*cycle += 341
+	}
+}
+
+func TestMapper4PrgBanking(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper4)
+	// 8 16KB banks = 16 8KB banks, value read is number of 16KB bank
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringVertical})
+
+	mpr.Write("cpu", 0x8000, 6, false)
+	mpr.Write("cpu", 0x8001, 4, false)
+	mpr.Write("cpu", 0x8000, 7, false)
+	mpr.Write("cpu", 0x8001, 3, false)
+
+	a.Equal(uint8(2), readCPU(mpr, 0x8000), "R6 should be mapped at $8000")
+	a.Equal(uint8(1), readCPU(mpr, 0xA000), "R7 should be mapped at $A000")
+	a.Equal(uint8(7), readCPU(mpr, 0xC000), "Second last bank should be fixed at $C000")
+	a.Equal(uint8(7), readCPU(mpr, 0xE000), "Last bank should be fixed at $E000")
+
+	// PRG mode 1 - $8000 and $C000 are swapped
+	mpr.Write("cpu", 0x8000, 0b01000110, false)
+	a.Equal(uint8(7), readCPU(mpr, 0x8000), "Second last bank should be fixed at $8000")
+	a.Equal(uint8(2), readCPU(mpr, 0xC000), "R6 should be mapped at $C000")
+}
+
+func TestMapper4Mirroring(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper4)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringVertical})
+
+	mpr.Write("cpu", 0xA000, 1, false)
+	a.Equal(uint8(mappers.MirroringHorizontal), mpr.GetMirroring(), "Mirroring should be horizontal")
+
+	mpr.Write("cpu", 0xA000, 0, false)
+	a.Equal(uint8(mappers.MirroringVertical), mpr.GetMirroring(), "Mirroring should be vertical")
+}
+
+func TestMapper4IRQ(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper4)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringVertical})
+	cycle := uint64(1000)
+
+	// Latch 3, reload and enable
+	mpr.Write("cpu", 0xC000, 3, false)
+	mpr.Write("cpu", 0xC001, 0, false)
+	mpr.Write("cpu", 0xE001, 0, false)
+
+	// Reload on first clock, then 3 decrements
+	clockMMC3ScanLines(mpr, &cycle, 3)
+	a.False(mpr.IsIRQAsserted(), "IRQ should not be asserted before counter reaches 0")
+
+	clockMMC3ScanLines(mpr, &cycle, 1)
+	a.True(mpr.IsIRQAsserted(), "IRQ should be asserted when counter reaches 0")
+
+	mpr.Write("cpu", 0xE000, 0, false)
+	a.False(mpr.IsIRQAsserted(), "Writing to $E000 should acknowledge IRQ")
+
+	clockMMC3ScanLines(mpr, &cycle, 4)
+	a.False(mpr.IsIRQAsserted(), "IRQ should not be asserted when disabled")
+}
+
+func TestMapper4State(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper4)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringVertical})
+
+	mpr.Write("cpu", 0x8000, 6, false)
+	mpr.Write("cpu", 0x8001, 4, false)
+	mpr.Write("cpu", 0x6000, 0x42, false)
+	mpr.Write("ppu", 0x0010, 0x24, false)
+
+	w := new(state.Writer)
+	mpr.Serialize(w)
+
+	mpr.Write("cpu", 0x8001, 5, false)
+	mpr.Write("cpu", 0x6000, 0x00, false)
+	mpr.Write("ppu", 0x0010, 0x00, false)
+
+	r := state.NewReader(w.GetData())
+	mpr.Deserialize(r)
+	a.NoError(r.Err())
+
+	a.Equal(uint8(2), readCPU(mpr, 0x8000), "PRG bank should be restored")
+	a.Equal(uint8(0x42), readCPU(mpr, 0x6000), "PRG RAM should be restored")
+	a.Equal(uint8(0x24), readPPU(mpr, 0x0010), "CHR RAM should be restored")
+}
@@ -0,0 +1,662 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// ExRAM modes selected by $5104
+const (
+	mmc5ExRAMNameTable = iota
+	mmc5ExRAMExtendedAttributes
+	mmc5ExRAMReadWrite
+	mmc5ExRAMReadOnly
+)
+
+// Nametable sources selected by $5105
+const (
+	mmc5NameTableCIRAMA = iota
+	mmc5NameTableCIRAMB
+	mmc5NameTableExRAM
+	mmc5NameTableFill
+)
+
+// Mapper5 - MMC5
+// https://wiki.nesdev.com/w/index.php/MMC5
+type Mapper5 struct {
+	prgRomBanks int
+	chrRomBanks int
+	prgMem      []uint8
+	chrMem      []uint8
+	sRam        prgRam
+	exRam       [0x400]uint8
+
+	prgMode       uint8
+	chrMode       uint8
+	prgRamProtect [2]uint8
+	exRamMode     uint8
+
+	// DDCC BBAA - source of each of four nametables
+	nameTableMapping uint8
+	fillTile         uint8
+	fillColor        uint8
+
+	// $5113-$5117 - RAM bank at $6000 and four PRG bank registers
+	prgBanks [5]uint8
+
+	// $5120-$5127 (set A, sprites) and $5128-$512B (set B, background in 8x16 sprite mode)
+	chrBanks      [12]uint16
+	upperChrBits  uint8
+	isLastChrSetB bool
+
+	// ES-T TTTT - split enable, side and tile count
+	splitMode   uint8
+	splitScroll uint8
+	splitBank   uint8
+
+	irqCompare uint8
+	irqCounter uint8
+	irqEnabled bool
+	irqPending bool
+	inFrame    bool
+
+	multiplicand uint8
+	multiplier   uint8
+
+	audio *mmc5Audio
+
+	// PPU state snooped from CPU writes to $2000 and $2001
+	isSprite16  bool
+	isRendering bool
+
+	// Last rendering fetch reported by PPU
+	isFetching       bool
+	fetchKind        uint8
+	fetchScanLine    int16
+	fetchTile        uint8
+	lastNameTableIdx uint16
+}
+
+func (mpr *Mapper5) Initialize(board Board) {
+	mpr.prgRomBanks = board.prgRomBanks()
+	mpr.chrRomBanks = board.chrRomBanks()
+	mpr.prgMem = board.PrgMem
+	mpr.chrMem = board.getChrMem()
+
+	// Largest PRG RAM used by MMC5 boards, unless header says otherwise
+	mpr.sRam = board.newPrgRam(0x10000)
+	mpr.exRam = [0x400]uint8{}
+
+	// Power up with last bank at $E000
+	mpr.prgMode = 3
+	mpr.chrMode = 0
+	mpr.prgRamProtect = [2]uint8{}
+	mpr.exRamMode = mmc5ExRAMNameTable
+	mpr.nameTableMapping = 0
+	mpr.fillTile = 0
+	mpr.fillColor = 0
+	mpr.prgBanks = [5]uint8{0, 0xFF, 0xFF, 0xFF, 0xFF}
+	mpr.chrBanks = [12]uint16{}
+	mpr.upperChrBits = 0
+	mpr.isLastChrSetB = false
+
+	mpr.splitMode = 0
+	mpr.splitScroll = 0
+	mpr.splitBank = 0
+
+	mpr.irqCompare = 0
+	mpr.irqCounter = 0
+	mpr.irqEnabled = false
+	mpr.irqPending = false
+	mpr.inFrame = false
+
+	mpr.multiplicand = 0xFF
+	mpr.multiplier = 0xFF
+
+	mpr.audio = newMMC5Audio()
+
+	mpr.isSprite16 = false
+	mpr.isRendering = false
+	mpr.isFetching = false
+}
+
+func (mpr *Mapper5) Read(busId string, addr uint16, debug bool) (uint8, bool) {
+	if busId == "cpu" {
+		return mpr.readCPU(addr, debug)
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		return mpr.chrMem[mpr.getChrAddress(addr)], true
+	}
+
+	if busId == "ppu" && addr >= 0x2000 && addr < 0x3F00 {
+		return mpr.readNameTable(addr, debug)
+	}
+
+	return 0, false
+}
+
+func (mpr *Mapper5) Write(busId string, addr uint16, data uint8, debug bool) bool {
+	if busId == "cpu" {
+		return mpr.writeCPU(addr, data, debug)
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		if mpr.chrRomBanks == 0 {
+			mpr.chrMem[mpr.getChrAddress(addr)] = data
+			return true
+		}
+	}
+
+	if busId == "ppu" && addr >= 0x2000 && addr < 0x3F00 {
+		switch mpr.getNameTableSource(addr) {
+		case mmc5NameTableExRAM:
+			if mpr.exRamMode <= mmc5ExRAMExtendedAttributes {
+				mpr.exRam[addr&0x03FF] = data
+			}
+
+			return true
+		case mmc5NameTableFill:
+			return true
+		}
+	}
+
+	return false
+}
+
+func (mpr *Mapper5) readCPU(addr uint16, debug bool) (uint8, bool) {
+	switch {
+	case addr == 0x5204:
+		// IRQ status - reading acknowledges pending interrupt
+		status := uint8(0)
+
+		if mpr.irqPending {
+			status |= 0b10000000
+		}
+
+		if mpr.inFrame {
+			status |= 0b01000000
+		}
+
+		if !debug {
+			mpr.irqPending = false
+		}
+
+		return status, true
+
+	case addr == 0x5015:
+		return mpr.audio.readStatus(), true
+
+	case addr == 0x5205:
+		return uint8(uint16(mpr.multiplicand) * uint16(mpr.multiplier)), true
+
+	case addr == 0x5206:
+		return uint8(uint16(mpr.multiplicand) * uint16(mpr.multiplier) >> 8), true
+
+	case addr >= 0x5C00 && addr < 0x6000:
+		// ExRAM is readable only in modes 2 and 3
+		if mpr.exRamMode < mmc5ExRAMReadWrite {
+			return 0, false
+		}
+
+		return mpr.exRam[addr-0x5C00], true
+
+	case addr >= 0x6000:
+		offset, isRam := mpr.getPrgAddress(addr)
+
+		if isRam {
+			// Boards without PRG RAM leave open bus
+			if len(mpr.sRam) == 0 {
+				return 0, false
+			}
+
+			return mpr.sRam[offset], true
+		}
+
+		return mpr.prgMem[offset], true
+	}
+
+	return 0, false
+}
+
+func (mpr *Mapper5) writeCPU(addr uint16, data uint8, debug bool) bool {
+	// MMC5 snoops PPU control registers to know sprite size and whether rendering is enabled,
+	// write is not handled here so it still reaches PPU
+	if addr >= 0x2000 && addr < 0x4000 && !debug {
+		switch addr & 0x2007 {
+		case 0x2000:
+			mpr.isSprite16 = data&0b00100000 != 0
+		case 0x2001:
+			mpr.isRendering = data&0b00011000 != 0
+
+			if !mpr.isRendering {
+				mpr.inFrame = false
+				mpr.isFetching = false
+			}
+		}
+
+		return false
+	}
+
+	if addr >= 0x5C00 && addr < 0x6000 {
+		// ExRAM is read only in mode 3
+		if mpr.exRamMode != mmc5ExRAMReadOnly {
+			mpr.exRam[addr-0x5C00] = data
+		}
+
+		return true
+	}
+
+	if addr >= 0x6000 {
+		offset, isRam := mpr.getPrgAddress(addr)
+
+		if isRam && mpr.isPrgRamWritable() && len(mpr.sRam) > 0 {
+			mpr.sRam[offset] = data
+		}
+
+		return true
+	}
+
+	if addr >= 0x5000 && addr <= 0x5015 {
+		if !debug {
+			mpr.audio.write(addr, data)
+		}
+
+		return true
+	}
+
+	if addr < 0x5100 || addr > 0x5206 {
+		return false
+	}
+
+	if debug {
+		return true
+	}
+
+	switch {
+	case addr == 0x5100:
+		mpr.prgMode = data & 0b11
+	case addr == 0x5101:
+		mpr.chrMode = data & 0b11
+	case addr == 0x5102:
+		mpr.prgRamProtect[0] = data & 0b11
+	case addr == 0x5103:
+		mpr.prgRamProtect[1] = data & 0b11
+	case addr == 0x5104:
+		mpr.exRamMode = data & 0b11
+	case addr == 0x5105:
+		mpr.nameTableMapping = data
+	case addr == 0x5106:
+		mpr.fillTile = data
+	case addr == 0x5107:
+		mpr.fillColor = data & 0b11
+	case addr >= 0x5113 && addr <= 0x5117:
+		mpr.prgBanks[addr-0x5113] = data
+	case addr >= 0x5120 && addr <= 0x512B:
+		// Upper bits are latched from $5130 on every bank write
+		mpr.chrBanks[addr-0x5120] = uint16(mpr.upperChrBits)<<8 | uint16(data)
+		mpr.isLastChrSetB = addr >= 0x5128
+	case addr == 0x5130:
+		mpr.upperChrBits = data & 0b11
+	case addr == 0x5200:
+		mpr.splitMode = data
+	case addr == 0x5201:
+		mpr.splitScroll = data
+	case addr == 0x5202:
+		mpr.splitBank = data
+	case addr == 0x5203:
+		mpr.irqCompare = data
+	case addr == 0x5204:
+		mpr.irqEnabled = data&0b10000000 != 0
+	case addr == 0x5205:
+		mpr.multiplicand = data
+	case addr == 0x5206:
+		mpr.multiplier = data
+	}
+
+	return true
+}
+
+// GetMirroring - approximation of nametable mapping, console VRAM pages are selected by GetNameTablePage
+func (mpr *Mapper5) GetMirroring() uint8 {
+	switch mpr.nameTableMapping {
+	case 0b01000100:
+		return MirroringVertical
+	case 0b00000000:
+		return MirroringSingleScreenLow
+	case 0b01010101:
+		return MirroringSingleScreenHigh
+	default:
+		return MirroringHorizontal
+	}
+}
+
+func (mpr *Mapper5) Serialize(w *state.Writer) {
+	w.Bytes(mpr.sRam)
+	w.Bytes(mpr.exRam[:])
+	writeChrRam(w, mpr.chrMem, mpr.chrRomBanks)
+
+	w.Uint8(mpr.prgMode)
+	w.Uint8(mpr.chrMode)
+	w.Bytes(mpr.prgRamProtect[:])
+	w.Uint8(mpr.exRamMode)
+	w.Uint8(mpr.nameTableMapping)
+	w.Uint8(mpr.fillTile)
+	w.Uint8(mpr.fillColor)
+	w.Bytes(mpr.prgBanks[:])
+
+	for _, bank := range mpr.chrBanks {
+		w.Uint16(bank)
+	}
+
+	w.Uint8(mpr.upperChrBits)
+	w.Bool(mpr.isLastChrSetB)
+
+	w.Uint8(mpr.splitMode)
+	w.Uint8(mpr.splitScroll)
+	w.Uint8(mpr.splitBank)
+
+	w.Uint8(mpr.irqCompare)
+	w.Uint8(mpr.irqCounter)
+	w.Bool(mpr.irqEnabled)
+	w.Bool(mpr.irqPending)
+	w.Bool(mpr.inFrame)
+
+	w.Uint8(mpr.multiplicand)
+	w.Uint8(mpr.multiplier)
+
+	mpr.audio.serialize(w)
+
+	w.Bool(mpr.isSprite16)
+	w.Bool(mpr.isRendering)
+	w.Bool(mpr.isFetching)
+	w.Uint8(mpr.fetchKind)
+	w.Int16(mpr.fetchScanLine)
+	w.Uint8(mpr.fetchTile)
+	w.Uint16(mpr.lastNameTableIdx)
+}
+
+func (mpr *Mapper5) Deserialize(r *state.Reader) {
+	r.Bytes(mpr.sRam)
+	r.Bytes(mpr.exRam[:])
+	readChrRam(r, mpr.chrMem, mpr.chrRomBanks)
+
+	mpr.prgMode = r.Uint8()
+	mpr.chrMode = r.Uint8()
+	r.Bytes(mpr.prgRamProtect[:])
+	mpr.exRamMode = r.Uint8()
+	mpr.nameTableMapping = r.Uint8()
+	mpr.fillTile = r.Uint8()
+	mpr.fillColor = r.Uint8()
+	r.Bytes(mpr.prgBanks[:])
+
+	for i := range mpr.chrBanks {
+		mpr.chrBanks[i] = r.Uint16()
+	}
+
+	mpr.upperChrBits = r.Uint8()
+	mpr.isLastChrSetB = r.Bool()
+
+	mpr.splitMode = r.Uint8()
+	mpr.splitScroll = r.Uint8()
+	mpr.splitBank = r.Uint8()
+
+	mpr.irqCompare = r.Uint8()
+	mpr.irqCounter = r.Uint8()
+	mpr.irqEnabled = r.Bool()
+	mpr.irqPending = r.Bool()
+	mpr.inFrame = r.Bool()
+
+	mpr.multiplicand = r.Uint8()
+	mpr.multiplier = r.Uint8()
+
+	mpr.audio.deserialize(r)
+
+	mpr.isSprite16 = r.Bool()
+	mpr.isRendering = r.Bool()
+	mpr.isFetching = r.Bool()
+	mpr.fetchKind = r.Uint8()
+	mpr.fetchScanLine = r.Int16()
+	mpr.fetchTile = r.Uint8()
+	mpr.lastNameTableIdx = r.Uint16()
+}
+
+// GetNameTablePage - console VRAM page for nametable mapped to CIRAM, other sources are handled by the mapper
+func (mpr *Mapper5) GetNameTablePage(index uint8) uint8 {
+	return (mpr.nameTableMapping >> ((index & 0b11) * 2)) & 0b01
+}
+
+func (mpr *Mapper5) ClockCPU() {
+	mpr.audio.clock()
+}
+
+func (mpr *Mapper5) AudioOutput() float32 {
+	return mpr.audio.output()
+}
+
+func (mpr *Mapper5) IsIRQAsserted() bool {
+	return mpr.irqPending && mpr.irqEnabled
+}
+
+// OnRenderingFetch - MMC5 detects new scan line when PPU fetches nametable of the third tile, which is the
+// first fetch not made during previous line's prefetch.
+func (mpr *Mapper5) OnRenderingFetch(kind uint8, scanLine int16, tile uint8) {
+	mpr.isFetching = true
+	mpr.fetchKind = kind
+	mpr.fetchScanLine = scanLine
+	mpr.fetchTile = tile
+
+	if kind == FetchNameTable && tile == 2 {
+		mpr.onScanLine(scanLine)
+	}
+}
+
+func (mpr *Mapper5) onScanLine(scanLine int16) {
+	// No more lines are rendered, PPU goes idle until next frame
+	if scanLine >= 240 {
+		mpr.inFrame = false
+		mpr.isFetching = false
+		return
+	}
+
+	if !mpr.inFrame {
+		mpr.inFrame = true
+		mpr.irqCounter = 0
+		return
+	}
+
+	mpr.irqCounter++
+
+	if mpr.irqCounter == mpr.irqCompare {
+		mpr.irqPending = true
+	}
+}
+
+func (mpr *Mapper5) isPrgRamWritable() bool {
+	return mpr.prgRamProtect[0] == 0b10 && mpr.prgRamProtect[1] == 0b01
+}
+
+// Returns offset for CPU address $6000-$FFFF and whether it's in PRG RAM or ROM
+func (mpr *Mapper5) getPrgAddress(addr uint16) (int, bool) {
+	if addr < 0x8000 {
+		return mpr.getRamOffset(int(mpr.prgBanks[0]&0b111)*0x2000 + int(addr&0x1FFF)), true
+	}
+
+	// Index of $5114-$5117 register and size of the bank it selects
+	var index, size int
+
+	switch mpr.prgMode {
+	case 0:
+		index, size = 4, 0x8000
+	case 1:
+		if addr < 0xC000 {
+			index, size = 2, 0x4000
+		} else {
+			index, size = 4, 0x4000
+		}
+	case 2:
+		if addr < 0xC000 {
+			index, size = 2, 0x4000
+		} else if addr < 0xE000 {
+			index, size = 3, 0x2000
+		} else {
+			index, size = 4, 0x2000
+		}
+	case 3:
+		index, size = 1+int(addr-0x8000)/0x2000, 0x2000
+	}
+
+	// Bit 7 selects ROM, $5117 always selects ROM
+	register := mpr.prgBanks[index]
+	isRam := register&0b10000000 == 0 && index != 4
+
+	// Bank numbers are in 8KB units, bigger banks ignore lowest bits
+	bank := int(register&0x7F) &^ (size/0x2000 - 1)
+	offset := bank*0x2000 + int(addr)&(size-1)
+
+	if isRam {
+		return mpr.getRamOffset(offset), true
+	}
+
+	return offset % len(mpr.prgMem), false
+}
+
+// PRG RAM is mirrored when it's smaller than selected bank
+func (mpr *Mapper5) getRamOffset(offset int) int {
+	if len(mpr.sRam) == 0 {
+		return 0
+	}
+
+	return offset % len(mpr.sRam)
+}
+
+func (mpr *Mapper5) getChrAddress(addr uint16) int {
+	isBackground := mpr.isFetching && mpr.fetchKind != FetchSpritePattern
+
+	// Split screen and extended attributes use their own 4KB banks for background
+	if isBackground && mpr.isSplitTile() {
+		y := mpr.getSplitY()
+		offset := int(mpr.splitBank)*0x1000 + (int(addr&0x0FF8) | y&0x07)
+
+		return offset % len(mpr.chrMem)
+	}
+
+	if isBackground && mpr.exRamMode == mmc5ExRAMExtendedAttributes {
+		bank := int(mpr.exRam[mpr.lastNameTableIdx]&0x3F) | int(mpr.upperChrBits)<<6
+
+		return (bank*0x1000 + int(addr&0x0FFF)) % len(mpr.chrMem)
+	}
+
+	// In 8x16 sprite mode background uses set B and sprites set A, otherwise last written set is used
+	useSetB := mpr.isLastChrSetB
+	if mpr.isSprite16 && mpr.isFetching {
+		useSetB = isBackground
+	}
+
+	var bank uint16
+	var size int
+
+	if useSetB {
+		// Set B has only 4KB, mirrored in both pattern tables
+		switch mpr.chrMode {
+		case 0:
+			bank, size = mpr.chrBanks[11], 0x2000
+		case 1:
+			bank, size = mpr.chrBanks[11], 0x1000
+		case 2:
+			bank, size = mpr.chrBanks[9+(addr&0x0FFF)/0x0800*2], 0x0800
+		case 3:
+			bank, size = mpr.chrBanks[8+(addr&0x0FFF)/0x0400], 0x0400
+		}
+	} else {
+		switch mpr.chrMode {
+		case 0:
+			bank, size = mpr.chrBanks[7], 0x2000
+		case 1:
+			bank, size = mpr.chrBanks[3+addr/0x1000*4], 0x1000
+		case 2:
+			bank, size = mpr.chrBanks[1+addr/0x0800*2], 0x0800
+		case 3:
+			bank, size = mpr.chrBanks[addr/0x0400], 0x0400
+		}
+	}
+
+	return (int(bank)*size + int(addr)&(size-1)) % len(mpr.chrMem)
+}
+
+func (mpr *Mapper5) getNameTableSource(addr uint16) uint8 {
+	index := (addr >> 10) & 0b11
+
+	return (mpr.nameTableMapping >> (index * 2)) & 0b11
+}
+
+func (mpr *Mapper5) readNameTable(addr uint16, debug bool) (uint8, bool) {
+	offset := addr & 0x03FF
+
+	if mpr.isFetching && !debug {
+		// Split screen takes nametable and attributes from ExRAM
+		if mpr.isSplitTile() {
+			y := mpr.getSplitY()
+			column := int(mpr.fetchTile) & 0x1F
+
+			if mpr.fetchKind == FetchAttribute {
+				attribute := mpr.exRam[0x3C0+y/32*8+column/4]
+				shift := (y/16&0x01)*4 + (column/2&0x01)*2
+
+				return ((attribute >> shift) & 0b11) * 0b01010101, true
+			}
+
+			return mpr.exRam[y/8*32+column], true
+		}
+
+		// Every tile has its own palette in extended attribute mode, it's copied to all four quadrants
+		if mpr.exRamMode == mmc5ExRAMExtendedAttributes {
+			if mpr.fetchKind == FetchNameTable {
+				mpr.lastNameTableIdx = offset
+			}
+
+			if mpr.fetchKind == FetchAttribute {
+				return (mpr.exRam[mpr.lastNameTableIdx] >> 6) * 0b01010101, true
+			}
+		}
+	}
+
+	switch mpr.getNameTableSource(addr) {
+	case mmc5NameTableExRAM:
+		if mpr.exRamMode > mmc5ExRAMExtendedAttributes {
+			return 0, true
+		}
+
+		return mpr.exRam[offset], true
+
+	case mmc5NameTableFill:
+		if offset >= 0x03C0 {
+			return mpr.fillColor * 0b01010101, true
+		}
+
+		return mpr.fillTile, true
+	}
+
+	// Console VRAM
+	return 0, false
+}
+
+func (mpr *Mapper5) isSplitTile() bool {
+	if mpr.splitMode&0b10000000 == 0 || mpr.exRamMode > mmc5ExRAMExtendedAttributes {
+		return false
+	}
+
+	// Tiles 0-31 are visible on the screen, 32 and 33 are fetched for fine X scroll
+	if mpr.fetchTile > 33 {
+		return false
+	}
+
+	count := mpr.splitMode & 0x1F
+	if mpr.splitMode&0b01000000 == 0 {
+		return mpr.fetchTile < count
+	}
+
+	return mpr.fetchTile >= count
+}
+
+// Vertical position in split region, it's scrolled independently and wraps at 240 lines
+func (mpr *Mapper5) getSplitY() int {
+	return (int(mpr.splitScroll) + int(mpr.fetchScanLine)) % 240
+}
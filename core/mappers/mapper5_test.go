@@ -0,0 +1,136 @@
+package mappers_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"testing"
+)
+
+// Reports background fetches of whole scan line, like PPU does
+func renderMMC5ScanLine(mpr *mappers.Mapper5, scanLine int16) {
+	for tile := uint8(0); tile < 34; tile++ {
+		mpr.OnRenderingFetch(mappers.FetchNameTable, scanLine, tile)
+		mpr.OnRenderingFetch(mappers.FetchAttribute, scanLine, tile)
+	}
+}
+
+func TestMapper5PrgBanking(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper5)
+	// 16KB banks are 2 8KB banks, value read is number of 16KB bank
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringVertical})
+
+	a.Equal(uint8(7), readCPU(mpr, 0xE000), "Last bank should be mapped at $E000 on power up")
+
+	// Mode 1 - two 16KB banks
+	mpr.Write("cpu", 0x5100, 1, false)
+	mpr.Write("cpu", 0x5115, 0x80|4, false)
+	mpr.Write("cpu", 0x5117, 0x80|6, false)
+	a.Equal(uint8(2), readCPU(mpr, 0x8000), "16KB bank should be mapped at $8000")
+	a.Equal(uint8(2), readCPU(mpr, 0xA000), "16KB bank should be mapped at $A000")
+	a.Equal(uint8(3), readCPU(mpr, 0xC000), "16KB bank should be mapped at $C000")
+
+	// PRG RAM at $8000 is writable only when unlocked
+	mpr.Write("cpu", 0x5115, 0x00, false)
+	mpr.Write("cpu", 0x8000, 0x55, false)
+	a.Equal(uint8(0), readCPU(mpr, 0x8000), "PRG RAM should be write protected")
+
+	mpr.Write("cpu", 0x5102, 0b10, false)
+	mpr.Write("cpu", 0x5103, 0b01, false)
+	mpr.Write("cpu", 0x8000, 0x55, false)
+	a.Equal(uint8(0x55), readCPU(mpr, 0x8000), "PRG RAM should be writable")
+}
+
+func TestMapper5Multiplier(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper5)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringVertical})
+
+	mpr.Write("cpu", 0x5205, 200, false)
+	mpr.Write("cpu", 0x5206, 100, false)
+	a.Equal(uint8(20000&0xFF), readCPU(mpr, 0x5205), "Low byte of product should be returned")
+	a.Equal(uint8(20000>>8), readCPU(mpr, 0x5206), "High byte of product should be returned")
+}
+
+func TestMapper5FillMode(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper5)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringVertical})
+
+	// $2000 - CIRAM A, $2400 - CIRAM B, $2800 - ExRAM, $2C00 - fill
+	mpr.Write("cpu", 0x5105, 0b11100100, false)
+	mpr.Write("cpu", 0x5106, 0x42, false)
+	mpr.Write("cpu", 0x5107, 0x02, false)
+	mpr.Write("cpu", 0x5C10, 0x99, false)
+
+	a.Equal(uint8(1), mpr.GetNameTablePage(1), "Second nametable should use CIRAM B")
+
+	_, handled := mpr.Read("ppu", 0x2000, false)
+	a.False(handled, "CIRAM nametable should be handled by console VRAM")
+	a.Equal(uint8(0x99), readPPU(mpr, 0x2810), "ExRAM nametable should be read")
+	a.Equal(uint8(0x42), readPPU(mpr, 0x2C00), "Fill tile should be read")
+	a.Equal(uint8(0b10101010), readPPU(mpr, 0x2FC0), "Fill color should be read in all quadrants")
+}
+
+func TestMapper5IRQ(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper5)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringVertical})
+
+	mpr.Write("cpu", 0x2001, 0b00011000, false)
+	mpr.Write("cpu", 0x5203, 10, false)
+	mpr.Write("cpu", 0x5204, 0x80, false)
+
+	for line := int16(0); line < 10; line++ {
+		renderMMC5ScanLine(mpr, line)
+	}
+
+	a.False(mpr.IsIRQAsserted(), "IRQ should not be asserted before compared scan line")
+	a.Equal(uint8(0b01000000), readCPU(mpr, 0x5204), "PPU should be in frame")
+
+	renderMMC5ScanLine(mpr, 10)
+	a.True(mpr.IsIRQAsserted(), "IRQ should be asserted on compared scan line")
+	a.Equal(uint8(0b11000000), readCPU(mpr, 0x5204), "IRQ should be pending")
+	a.False(mpr.IsIRQAsserted(), "Reading status should acknowledge IRQ")
+
+	renderMMC5ScanLine(mpr, 240)
+	a.Equal(uint8(0), readCPU(mpr, 0x5204), "PPU should not be in frame after last scan line")
+}
+
+func TestMapper5ExtendedAttributes(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper5)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: createChrMem(4), Mirroring: mappers.MirroringVertical})
+
+	mpr.Write("cpu", 0x2001, 0b00011000, false)
+	mpr.Write("cpu", 0x5104, 0x01, false)
+	mpr.Write("cpu", 0x5C05, 0b11000101, false)
+
+	mpr.OnRenderingFetch(mappers.FetchNameTable, 0, 5)
+	mpr.Read("ppu", 0x2005, false)
+
+	mpr.OnRenderingFetch(mappers.FetchAttribute, 0, 5)
+	a.Equal(uint8(0xFF), readPPU(mpr, 0x23C1), "Palette should be taken from ExRAM")
+
+	mpr.OnRenderingFetch(mappers.FetchBackgroundPattern, 0, 5)
+	a.Equal(uint8(5), readPPU(mpr, 0x0010), "4KB CHR bank should be taken from ExRAM")
+}
+
+func TestMapper5Audio(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper5)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringVertical})
+
+	mpr.Write("cpu", 0x5011, 0x80, false)
+	pcm := mpr.AudioOutput()
+	a.Greater(pcm, float32(0), "PCM level should be output")
+
+	mpr.Write("cpu", 0x5011, 0x00, false)
+	a.Equal(pcm, mpr.AudioOutput(), "Writing 0 should not change PCM level")
+
+	// Pulse 1 with constant volume, enabled, length counter loaded
+	mpr.Write("cpu", 0x5015, 0b01, false)
+	mpr.Write("cpu", 0x5000, 0b10111111, false)
+	mpr.Write("cpu", 0x5003, 0b00001000, false)
+	a.Equal(uint8(0b01), readCPU(mpr, 0x5015), "Pulse 1 length counter should be active")
+}
@@ -0,0 +1,93 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Mapper66 - GxROM
+// https://wiki.nesdev.com/w/index.php/GxROM
+type Mapper66 struct {
+	prgRomBanks int
+	chrRomBanks int
+	prgMem      []uint8
+	chrMem      []uint8
+	mirroring   uint8
+
+	// --PP --CC - 32KB PRG bank and 8KB CHR bank
+	bankSelect uint8
+}
+
+func (mpr *Mapper66) Initialize(board Board) {
+	mpr.mirroring = board.Mirroring
+	mpr.prgRomBanks = board.prgRomBanks()
+	mpr.chrRomBanks = board.chrRomBanks()
+	mpr.prgMem = board.PrgMem
+	mpr.chrMem = board.getChrMem()
+
+	mpr.bankSelect = 0
+}
+
+func (mpr *Mapper66) Read(busId string, addr uint16, _ bool) (uint8, bool) {
+	if busId == "cpu" && addr >= 0x8000 {
+		return mpr.prgMem[mpr.getPrgAddress(addr)], true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		return mpr.chrMem[mpr.getChrAddress(addr)], true
+	}
+
+	return 0, false
+}
+
+func (mpr *Mapper66) Write(busId string, addr uint16, data uint8, debug bool) bool {
+	if busId == "cpu" && addr >= 0x8000 {
+		if !debug {
+			// Bus conflict - ROM drives the bus at the same time, so only bits set in both values win
+			mpr.bankSelect = data & mpr.prgMem[mpr.getPrgAddress(addr)]
+		}
+
+		return true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		if mpr.chrRomBanks == 0 {
+			mpr.chrMem[mpr.getChrAddress(addr)] = data
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetMirroring - mirroring is hardwired on the board
+func (mpr *Mapper66) GetMirroring() uint8 {
+	return mpr.mirroring
+}
+
+func (mpr *Mapper66) Serialize(w *state.Writer) {
+	writeChrRam(w, mpr.chrMem, mpr.chrRomBanks)
+	w.Uint8(mpr.bankSelect)
+}
+
+func (mpr *Mapper66) Deserialize(r *state.Reader) {
+	readChrRam(r, mpr.chrMem, mpr.chrRomBanks)
+	mpr.bankSelect = r.Uint8()
+}
+
+func (mpr *Mapper66) getPrgAddress(addr uint16) int {
+	// Number of 32KB banks
+	bankCount := len(mpr.prgMem) / 0x8000
+	if bankCount == 0 {
+		return int(addr) & (len(mpr.prgMem) - 1)
+	}
+
+	bank := int(mpr.bankSelect>>4&0b11) % bankCount
+
+	return bank*0x8000 + int(addr&0x7FFF)
+}
+
+func (mpr *Mapper66) getChrAddress(addr uint16) int {
+	// Number of 8KB banks
+	bankCount := len(mpr.chrMem) / 0x2000
+	bank := int(mpr.bankSelect&0b11) % bankCount
+
+	return bank*0x2000 + int(addr&0x1FFF)
+}
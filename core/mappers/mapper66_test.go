@@ -0,0 +1,28 @@
+package mappers_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"testing"
+)
+
+func TestMapper66Banking(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper66)
+	prgMem := createPrgMem(8)
+	prgMem[0x7FFF] = 0xFF
+	mpr.Initialize(mappers.Board{PrgMem: prgMem, ChrMem: create1KBChrMem(32), Mirroring: mappers.MirroringVertical})
+
+	a.Equal(uint8(0), readCPU(mpr, 0x8000), "First 32KB bank should be mapped on power up")
+
+	// Single write selects both banks - write to $FFFF where ROM holds $FF
+	mpr.Write("cpu", 0xFFFF, 0b00100001, false)
+	a.Equal(uint8(4), readCPU(mpr, 0x8000), "32KB PRG bank 2 should be switched")
+	a.Equal(uint8(5), readCPU(mpr, 0xC000), "Whole 32KB should be switched")
+	a.Equal(uint8(8), readPPU(mpr, 0x0000), "8KB CHR bank 1 should be switched")
+
+	// Bank 2 holds 4 at $8000
+	mpr.Write("cpu", 0x8000, 0b00110011, false)
+	a.Equal(uint8(0), readCPU(mpr, 0x8000), "Written value should be ANDed with ROM value")
+	a.Equal(uint8(0), readPPU(mpr, 0x0000), "Written value should be ANDed with ROM value")
+}
@@ -0,0 +1,214 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Mapper69 - Sunsoft FME-7 and 5A/5B, registers are accessed through command register at $8000 and parameter
+// register at $A000
+// https://wiki.nesdev.com/w/index.php/Sunsoft_FME-7
+type Mapper69 struct {
+	prgRomBanks int
+	chrRomBanks int
+	prgMem      []uint8
+	chrMem      []uint8
+	sRam        prgRam
+
+	command  uint8
+	chrBanks [8]uint8
+
+	// ERBB BBBB - RAM enable, RAM select and bank at $6000, then banks at $8000, $A000 and $C000
+	prgBanks [4]uint8
+
+	mirroring uint8
+
+	// C... ...T - counter enable and IRQ enable
+	irqControl uint8
+	irqCounter uint16
+	irqPending bool
+
+	audio *sunsoft5BAudio
+}
+
+func (mpr *Mapper69) Initialize(board Board) {
+	mpr.prgRomBanks = board.prgRomBanks()
+	mpr.chrRomBanks = board.chrRomBanks()
+	mpr.prgMem = board.PrgMem
+	mpr.chrMem = board.getChrMem()
+	mpr.sRam = board.newPrgRam(0x2000)
+
+	mpr.command = 0
+	mpr.chrBanks = [8]uint8{}
+	mpr.prgBanks = [4]uint8{}
+	mpr.mirroring = MirroringVertical
+	mpr.irqControl = 0
+	mpr.irqCounter = 0
+	mpr.irqPending = false
+	mpr.audio = newSunsoft5BAudio()
+}
+
+func (mpr *Mapper69) Read(busId string, addr uint16, _ bool) (uint8, bool) {
+	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 {
+		bank := mpr.prgBanks[0]
+
+		// RAM can be disabled, which leaves open bus
+		if mpr.isPrgRamSelected() {
+			if bank&0b10000000 == 0 {
+				return 0, false
+			}
+
+			return mpr.sRam.read(addr)
+		}
+
+		return mpr.prgMem[mpr.getPrgAddress(int(bank&0x3F), addr)], true
+	}
+
+	if busId == "cpu" && addr >= 0x8000 {
+		// Last bank is fixed at $E000
+		bank := len(mpr.prgMem)/0x2000 - 1
+		if addr < 0xE000 {
+			bank = int(mpr.prgBanks[1+(addr-0x8000)/0x2000] & 0x3F)
+		}
+
+		return mpr.prgMem[mpr.getPrgAddress(bank, addr)], true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		return mpr.chrMem[mpr.getChrAddress(addr)], true
+	}
+
+	return 0, false
+}
+
+func (mpr *Mapper69) Write(busId string, addr uint16, data uint8, debug bool) bool {
+	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 {
+		if !mpr.isPrgRamSelected() || mpr.prgBanks[0]&0b10000000 == 0 {
+			return false
+		}
+
+		return mpr.sRam.write(addr, data)
+	}
+
+	if busId == "cpu" && addr >= 0x8000 {
+		if !debug {
+			switch addr & 0xE000 {
+			case 0x8000:
+				mpr.command = data & 0x0F
+			case 0xA000:
+				mpr.writeParameter(data)
+			case 0xC000:
+				mpr.audio.writeRegisterSelect(data)
+			case 0xE000:
+				mpr.audio.writeData(data)
+			}
+		}
+
+		return true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		if mpr.chrRomBanks == 0 {
+			mpr.chrMem[mpr.getChrAddress(addr)] = data
+			return true
+		}
+	}
+
+	return false
+}
+
+func (mpr *Mapper69) writeParameter(data uint8) {
+	switch {
+	case mpr.command < 0x08:
+		mpr.chrBanks[mpr.command] = data
+	case mpr.command < 0x0C:
+		mpr.prgBanks[mpr.command-0x08] = data
+	case mpr.command == 0x0C:
+		switch data & 0b11 {
+		case 0:
+			mpr.mirroring = MirroringVertical
+		case 1:
+			mpr.mirroring = MirroringHorizontal
+		case 2:
+			mpr.mirroring = MirroringSingleScreenLow
+		case 3:
+			mpr.mirroring = MirroringSingleScreenHigh
+		}
+	case mpr.command == 0x0D:
+		// Any write acknowledges pending IRQ
+		mpr.irqControl = data
+		mpr.irqPending = false
+	case mpr.command == 0x0E:
+		mpr.irqCounter = mpr.irqCounter&0xFF00 | uint16(data)
+	default:
+		mpr.irqCounter = mpr.irqCounter&0x00FF | uint16(data)<<8
+	}
+}
+
+// GetMirroring - mirroring is selected by command $C
+func (mpr *Mapper69) GetMirroring() uint8 {
+	return mpr.mirroring
+}
+
+func (mpr *Mapper69) Serialize(w *state.Writer) {
+	w.Bytes(mpr.sRam)
+	writeChrRam(w, mpr.chrMem, mpr.chrRomBanks)
+	w.Uint8(mpr.command)
+	w.Bytes(mpr.chrBanks[:])
+	w.Bytes(mpr.prgBanks[:])
+	w.Uint8(mpr.mirroring)
+	w.Uint8(mpr.irqControl)
+	w.Uint16(mpr.irqCounter)
+	w.Bool(mpr.irqPending)
+	mpr.audio.serialize(w)
+}
+
+func (mpr *Mapper69) Deserialize(r *state.Reader) {
+	r.Bytes(mpr.sRam)
+	readChrRam(r, mpr.chrMem, mpr.chrRomBanks)
+	mpr.command = r.Uint8()
+	r.Bytes(mpr.chrBanks[:])
+	r.Bytes(mpr.prgBanks[:])
+	mpr.mirroring = r.Uint8()
+	mpr.irqControl = r.Uint8()
+	mpr.irqCounter = r.Uint16()
+	mpr.irqPending = r.Bool()
+	mpr.audio.deserialize(r)
+}
+
+// ClockCPU - counter decrements every cycle when enabled and IRQ fires when it wraps from $0000 to $FFFF
+func (mpr *Mapper69) ClockCPU() {
+	if mpr.irqControl&0b10000000 != 0 {
+		mpr.irqCounter--
+
+		if mpr.irqCounter == 0xFFFF && mpr.irqControl&0b00000001 != 0 {
+			mpr.irqPending = true
+		}
+	}
+
+	mpr.audio.clock()
+}
+
+func (mpr *Mapper69) AudioOutput() float32 {
+	return mpr.audio.output()
+}
+
+func (mpr *Mapper69) IsIRQAsserted() bool {
+	return mpr.irqPending
+}
+
+func (mpr *Mapper69) isPrgRamSelected() bool {
+	return mpr.prgBanks[0]&0b01000000 != 0
+}
+
+func (mpr *Mapper69) getPrgAddress(bank int, addr uint16) int {
+	// Number of 8KB banks
+	bankCount := len(mpr.prgMem) / 0x2000
+
+	return (bank%bankCount)*0x2000 + int(addr&0x1FFF)
+}
+
+func (mpr *Mapper69) getChrAddress(addr uint16) int {
+	// Number of 1KB banks
+	bankCount := len(mpr.chrMem) / 0x0400
+	bank := int(mpr.chrBanks[addr/0x0400])
+
+	return (bank%bankCount)*0x0400 + int(addr&0x03FF)
+}
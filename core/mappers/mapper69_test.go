@@ -0,0 +1,78 @@
+package mappers_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"testing"
+)
+
+func writeFME7(mpr *mappers.Mapper69, command uint8, parameter uint8) {
+	mpr.Write("cpu", 0x8000, command, false)
+	mpr.Write("cpu", 0xA000, parameter, false)
+}
+
+func TestMapper69Banking(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper69)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: createChrMem(4), Mirroring: mappers.MirroringVertical})
+
+	writeFME7(mpr, 0x09, 2)
+	writeFME7(mpr, 0x0B, 9)
+	a.Equal(uint8(1), readCPU(mpr, 0x8000), "8KB bank should be mapped at $8000")
+	a.Equal(uint8(4), readCPU(mpr, 0xC000), "8KB bank should be mapped at $C000")
+	a.Equal(uint8(7), readCPU(mpr, 0xE000), "Last bank should be fixed at $E000")
+
+	writeFME7(mpr, 0x01, 12)
+	a.Equal(uint8(3), readPPU(mpr, 0x0400), "Second 1KB CHR bank should be switched")
+
+	writeFME7(mpr, 0x0C, 1)
+	a.Equal(uint8(mappers.MirroringHorizontal), mpr.GetMirroring(), "Mirroring should be horizontal")
+
+	// ROM at $6000 by default, RAM when selected and enabled
+	writeFME7(mpr, 0x08, 4)
+	a.Equal(uint8(2), readCPU(mpr, 0x6000), "ROM bank should be mapped at $6000")
+
+	writeFME7(mpr, 0x08, 0b11000000)
+	mpr.Write("cpu", 0x6000, 0x55, false)
+	a.Equal(uint8(0x55), readCPU(mpr, 0x6000), "PRG RAM should be mapped at $6000")
+}
+
+func TestMapper69IRQ(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper69)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: createChrMem(4), Mirroring: mappers.MirroringVertical})
+
+	writeFME7(mpr, 0x0E, 1)
+	writeFME7(mpr, 0x0F, 0)
+	writeFME7(mpr, 0x0D, 0b10000001)
+
+	mpr.ClockCPU()
+	a.False(mpr.IsIRQAsserted(), "IRQ should not be asserted when counter reaches zero")
+
+	mpr.ClockCPU()
+	a.True(mpr.IsIRQAsserted(), "IRQ should be asserted when counter wraps")
+
+	writeFME7(mpr, 0x0D, 0)
+	a.False(mpr.IsIRQAsserted(), "Writing IRQ control should acknowledge IRQ")
+}
+
+func TestMapper69Audio(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper69)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: createChrMem(4), Mirroring: mappers.MirroringVertical})
+
+	// Channel A tone only at fixed full volume
+	mpr.Write("cpu", 0xC000, 0x07, false)
+	mpr.Write("cpu", 0xE000, 0b00111110, false)
+	mpr.Write("cpu", 0xC000, 0x08, false)
+	mpr.Write("cpu", 0xE000, 0x0F, false)
+
+	levels := map[bool]int{}
+	for i := 0; i < 16*4; i++ {
+		mpr.ClockCPU()
+		levels[mpr.AudioOutput() > 0]++
+	}
+
+	a.NotZero(levels[true], "Tone should be high for part of the time")
+	a.NotZero(levels[false], "Tone should be low for part of the time")
+}
@@ -0,0 +1,87 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Mapper7 - AxROM
+// https://wiki.nesdev.com/w/index.php/AxROM
+type Mapper7 struct {
+	prgRomBanks int
+	chrRomBanks int
+	prgMem      []uint8
+	chrMem      []uint8
+
+	// Bits 0-2 select 32KB PRG bank, bit 4 selects single-screen nametable
+	bankSelect uint8
+}
+
+func (mpr *Mapper7) Initialize(board Board) {
+	mpr.prgRomBanks = board.prgRomBanks()
+	mpr.chrRomBanks = board.chrRomBanks()
+	mpr.prgMem = board.PrgMem
+	mpr.chrMem = board.getChrMem()
+
+	mpr.bankSelect = 0
+}
+
+func (mpr *Mapper7) Read(busId string, addr uint16, _ bool) (uint8, bool) {
+	if busId == "cpu" && addr >= 0x8000 {
+		return mpr.prgMem[mpr.getPrgAddress(addr)], true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		return mpr.chrMem[addr], true
+	}
+
+	return 0, false
+}
+
+func (mpr *Mapper7) Write(busId string, addr uint16, data uint8, debug bool) bool {
+	if busId == "cpu" && addr >= 0x8000 {
+		// Most AxROM games are on ANROM boards, which prevent bus conflicts, so written value is taken as is
+		if !debug {
+			mpr.bankSelect = data
+		}
+
+		return true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		if mpr.chrRomBanks == 0 {
+			mpr.chrMem[addr] = data
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetMirroring - one of two nametables is selected by bit 4 of bank register
+func (mpr *Mapper7) GetMirroring() uint8 {
+	if mpr.bankSelect&0b00010000 != 0 {
+		return MirroringSingleScreenHigh
+	}
+
+	return MirroringSingleScreenLow
+}
+
+func (mpr *Mapper7) Serialize(w *state.Writer) {
+	writeChrRam(w, mpr.chrMem, mpr.chrRomBanks)
+	w.Uint8(mpr.bankSelect)
+}
+
+func (mpr *Mapper7) Deserialize(r *state.Reader) {
+	readChrRam(r, mpr.chrMem, mpr.chrRomBanks)
+	mpr.bankSelect = r.Uint8()
+}
+
+func (mpr *Mapper7) getPrgAddress(addr uint16) int {
+	// Number of 32KB banks
+	bankCount := len(mpr.prgMem) / 0x8000
+	if bankCount == 0 {
+		return int(addr) & (len(mpr.prgMem) - 1)
+	}
+
+	bank := int(mpr.bankSelect&0b00000111) % bankCount
+
+	return bank*0x8000 + int(addr&0x7FFF)
+}
@@ -0,0 +1,21 @@
+package mappers_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"testing"
+)
+
+func TestMapper7Banking(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper7)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), Mirroring: mappers.MirroringVertical})
+
+	a.Equal(uint8(0), readCPU(mpr, 0x8000), "First 32KB bank should be selected on power up")
+	a.Equal(uint8(mappers.MirroringSingleScreenLow), mpr.GetMirroring(), "Lower nametable should be selected on power up")
+
+	mpr.Write("cpu", 0x8000, 0b00010011, false)
+	a.Equal(uint8(6), readCPU(mpr, 0x8000), "Bank 3 should be mapped at $8000")
+	a.Equal(uint8(7), readCPU(mpr, 0xC000), "Second half of bank 3 should be mapped at $C000")
+	a.Equal(uint8(mappers.MirroringSingleScreenHigh), mpr.GetMirroring(), "Upper nametable should be selected")
+}
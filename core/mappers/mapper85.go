@@ -0,0 +1,192 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Mapper85 - VRC7, VRC7b (submapper 1) uses A3 and VRC7a (submapper 2) uses A4 to select odd registers
+// https://wiki.nesdev.com/w/index.php/VRC7
+type Mapper85 struct {
+	prgRomBanks int
+	chrRomBanks int
+	prgMem      []uint8
+	chrMem      []uint8
+	sRam        prgRam
+
+	// Address lines selecting odd registers, both are used when submapper is unknown
+	registerSelect uint16
+
+	prgBanks [3]uint8
+	chrBanks [8]uint8
+
+	// WS.. ..MM - PRG RAM enable, audio silence, mirroring
+	control uint8
+
+	irq vrcIRQ
+}
+
+func (mpr *Mapper85) Initialize(board Board) {
+	switch board.Submapper {
+	case 1:
+		mpr.registerSelect = 0x08
+	case 2:
+		mpr.registerSelect = 0x10
+	default:
+		mpr.registerSelect = 0x18
+	}
+
+	mpr.prgRomBanks = board.prgRomBanks()
+	mpr.chrRomBanks = board.chrRomBanks()
+	mpr.prgMem = board.PrgMem
+	mpr.chrMem = board.getChrMem()
+	mpr.sRam = board.newPrgRam(0x2000)
+
+	mpr.prgBanks = [3]uint8{}
+	mpr.chrBanks = [8]uint8{}
+	mpr.control = 0
+	mpr.irq.reset()
+}
+
+func (mpr *Mapper85) Read(busId string, addr uint16, _ bool) (uint8, bool) {
+	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 {
+		if !mpr.isPrgRamEnabled() {
+			return 0, false
+		}
+
+		return mpr.sRam.read(addr)
+	}
+
+	if busId == "cpu" && addr >= 0x8000 {
+		return mpr.prgMem[mpr.getPrgAddress(addr)], true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		return mpr.chrMem[mpr.getChrAddress(addr)], true
+	}
+
+	return 0, false
+}
+
+func (mpr *Mapper85) Write(busId string, addr uint16, data uint8, debug bool) bool {
+	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 {
+		if !mpr.isPrgRamEnabled() {
+			return false
+		}
+
+		return mpr.sRam.write(addr, data)
+	}
+
+	if busId == "cpu" && addr >= 0x8000 {
+		if !debug {
+			mpr.writeRegister(addr&0xF000, addr&mpr.registerSelect != 0, data)
+		}
+
+		return true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		if mpr.chrRomBanks == 0 {
+			mpr.chrMem[mpr.getChrAddress(addr)] = data
+			return true
+		}
+	}
+
+	return false
+}
+
+func (mpr *Mapper85) writeRegister(register uint16, isOdd bool, data uint8) {
+	switch register {
+	case 0x8000:
+		if isOdd {
+			mpr.prgBanks[1] = data & 0x3F
+		} else {
+			mpr.prgBanks[0] = data & 0x3F
+		}
+	case 0x9000:
+		// Odd registers at $9000 belong to YM2413 derived audio
+		if !isOdd {
+			mpr.prgBanks[2] = data & 0x3F
+		}
+	case 0xA000, 0xB000, 0xC000, 0xD000:
+		index := (register - 0xA000) >> 11
+		if isOdd {
+			index++
+		}
+
+		mpr.chrBanks[index] = data
+	case 0xE000:
+		if isOdd {
+			mpr.irq.writeLatch(data)
+		} else {
+			mpr.control = data
+		}
+	case 0xF000:
+		if isOdd {
+			mpr.irq.acknowledge()
+		} else {
+			mpr.irq.writeControl(data)
+		}
+	}
+}
+
+// GetMirroring - mirroring is selected by $E000 register
+func (mpr *Mapper85) GetMirroring() uint8 {
+	switch mpr.control & 0b11 {
+	case 0:
+		return MirroringVertical
+	case 1:
+		return MirroringHorizontal
+	case 2:
+		return MirroringSingleScreenLow
+	default:
+		return MirroringSingleScreenHigh
+	}
+}
+
+func (mpr *Mapper85) Serialize(w *state.Writer) {
+	w.Bytes(mpr.sRam)
+	writeChrRam(w, mpr.chrMem, mpr.chrRomBanks)
+	w.Bytes(mpr.prgBanks[:])
+	w.Bytes(mpr.chrBanks[:])
+	w.Uint8(mpr.control)
+	mpr.irq.serialize(w)
+}
+
+func (mpr *Mapper85) Deserialize(r *state.Reader) {
+	r.Bytes(mpr.sRam)
+	readChrRam(r, mpr.chrMem, mpr.chrRomBanks)
+	r.Bytes(mpr.prgBanks[:])
+	r.Bytes(mpr.chrBanks[:])
+	mpr.control = r.Uint8()
+	mpr.irq.deserialize(r)
+}
+
+func (mpr *Mapper85) ClockCPU() {
+	mpr.irq.clock()
+}
+
+func (mpr *Mapper85) IsIRQAsserted() bool {
+	return mpr.irq.isPending
+}
+
+func (mpr *Mapper85) isPrgRamEnabled() bool {
+	return mpr.control&0b10000000 != 0
+}
+
+func (mpr *Mapper85) getPrgAddress(addr uint16) int {
+	// Number of 8KB banks
+	bankCount := len(mpr.prgMem) / 0x2000
+
+	bank := bankCount - 1
+	if addr < 0xE000 {
+		bank = int(mpr.prgBanks[(addr-0x8000)/0x2000])
+	}
+
+	return (bank%bankCount)*0x2000 + int(addr&0x1FFF)
+}
+
+func (mpr *Mapper85) getChrAddress(addr uint16) int {
+	// Number of 1KB banks
+	bankCount := len(mpr.chrMem) / 0x0400
+	bank := int(mpr.chrBanks[addr/0x0400])
+
+	return (bank%bankCount)*0x0400 + int(addr&0x03FF)
+}
@@ -0,0 +1,73 @@
+package mappers_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"testing"
+)
+
+func TestMapper85Banking(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper85)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: create1KBChrMem(32), Submapper: 1})
+
+	// PRG values are numbers of 8KB banks, test memory holds numbers of 16KB banks
+	mpr.Write("cpu", 0x8000, 2, false)
+	mpr.Write("cpu", 0x8008, 5, false)
+	mpr.Write("cpu", 0x9000, 9, false)
+	a.Equal(uint8(1), readCPU(mpr, 0x8000), "First 8KB bank should be mapped at $8000")
+	a.Equal(uint8(2), readCPU(mpr, 0xA000), "Second 8KB bank should be mapped at $A000")
+	a.Equal(uint8(4), readCPU(mpr, 0xC000), "Third 8KB bank should be mapped at $C000")
+	a.Equal(uint8(7), readCPU(mpr, 0xE000), "Last bank should be fixed at $E000")
+
+	for i := uint16(0); i < 8; i++ {
+		mpr.Write("cpu", 0xA000+(i>>1)*0x1000+(i&0x01)*0x08, uint8(31-i), false)
+	}
+
+	a.Equal([]uint8{31, 30, 29, 28, 27, 26, 25, 24}, readChrPages(mpr), "CHR registers should map 1KB banks")
+}
+
+func TestMapper85Control(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper85)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: create1KBChrMem(32), Mirroring: mappers.MirroringHorizontal})
+
+	a.False(mpr.Write("cpu", 0x6000, 0x55, false), "PRG RAM should be disabled on power up")
+
+	mpr.Write("cpu", 0xE000, 0b10000000, false)
+	a.True(mpr.Write("cpu", 0x6000, 0x55, false), "PRG RAM should be enabled by bit 7 of $E000")
+	a.Equal(uint8(0x55), readCPU(mpr, 0x6000))
+
+	mirroring := map[uint8]uint8{
+		0: mappers.MirroringVertical,
+		1: mappers.MirroringHorizontal,
+		2: mappers.MirroringSingleScreenLow,
+		3: mappers.MirroringSingleScreenHigh,
+	}
+
+	for value, expected := range mirroring {
+		mpr.Write("cpu", 0xE000, 0b10000000|value, false)
+		a.Equal(expected, mpr.GetMirroring(), "Mirroring should be selected by bits 0-1 of $E000")
+	}
+}
+
+func TestMapper85Wiring(t *testing.T) {
+	a := assert.New(t)
+
+	// VRC7b selects odd registers with A3, VRC7a with A4, unknown submapper with both
+	for submapper, oddRegisters := range map[uint8][]uint16{1: {0x8008}, 2: {0x8010}, 0: {0x8008, 0x8010}} {
+		for _, addr := range oddRegisters {
+			mpr := new(mappers.Mapper85)
+			mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: create1KBChrMem(32), Submapper: submapper})
+
+			mpr.Write("cpu", addr, 4, false)
+			a.Equal(uint8(2), readCPU(mpr, 0xA000), "$%04X should select $8010 on submapper %d", addr, submapper)
+			a.Equal(uint8(0), readCPU(mpr, 0x8000), "$%04X should not select $8000 on submapper %d", addr, submapper)
+		}
+	}
+
+	mpr := new(mappers.Mapper85)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: create1KBChrMem(32), Submapper: 1})
+	mpr.Write("cpu", 0x8010, 4, false)
+	a.Equal(uint8(2), readCPU(mpr, 0x8000), "A4 should not select odd registers on VRC7b")
+}
@@ -0,0 +1,215 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Mapper9 - MMC2, used by Punch-Out!!
+// https://wiki.nesdev.com/w/index.php/MMC2
+type Mapper9 struct {
+	latchMapper
+}
+
+func (mpr *Mapper9) Initialize(board Board) {
+	mpr.initialize(board, false)
+}
+
+// Mapper10 - MMC4, used by Fire Emblem titles
+// https://wiki.nesdev.com/w/index.php/MMC4
+type Mapper10 struct {
+	latchMapper
+}
+
+func (mpr *Mapper10) Initialize(board Board) {
+	mpr.initialize(board, true)
+}
+
+// MMC2 and MMC4 share CHR latches which switch banks automatically when PPU reads tile $FD or $FE.
+// They differ in PRG banking and in the address range triggering the first latch.
+type latchMapper struct {
+	prgRomBanks int
+	chrRomBanks int
+	prgMem      []uint8
+	chrMem      []uint8
+	sRam        prgRam
+	mirroring   uint8
+	isMMC4      bool
+
+	prgBank uint8
+
+	// 4KB CHR banks for $0000 and $1000 selected when latch holds $FD (index 0) or $FE (index 1)
+	chrBanks [2][2]uint8
+	latches  [2]uint8
+}
+
+func (mpr *latchMapper) initialize(board Board, isMMC4 bool) {
+	mpr.mirroring = board.Mirroring
+	mpr.isMMC4 = isMMC4
+	mpr.prgRomBanks = board.prgRomBanks()
+	mpr.chrRomBanks = board.chrRomBanks()
+	mpr.prgMem = board.PrgMem
+	mpr.chrMem = board.getChrMem()
+	mpr.sRam = board.newPrgRam(0x2000)
+
+	mpr.prgBank = 0
+	mpr.chrBanks = [2][2]uint8{}
+	mpr.latches = [2]uint8{0xFE, 0xFE}
+}
+
+func (mpr *latchMapper) Read(busId string, addr uint16, debug bool) (uint8, bool) {
+	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 && mpr.isMMC4 {
+		return mpr.sRam.read(addr)
+	}
+
+	if busId == "cpu" && addr >= 0x8000 {
+		return mpr.prgMem[mpr.getPrgAddress(addr)], true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		data := mpr.chrMem[mpr.getChrAddress(addr)]
+
+		// Latch is updated after the read, so tile $FD/$FE itself is still fetched from previous bank
+		if !debug {
+			mpr.updateLatch(addr)
+		}
+
+		return data, true
+	}
+
+	return 0, false
+}
+
+func (mpr *latchMapper) Write(busId string, addr uint16, data uint8, debug bool) bool {
+	if busId == "cpu" && addr >= 0x6000 && addr < 0x8000 && mpr.isMMC4 {
+		return mpr.sRam.write(addr, data)
+	}
+
+	if busId == "cpu" && addr >= 0x8000 {
+		if !debug {
+			mpr.writeRegister(addr, data)
+		}
+
+		return true
+	}
+
+	if busId == "ppu" && addr <= 0x1FFF {
+		if mpr.chrRomBanks == 0 {
+			mpr.chrMem[mpr.getChrAddress(addr)] = data
+			return true
+		}
+	}
+
+	return false
+}
+
+func (mpr *latchMapper) writeRegister(addr uint16, data uint8) {
+	switch addr & 0xF000 {
+	case 0xA000:
+		mpr.prgBank = data & 0x0F
+	case 0xB000:
+		mpr.chrBanks[0][0] = data & 0x1F
+	case 0xC000:
+		mpr.chrBanks[0][1] = data & 0x1F
+	case 0xD000:
+		mpr.chrBanks[1][0] = data & 0x1F
+	case 0xE000:
+		mpr.chrBanks[1][1] = data & 0x1F
+	case 0xF000:
+		if data&0x01 == 0 {
+			mpr.mirroring = MirroringVertical
+		} else {
+			mpr.mirroring = MirroringHorizontal
+		}
+	}
+}
+
+// GetMirroring - mirroring is selected by $F000 register
+func (mpr *latchMapper) GetMirroring() uint8 {
+	return mpr.mirroring
+}
+
+func (mpr *latchMapper) Serialize(w *state.Writer) {
+	w.Bytes(mpr.sRam)
+	writeChrRam(w, mpr.chrMem, mpr.chrRomBanks)
+	w.Uint8(mpr.mirroring)
+	w.Uint8(mpr.prgBank)
+
+	for i := range mpr.chrBanks {
+		w.Uint8(mpr.chrBanks[i][0])
+		w.Uint8(mpr.chrBanks[i][1])
+		w.Uint8(mpr.latches[i])
+	}
+}
+
+func (mpr *latchMapper) Deserialize(r *state.Reader) {
+	r.Bytes(mpr.sRam)
+	readChrRam(r, mpr.chrMem, mpr.chrRomBanks)
+	mpr.mirroring = r.Uint8()
+	mpr.prgBank = r.Uint8()
+
+	for i := range mpr.chrBanks {
+		mpr.chrBanks[i][0] = r.Uint8()
+		mpr.chrBanks[i][1] = r.Uint8()
+		mpr.latches[i] = r.Uint8()
+	}
+}
+
+// Reads of tiles $FD and $FE switch the latch of the pattern table being read. MMC2 triggers the
+// first latch only on a single address, other latches are triggered by any byte of tile's high plane.
+func (mpr *latchMapper) updateLatch(addr uint16) {
+	table := addr >> 12
+	tile := addr & 0x0FF8
+
+	if table == 0 && !mpr.isMMC4 {
+		switch addr {
+		case 0x0FD8:
+			mpr.latches[0] = 0xFD
+		case 0x0FE8:
+			mpr.latches[0] = 0xFE
+		}
+
+		return
+	}
+
+	switch tile {
+	case 0x0FD8:
+		mpr.latches[table] = 0xFD
+	case 0x0FE8:
+		mpr.latches[table] = 0xFE
+	}
+}
+
+func (mpr *latchMapper) getPrgAddress(addr uint16) int {
+	if mpr.isMMC4 {
+		// Switchable 16KB bank at $8000, last bank fixed at $C000
+		bankCount := len(mpr.prgMem) / 0x4000
+		bank := int(mpr.prgBank) % bankCount
+
+		if addr >= 0xC000 {
+			bank = bankCount - 1
+		}
+
+		return bank*0x4000 + int(addr&0x3FFF)
+	}
+
+	// Switchable 8KB bank at $8000, last three banks fixed
+	bankCount := len(mpr.prgMem) / 0x2000
+	bank := int(mpr.prgBank) % bankCount
+
+	if addr >= 0xA000 {
+		bank = bankCount - 4 + int(addr-0x8000)/0x2000
+	}
+
+	return bank*0x2000 + int(addr&0x1FFF)
+}
+
+func (mpr *latchMapper) getChrAddress(addr uint16) int {
+	// Number of 4KB banks
+	bankCount := len(mpr.chrMem) / 0x1000
+	table := addr >> 12
+
+	bank := mpr.chrBanks[table][0]
+	if mpr.latches[table] == 0xFE {
+		bank = mpr.chrBanks[table][1]
+	}
+
+	return (int(bank)%bankCount)*0x1000 + int(addr&0x0FFF)
+}
@@ -0,0 +1,68 @@
+package mappers_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"testing"
+)
+
+// Creates CHR memory where every byte holds number of its 4KB bank
+func createChrMem(banks uint8) []uint8 {
+	mem := make([]uint8, int(banks)*0x2000)
+
+	for i := range mem {
+		mem[i] = uint8(i / 0x1000)
+	}
+
+	return mem
+}
+
+func readPPU(mpr mappers.Mapper, addr uint16) uint8 {
+	data, _ := mpr.Read("ppu", addr, false)
+	return data
+}
+
+func TestMapper9ChrLatches(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper9)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: createChrMem(4), Mirroring: mappers.MirroringVertical})
+
+	mpr.Write("cpu", 0xB000, 1, false)
+	mpr.Write("cpu", 0xC000, 2, false)
+	mpr.Write("cpu", 0xD000, 3, false)
+	mpr.Write("cpu", 0xE000, 4, false)
+
+	a.Equal(uint8(2), readPPU(mpr, 0x0000), "$FE bank should be selected for $0000")
+	a.Equal(uint8(4), readPPU(mpr, 0x1000), "$FE bank should be selected for $1000")
+
+	// Tile $FD is still read from previous bank, latch switches afterwards
+	a.Equal(uint8(2), readPPU(mpr, 0x0FD8), "Latch should switch after the read")
+	a.Equal(uint8(1), readPPU(mpr, 0x0000), "$FD bank should be selected for $0000")
+
+	// MMC2 triggers first latch only on $0FE8
+	readPPU(mpr, 0x0FE9)
+	a.Equal(uint8(1), readPPU(mpr, 0x0000), "$0FE9 should not switch first latch on MMC2")
+
+	readPPU(mpr, 0x1FDB)
+	a.Equal(uint8(3), readPPU(mpr, 0x1000), "$FD bank should be selected for $1000")
+
+	// Debug reads do not affect latches
+	mpr.Read("ppu", 0x1FE8, true)
+	a.Equal(uint8(3), readPPU(mpr, 0x1000), "Debug read should not switch latch")
+}
+
+func TestMapper10ChrLatches(t *testing.T) {
+	a := assert.New(t)
+	mpr := new(mappers.Mapper10)
+	mpr.Initialize(mappers.Board{PrgMem: createPrgMem(8), ChrMem: createChrMem(4), Mirroring: mappers.MirroringVertical})
+
+	mpr.Write("cpu", 0xB000, 1, false)
+	mpr.Write("cpu", 0xC000, 2, false)
+
+	readPPU(mpr, 0x0FDC)
+	a.Equal(uint8(1), readPPU(mpr, 0x0000), "Whole tile $FD should switch first latch on MMC4")
+
+	mpr.Write("cpu", 0xA000, 3, false)
+	a.Equal(uint8(3), readCPU(mpr, 0x8000), "16KB bank should be switched at $8000")
+	a.Equal(uint8(7), readCPU(mpr, 0xC000), "Last bank should be fixed at $C000")
+}
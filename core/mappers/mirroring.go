@@ -0,0 +1,11 @@
+package mappers
+
+// Nametable mirroring modes
+// https://wiki.nesdev.com/w/index.php/Mirroring
+const (
+	MirroringVertical = iota
+	MirroringHorizontal
+	MirroringSingleScreenLow
+	MirroringSingleScreenHigh
+	MirroringFourScreen
+)
@@ -0,0 +1,114 @@
+package mappers
+
+import (
+	"github.com/szymonkups/nesgo/core/apu"
+	"github.com/szymonkups/nesgo/core/state"
+)
+
+// MMC5 has its own frame sequencer clocking envelopes and length counters at fixed 240Hz
+const mmc5FramePeriod = 7457
+
+// Expansion audio of MMC5 - two pulse channels like in APU (without sweep) and raw PCM channel.
+// PCM read mode and its IRQ are not supported, no game uses them.
+// https://wiki.nesdev.com/w/index.php/MMC5_audio
+type mmc5Audio struct {
+	pulse1 *apu.Pulse
+	pulse2 *apu.Pulse
+	pcm    uint8
+
+	// Channels go through the same kind of non-linear mixer as in APU
+	mixer *apu.Mixer
+
+	frameCounter uint16
+	cycles       uint64
+}
+
+func newMMC5Audio() *mmc5Audio {
+	return &mmc5Audio{
+		pulse1: apu.NewPulseWithoutSweep(),
+		pulse2: apu.NewPulseWithoutSweep(),
+		mixer:  apu.NewMixer(),
+	}
+}
+
+// Writes to $5000-$5015
+func (a *mmc5Audio) write(addr uint16, data uint8) {
+	switch addr {
+	case 0x5000:
+		a.pulse1.WriteControl(data)
+	case 0x5002:
+		a.pulse1.WriteTimerLow(data)
+	case 0x5003:
+		a.pulse1.WriteTimerHigh(data)
+	case 0x5004:
+		a.pulse2.WriteControl(data)
+	case 0x5006:
+		a.pulse2.WriteTimerLow(data)
+	case 0x5007:
+		a.pulse2.WriteTimerHigh(data)
+	case 0x5011:
+		// Writing 0 has no effect in write mode
+		if data != 0 {
+			a.pcm = data
+		}
+	case 0x5015:
+		a.pulse1.SetEnabled(data&0b01 != 0)
+		a.pulse2.SetEnabled(data&0b10 != 0)
+	}
+}
+
+// Reads $5015 - length counter status
+func (a *mmc5Audio) readStatus() uint8 {
+	status := uint8(0)
+
+	if a.pulse1.IsActive() {
+		status |= 0b01
+	}
+
+	if a.pulse2.IsActive() {
+		status |= 0b10
+	}
+
+	return status
+}
+
+// Should be called every CPU cycle
+func (a *mmc5Audio) clock() {
+	if a.cycles%2 == 1 {
+		a.pulse1.ClockTimer()
+		a.pulse2.ClockTimer()
+	}
+
+	a.frameCounter++
+	if a.frameCounter >= mmc5FramePeriod {
+		a.frameCounter = 0
+
+		a.pulse1.ClockQuarterFrame()
+		a.pulse2.ClockQuarterFrame()
+		a.pulse1.ClockHalfFrame()
+		a.pulse2.ClockHalfFrame()
+	}
+
+	a.cycles++
+}
+
+// PCM is about as loud as APU DMC channel at the same level
+func (a *mmc5Audio) output() float32 {
+	return a.mixer.Mix(a.pulse1.Output(), a.pulse2.Output(), 0, 0, a.pcm>>1)
+}
+
+func (a *mmc5Audio) serialize(w *state.Writer) {
+	a.pulse1.Serialize(w)
+	a.pulse2.Serialize(w)
+	w.Uint8(a.pcm)
+	w.Uint16(a.frameCounter)
+	w.Uint64(a.cycles)
+}
+
+func (a *mmc5Audio) deserialize(r *state.Reader) {
+	a.pulse1.Deserialize(r)
+	a.pulse2.Deserialize(r)
+	a.pcm = r.Uint8()
+	a.frameCounter = r.Uint16()
+	a.cycles = r.Uint64()
+}
@@ -0,0 +1,148 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Level of single step of channel output (sample centered at 8 multiplied by volume), channel at full volume
+// swings about twice as much as APU pulse
+const n163AudioLevel = 0.1488 / 60
+
+// Expansion audio of Namco 163 - up to 8 wavetable channels stored with their registers in 128 bytes
+// of internal RAM. Only one channel is updated at a time, every 15 CPU cycles.
+// https://wiki.nesdev.com/w/index.php/Namco_163_audio
+type n163Audio struct {
+	ram [0x80]uint8
+
+	// Address port at $F800 - IAAA AAAA - auto increment and address
+	address       uint8
+	autoIncrement bool
+
+	isDisabled bool
+
+	cycles         uint8
+	currentChannel uint8
+	outputs        [8]int16
+}
+
+func (a *n163Audio) writeAddress(data uint8) {
+	a.address = data & 0x7F
+	a.autoIncrement = data&0b10000000 != 0
+}
+
+// Data port at $4800
+func (a *n163Audio) readData(debug bool) uint8 {
+	data := a.ram[a.address]
+
+	if !debug {
+		a.incrementAddress()
+	}
+
+	return data
+}
+
+func (a *n163Audio) writeData(data uint8) {
+	a.ram[a.address] = data
+	a.incrementAddress()
+}
+
+func (a *n163Audio) incrementAddress() {
+	if a.autoIncrement {
+		a.address = (a.address + 1) & 0x7F
+	}
+}
+
+// Number of enabled channels is stored in bits 4-6 of $7F, channels are counted from the last one
+func (a *n163Audio) getChannelCount() uint8 {
+	return (a.ram[0x7F]>>4)&0b111 + 1
+}
+
+// Should be called every CPU cycle
+func (a *n163Audio) clock() {
+	if a.isDisabled {
+		return
+	}
+
+	a.cycles++
+	if a.cycles < 15 {
+		return
+	}
+
+	a.cycles = 0
+
+	count := a.getChannelCount()
+	if a.currentChannel >= count {
+		a.currentChannel = 0
+	}
+
+	a.updateChannel(7 - a.currentChannel)
+	a.currentChannel++
+}
+
+func (a *n163Audio) updateChannel(channel uint8) {
+	// Channel registers occupy 8 bytes, channel 7 at $78-$7F
+	base := 0x40 + channel*8
+	regs := a.ram[base : base+8]
+
+	frequency := uint32(regs[0]) | uint32(regs[2])<<8 | uint32(regs[4]&0b11)<<16
+	phase := uint32(regs[1]) | uint32(regs[3])<<8 | uint32(regs[5])<<16
+	length := (256 - uint32(regs[4]&0xFC)) << 16
+
+	phase = (phase + frequency) % length
+
+	regs[1] = uint8(phase)
+	regs[3] = uint8(phase >> 8)
+	regs[5] = uint8(phase >> 16)
+
+	// Samples are 4 bit, low nibble first
+	sampleAddress := (uint32(regs[6]) + phase>>16) & 0xFF
+	sample := a.ram[sampleAddress>>1]
+	if sampleAddress&0x01 == 0 {
+		sample &= 0x0F
+	} else {
+		sample >>= 4
+	}
+
+	volume := int16(regs[7] & 0x0F)
+	a.outputs[channel] = (int16(sample) - 8) * volume
+}
+
+// Channels are time multiplexed, so output is their average
+func (a *n163Audio) output() float32 {
+	if a.isDisabled {
+		return 0
+	}
+
+	count := a.getChannelCount()
+	sum := int16(0)
+
+	for i := uint8(0); i < count; i++ {
+		sum += a.outputs[7-i]
+	}
+
+	return float32(sum) / float32(count) * n163AudioLevel
+}
+
+func (a *n163Audio) serialize(w *state.Writer) {
+	w.Bytes(a.ram[:])
+	w.Uint8(a.address)
+	w.Bool(a.autoIncrement)
+	w.Bool(a.isDisabled)
+	w.Uint8(a.cycles)
+	w.Uint8(a.currentChannel)
+
+	for _, output := range a.outputs {
+		w.Int16(output)
+	}
+}
+
+func (a *n163Audio) deserialize(r *state.Reader) {
+	r.Bytes(a.ram[:])
+	a.address = r.Uint8()
+	a.autoIncrement = r.Bool()
+	a.isDisabled = r.Bool()
+	a.cycles = r.Uint8()
+	a.currentChannel = r.Uint8()
+
+	for i := range a.outputs {
+		a.outputs[i] = r.Int16()
+	}
+}
@@ -0,0 +1,110 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// NSFIdleAddress - address of idle loop executed by CPU between init and play routine calls
+const NSFIdleAddress = 0x4100
+
+// Idle loop - JMP $4100
+var nsfDriver = []uint8{0x4C, NSFIdleAddress & 0xFF, NSFIdleAddress >> 8}
+
+// MapperNSF - synthetic cartridge for NSF music files with 4KB banks switched trough $5FF8-$5FFF.
+// https://wiki.nesdev.com/w/index.php/NSF
+type MapperNSF struct {
+	data  []uint8
+	banks [8]uint8
+	sRam  [0x2000]uint8
+}
+
+// NewMapperNSF - creates mapper for NSF data loaded at given address. When none of initial bank values
+// is set, NSF is not bankswitched and data is just placed at load address.
+func NewMapperNSF(data []uint8, loadAddress uint16, banks [8]uint8) *MapperNSF {
+	mpr := new(MapperNSF)
+	isBankswitched := false
+
+	for _, b := range banks {
+		if b != 0 {
+			isBankswitched = true
+		}
+	}
+
+	// Data is padded so it starts at load address offset inside first bank
+	padding := int(loadAddress & 0x0FFF)
+
+	if !isBankswitched {
+		padding = int(loadAddress - 0x8000)
+
+		for i := range banks {
+			banks[i] = uint8(i)
+		}
+	}
+
+	mpr.data = make([]uint8, padding+len(data))
+	copy(mpr.data[padding:], data)
+	mpr.banks = banks
+
+	return mpr
+}
+
+// Initialize - NSF data is provided by NewMapperNSF
+func (mpr *MapperNSF) Initialize(_ Board) {
+}
+
+// GetMirroring - nametables are not used by NSF tunes
+func (mpr *MapperNSF) GetMirroring() uint8 {
+	return MirroringHorizontal
+}
+
+func (mpr *MapperNSF) Serialize(w *state.Writer) {
+	w.Bytes(mpr.banks[:])
+	w.Bytes(mpr.sRam[:])
+}
+
+func (mpr *MapperNSF) Deserialize(r *state.Reader) {
+	r.Bytes(mpr.banks[:])
+	r.Bytes(mpr.sRam[:])
+}
+
+func (mpr *MapperNSF) Read(busId string, addr uint16, _ bool) (uint8, bool) {
+	if busId != "cpu" {
+		return 0, false
+	}
+
+	if addr >= NSFIdleAddress && addr < NSFIdleAddress+uint16(len(nsfDriver)) {
+		return nsfDriver[addr-NSFIdleAddress], true
+	}
+
+	if addr >= 0x6000 && addr < 0x8000 {
+		return mpr.sRam[addr-0x6000], true
+	}
+
+	if addr >= 0x8000 {
+		offset := int(mpr.banks[(addr-0x8000)>>12])*0x1000 + int(addr&0x0FFF)
+
+		if offset < len(mpr.data) {
+			return mpr.data[offset], true
+		}
+
+		return 0, true
+	}
+
+	return 0, false
+}
+
+func (mpr *MapperNSF) Write(busId string, addr uint16, data uint8, _ bool) bool {
+	if busId != "cpu" {
+		return false
+	}
+
+	if addr >= 0x5FF8 && addr <= 0x5FFF {
+		mpr.banks[addr-0x5FF8] = data
+		return true
+	}
+
+	if addr >= 0x6000 && addr < 0x8000 {
+		mpr.sRam[addr-0x6000] = data
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,77 @@
+package mappers_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"testing"
+)
+
+// NSF data where each byte holds number of its 4KB bank
+func createNSFData(banks int) []uint8 {
+	data := make([]uint8, banks*0x1000)
+
+	for i := range data {
+		data[i] = uint8(i / 0x1000)
+	}
+
+	return data
+}
+
+func TestMapperNSFBanking(t *testing.T) {
+	a := assert.New(t)
+
+	// Load address offset inside first bank pads data
+	data := createNSFData(16)
+	data[0] = 0xFF
+	mpr := mappers.NewMapperNSF(data, 0x8100, [8]uint8{0, 1, 2, 3, 4, 5, 6, 7})
+	a.Equal(uint8(0), readCPU(mpr, 0x80FF), "Padding should be read before load address")
+	a.Equal(uint8(0xFF), readCPU(mpr, 0x8100), "Data should start at load address")
+	a.Equal(uint8(0), readCPU(mpr, 0x90FF))
+	a.Equal(uint8(1), readCPU(mpr, 0x9100))
+
+	mpr.Write("cpu", 0x5FF8, 5, false)
+	mpr.Write("cpu", 0x5FFF, 10, false)
+	a.Equal(uint8(4), readCPU(mpr, 0x8000), "Bank 5 should be switched at $8000")
+	a.Equal(uint8(5), readCPU(mpr, 0x8100))
+	a.Equal(uint8(9), readCPU(mpr, 0xF000), "Bank 10 should be switched at $F000")
+	a.Equal(uint8(10), readCPU(mpr, 0xFFFF))
+	a.Equal(uint8(2), readCPU(mpr, 0xA100), "Other banks should not be switched")
+
+	mpr.Write("cpu", 0x5FF9, 100, false)
+	a.Equal(uint8(0), readCPU(mpr, 0x9100), "Banks outside of data should read 0")
+}
+
+func TestMapperNSFNotBankswitched(t *testing.T) {
+	a := assert.New(t)
+
+	// Data is placed at load address as is
+	data := createNSFData(2)
+	data[0] = 0xFF
+	mpr := mappers.NewMapperNSF(data, 0xA000, [8]uint8{})
+	a.Equal(uint8(0), readCPU(mpr, 0x8000))
+	a.Equal(uint8(0), readCPU(mpr, 0x9FFF))
+	a.Equal(uint8(0xFF), readCPU(mpr, 0xA000), "Data should be placed at load address")
+	a.Equal(uint8(0), readCPU(mpr, 0xA001))
+	a.Equal(uint8(1), readCPU(mpr, 0xB000))
+	a.Equal(uint8(0), readCPU(mpr, 0xC000), "Memory after data should read 0")
+}
+
+func TestMapperNSFMemory(t *testing.T) {
+	a := assert.New(t)
+	mpr := mappers.NewMapperNSF(createNSFData(1), 0x8000, [8]uint8{})
+
+	a.Equal([]uint8{0x4C, 0x00, 0x41}, []uint8{
+		readCPU(mpr, mappers.NSFIdleAddress),
+		readCPU(mpr, mappers.NSFIdleAddress+1),
+		readCPU(mpr, mappers.NSFIdleAddress+2),
+	}, "Idle loop should jump to itself")
+
+	a.True(mpr.Write("cpu", 0x6000, 0x12, false))
+	a.True(mpr.Write("cpu", 0x7FFF, 0x34, false))
+	a.Equal(uint8(0x12), readCPU(mpr, 0x6000), "Work RAM should be mapped at $6000")
+	a.Equal(uint8(0x34), readCPU(mpr, 0x7FFF))
+
+	a.False(mpr.Write("cpu", 0x8000, 0x56, false), "ROM should not be writable")
+	_, handled := mpr.Read("ppu", 0x0000, false)
+	a.False(handled, "PPU bus should not be handled")
+}
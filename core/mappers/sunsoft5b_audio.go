@@ -0,0 +1,240 @@
+package mappers
+
+import (
+	"github.com/szymonkups/nesgo/core/state"
+	"math"
+)
+
+// Level of tone channel at full volume, 5B is noticeably louder than APU pulse
+const sunsoft5BAudioLevel = 0.2
+
+// Logarithmic volume table, each step is 3dB
+var sunsoft5BVolumeTable = func() [16]float32 {
+	table := [16]float32{}
+
+	for i := 1; i < len(table); i++ {
+		table[i] = float32(math.Pow(10, -3*float64(15-i)/20))
+	}
+
+	return table
+}()
+
+// Expansion audio of Sunsoft 5B, a variant of AY-3-8910 with three square channels, noise and envelope.
+// Registers are accessed by writing register number to $C000 and value to $E000.
+// https://wiki.nesdev.com/w/index.php/Sunsoft_5B_audio
+type sunsoft5BAudio struct {
+	register  uint8
+	registers [16]uint8
+
+	// Chip runs at half CPU clock and its counters are clocked every 8 of its cycles
+	prescaler uint8
+
+	toneCounters [3]uint16
+	toneOutputs  [3]bool
+
+	noiseCounter uint8
+	noiseShift   uint32
+
+	envelopeCounter   uint32
+	envelopeStep      uint8
+	isEnvelopeAttack  bool
+	isEnvelopeHolding bool
+	envelopeHoldLevel uint8
+}
+
+func newSunsoft5BAudio() *sunsoft5BAudio {
+	return &sunsoft5BAudio{noiseShift: 1}
+}
+
+func (a *sunsoft5BAudio) writeRegisterSelect(data uint8) {
+	a.register = data & 0x0F
+}
+
+func (a *sunsoft5BAudio) writeData(data uint8) {
+	a.registers[a.register] = data
+
+	// Writing envelope shape restarts the envelope
+	if a.register == 0x0D {
+		a.envelopeStep = 0
+		a.envelopeCounter = 0
+		a.isEnvelopeAttack = data&0b0100 != 0
+		a.isEnvelopeHolding = false
+	}
+}
+
+// Should be called every CPU cycle
+func (a *sunsoft5BAudio) clock() {
+	a.prescaler++
+	if a.prescaler < 16 {
+		return
+	}
+
+	a.prescaler = 0
+
+	for i := range a.toneCounters {
+		a.toneCounters[i]++
+
+		if a.toneCounters[i] >= a.getTonePeriod(i) {
+			a.toneCounters[i] = 0
+			a.toneOutputs[i] = !a.toneOutputs[i]
+		}
+	}
+
+	// 17 bit LFSR is clocked at half of tone rate
+	a.noiseCounter++
+	if a.noiseCounter >= a.getNoisePeriod()*2 {
+		a.noiseCounter = 0
+		feedback := (a.noiseShift ^ a.noiseShift>>3) & 0x01
+		a.noiseShift = a.noiseShift>>1 | feedback<<16
+	}
+
+	// 5B envelope has 32 steps, two of them make one step of 16 step envelope used here
+	a.envelopeCounter++
+	if a.envelopeCounter >= uint32(a.getEnvelopePeriod())*2 {
+		a.envelopeCounter = 0
+		a.clockEnvelope()
+	}
+}
+
+func (a *sunsoft5BAudio) getTonePeriod(channel int) uint16 {
+	period := uint16(a.registers[channel*2]) | uint16(a.registers[channel*2+1]&0x0F)<<8
+
+	if period == 0 {
+		return 1
+	}
+
+	return period
+}
+
+func (a *sunsoft5BAudio) getNoisePeriod() uint8 {
+	period := a.registers[0x06] & 0x1F
+
+	if period == 0 {
+		return 1
+	}
+
+	return period
+}
+
+func (a *sunsoft5BAudio) getEnvelopePeriod() uint16 {
+	period := uint16(a.registers[0x0B]) | uint16(a.registers[0x0C])<<8
+
+	if period == 0 {
+		return 1
+	}
+
+	return period
+}
+
+// Envelope goes trough 16 steps, shape register selects what happens after (CAtH - continue, attack,
+// alternate, hold bits)
+func (a *sunsoft5BAudio) clockEnvelope() {
+	if a.isEnvelopeHolding {
+		return
+	}
+
+	a.envelopeStep++
+	if a.envelopeStep < 16 {
+		return
+	}
+
+	shape := a.registers[0x0D]
+	isContinued := shape&0b1000 != 0
+	isAlternating := shape&0b0010 != 0
+	isHolding := shape&0b0001 != 0
+
+	switch {
+	case !isContinued:
+		a.isEnvelopeHolding = true
+		a.envelopeHoldLevel = 0
+	case isHolding:
+		// Hold at the level envelope ended with, alternate flips it
+		a.isEnvelopeHolding = true
+		a.envelopeHoldLevel = 0
+		if a.isEnvelopeAttack != isAlternating {
+			a.envelopeHoldLevel = 15
+		}
+	default:
+		a.envelopeStep = 0
+		if isAlternating {
+			a.isEnvelopeAttack = !a.isEnvelopeAttack
+		}
+	}
+}
+
+func (a *sunsoft5BAudio) getEnvelopeLevel() uint8 {
+	if a.isEnvelopeHolding {
+		return a.envelopeHoldLevel
+	}
+
+	if a.isEnvelopeAttack {
+		return a.envelopeStep
+	}
+
+	return 15 - a.envelopeStep
+}
+
+func (a *sunsoft5BAudio) output() float32 {
+	// ..CB Acba - noise and tone disable bits, active low
+	mixer := a.registers[0x07]
+	isNoiseHigh := a.noiseShift&0x01 != 0
+	sum := float32(0)
+
+	for i := range a.toneOutputs {
+		isToneEnabled := mixer&(1<<i) == 0
+		isNoiseEnabled := mixer&(1<<(i+3)) == 0
+
+		if (isToneEnabled && !a.toneOutputs[i]) || (isNoiseEnabled && !isNoiseHigh) {
+			continue
+		}
+
+		volume := a.registers[0x08+i]
+		level := volume & 0x0F
+
+		if volume&0b10000 != 0 {
+			level = a.getEnvelopeLevel()
+		}
+
+		sum += sunsoft5BVolumeTable[level]
+	}
+
+	return sum * sunsoft5BAudioLevel
+}
+
+func (a *sunsoft5BAudio) serialize(w *state.Writer) {
+	w.Uint8(a.register)
+	w.Bytes(a.registers[:])
+	w.Uint8(a.prescaler)
+
+	for i := range a.toneCounters {
+		w.Uint16(a.toneCounters[i])
+		w.Bool(a.toneOutputs[i])
+	}
+
+	w.Uint8(a.noiseCounter)
+	w.Uint32(a.noiseShift)
+	w.Uint32(a.envelopeCounter)
+	w.Uint8(a.envelopeStep)
+	w.Bool(a.isEnvelopeAttack)
+	w.Bool(a.isEnvelopeHolding)
+	w.Uint8(a.envelopeHoldLevel)
+}
+
+func (a *sunsoft5BAudio) deserialize(r *state.Reader) {
+	a.register = r.Uint8()
+	r.Bytes(a.registers[:])
+	a.prescaler = r.Uint8()
+
+	for i := range a.toneCounters {
+		a.toneCounters[i] = r.Uint16()
+		a.toneOutputs[i] = r.Bool()
+	}
+
+	a.noiseCounter = r.Uint8()
+	a.noiseShift = r.Uint32()
+	a.envelopeCounter = r.Uint32()
+	a.envelopeStep = r.Uint8()
+	a.isEnvelopeAttack = r.Bool()
+	a.isEnvelopeHolding = r.Bool()
+	a.envelopeHoldLevel = r.Uint8()
+}
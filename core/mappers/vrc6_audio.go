@@ -0,0 +1,184 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Level of single volume step, VRC6 pulse at full volume is about as loud as APU pulse at full volume
+const vrc6AudioLevel = 0.1488 / 15
+
+// Expansion audio of VRC6 - two pulse channels and a sawtooth
+// https://wiki.nesdev.com/w/index.php/VRC6_audio
+type vrc6Audio struct {
+	pulses [2]vrc6Pulse
+	saw    vrc6Saw
+
+	// $9003 - halt and period shift (x16, x256) for all channels
+	isHalted    bool
+	periodShift uint8
+}
+
+type vrc6Pulse struct {
+	// MDDD VVVV - ignore duty, duty, volume
+	control     uint8
+	period      uint16
+	isEnabled   bool
+	timer       uint16
+	dutyCounter uint8
+}
+
+type vrc6Saw struct {
+	rate        uint8
+	period      uint16
+	isEnabled   bool
+	timer       uint16
+	step        uint8
+	accumulator uint8
+}
+
+// Writes to $9000-$B002, register has A0 and A1 already decoded
+func (a *vrc6Audio) write(register uint16, data uint8) {
+	switch register {
+	case 0x9003:
+		a.isHalted = data&0b001 != 0
+
+		switch {
+		case data&0b100 != 0:
+			a.periodShift = 8
+		case data&0b010 != 0:
+			a.periodShift = 4
+		default:
+			a.periodShift = 0
+		}
+
+	case 0x9000, 0xA000:
+		a.pulses[(register-0x9000)>>12].control = data
+	case 0x9001, 0xA001:
+		p := &a.pulses[(register-0x9000)>>12]
+		p.period = p.period&0x0F00 | uint16(data)
+	case 0x9002, 0xA002:
+		p := &a.pulses[(register-0x9000)>>12]
+		p.period = p.period&0x00FF | uint16(data&0x0F)<<8
+		p.isEnabled = data&0b10000000 != 0
+
+		// Disabling resets duty position
+		if !p.isEnabled {
+			p.dutyCounter = 15
+		}
+
+	case 0xB000:
+		a.saw.rate = data & 0x3F
+	case 0xB001:
+		a.saw.period = a.saw.period&0x0F00 | uint16(data)
+	case 0xB002:
+		a.saw.period = a.saw.period&0x00FF | uint16(data&0x0F)<<8
+		a.saw.isEnabled = data&0b10000000 != 0
+
+		if !a.saw.isEnabled {
+			a.saw.accumulator = 0
+			a.saw.step = 0
+		}
+	}
+}
+
+// Channel timers are clocked every CPU cycle
+func (a *vrc6Audio) clock() {
+	if a.isHalted {
+		return
+	}
+
+	for i := range a.pulses {
+		p := &a.pulses[i]
+
+		if !p.isEnabled {
+			continue
+		}
+
+		if p.timer == 0 {
+			p.timer = p.period >> a.periodShift
+			p.dutyCounter = (p.dutyCounter - 1) & 0x0F
+		} else {
+			p.timer--
+		}
+	}
+
+	if a.saw.isEnabled {
+		if a.saw.timer == 0 {
+			a.saw.timer = a.saw.period >> a.periodShift
+			a.saw.clockStep()
+		} else {
+			a.saw.timer--
+		}
+	}
+}
+
+// Accumulator is increased on every other step and reset after 14 steps
+func (s *vrc6Saw) clockStep() {
+	s.step++
+
+	if s.step == 14 {
+		s.step = 0
+		s.accumulator = 0
+	} else if s.step&0x01 == 0 {
+		s.accumulator += s.rate
+	}
+}
+
+func (p *vrc6Pulse) output() uint8 {
+	if !p.isEnabled {
+		return 0
+	}
+
+	// In ignore duty mode volume is output constantly
+	if p.control&0b10000000 != 0 || p.dutyCounter <= (p.control>>4)&0b111 {
+		return p.control & 0x0F
+	}
+
+	return 0
+}
+
+func (a *vrc6Audio) output() float32 {
+	saw := a.saw.accumulator >> 3
+	sum := a.pulses[0].output() + a.pulses[1].output() + saw
+
+	return float32(sum) * vrc6AudioLevel
+}
+
+func (a *vrc6Audio) serialize(w *state.Writer) {
+	for _, p := range a.pulses {
+		w.Uint8(p.control)
+		w.Uint16(p.period)
+		w.Bool(p.isEnabled)
+		w.Uint16(p.timer)
+		w.Uint8(p.dutyCounter)
+	}
+
+	w.Uint8(a.saw.rate)
+	w.Uint16(a.saw.period)
+	w.Bool(a.saw.isEnabled)
+	w.Uint16(a.saw.timer)
+	w.Uint8(a.saw.step)
+	w.Uint8(a.saw.accumulator)
+
+	w.Bool(a.isHalted)
+	w.Uint8(a.periodShift)
+}
+
+func (a *vrc6Audio) deserialize(r *state.Reader) {
+	for i := range a.pulses {
+		p := &a.pulses[i]
+		p.control = r.Uint8()
+		p.period = r.Uint16()
+		p.isEnabled = r.Bool()
+		p.timer = r.Uint16()
+		p.dutyCounter = r.Uint8()
+	}
+
+	a.saw.rate = r.Uint8()
+	a.saw.period = r.Uint16()
+	a.saw.isEnabled = r.Bool()
+	a.saw.timer = r.Uint16()
+	a.saw.step = r.Uint8()
+	a.saw.accumulator = r.Uint8()
+
+	a.isHalted = r.Bool()
+	a.periodShift = r.Uint8()
+}
@@ -0,0 +1,98 @@
+package mappers
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// IRQ counter shared by Konami VRC4, VRC6 and VRC7
+// https://wiki.nesdev.com/w/index.php/VRC_IRQ
+type vrcIRQ struct {
+	latch   uint8
+	counter uint8
+
+	// Scan line mode uses prescaler dividing CPU clock by 113.667 (341 / 3)
+	prescaler int16
+
+	isEnabled         bool
+	isEnabledAfterAck bool
+	isCycleMode       bool
+	isPending         bool
+}
+
+func (irq *vrcIRQ) reset() {
+	*irq = vrcIRQ{}
+}
+
+func (irq *vrcIRQ) writeLatch(data uint8) {
+	irq.latch = data
+}
+
+func (irq *vrcIRQ) writeLatchLow(data uint8) {
+	irq.latch = irq.latch&0xF0 | data&0x0F
+}
+
+func (irq *vrcIRQ) writeLatchHigh(data uint8) {
+	irq.latch = irq.latch&0x0F | (data&0x0F)<<4
+}
+
+// ---- -MEA - mode, enable, enable after acknowledge
+func (irq *vrcIRQ) writeControl(data uint8) {
+	irq.isEnabledAfterAck = data&0b001 != 0
+	irq.isEnabled = data&0b010 != 0
+	irq.isCycleMode = data&0b100 != 0
+	irq.isPending = false
+
+	if irq.isEnabled {
+		irq.counter = irq.latch
+		irq.prescaler = 341
+	}
+}
+
+func (irq *vrcIRQ) acknowledge() {
+	irq.isPending = false
+	irq.isEnabled = irq.isEnabledAfterAck
+}
+
+func (irq *vrcIRQ) clock() {
+	if !irq.isEnabled {
+		return
+	}
+
+	if irq.isCycleMode {
+		irq.clockCounter()
+		return
+	}
+
+	irq.prescaler -= 3
+	if irq.prescaler <= 0 {
+		irq.prescaler += 341
+		irq.clockCounter()
+	}
+}
+
+func (irq *vrcIRQ) clockCounter() {
+	if irq.counter == 0xFF {
+		irq.counter = irq.latch
+		irq.isPending = true
+	} else {
+		irq.counter++
+	}
+}
+
+func (irq *vrcIRQ) serialize(w *state.Writer) {
+	w.Uint8(irq.latch)
+	w.Uint8(irq.counter)
+	w.Int16(irq.prescaler)
+	w.Bool(irq.isEnabled)
+	w.Bool(irq.isEnabledAfterAck)
+	w.Bool(irq.isCycleMode)
+	w.Bool(irq.isPending)
+}
+
+func (irq *vrcIRQ) deserialize(r *state.Reader) {
+	irq.latch = r.Uint8()
+	irq.counter = r.Uint8()
+	irq.prescaler = r.Int16()
+	irq.isEnabled = r.Bool()
+	irq.isEnabledAfterAck = r.Bool()
+	irq.isCycleMode = r.Bool()
+	irq.isPending = r.Bool()
+}
@@ -0,0 +1,217 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"github.com/szymonkups/nesgo/core/flags"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"io/ioutil"
+)
+
+// https://wiki.nesdev.com/w/index.php/NSF
+type NSFHeader struct {
+	Magic          [5]uint8
+	Version        uint8
+	TotalSongs     uint8
+	StartingSong   uint8
+	LoadAddress    uint16
+	InitAddress    uint16
+	PlayAddress    uint16
+	SongName       [32]uint8
+	Artist         [32]uint8
+	Copyright      [32]uint8
+	PlaySpeedNTSC  uint16
+	BankswitchInit [8]uint8
+	PlaySpeedPAL   uint16
+	Region         uint8
+	ExtraSoundChip uint8
+	Reserved       [4]uint8
+}
+
+func (h *NSFHeader) GetSongName() string {
+	return nsfString(h.SongName)
+}
+
+func (h *NSFHeader) GetArtist() string {
+	return nsfString(h.Artist)
+}
+
+func (h *NSFHeader) GetCopyright() string {
+	return nsfString(h.Copyright)
+}
+
+// IsPAL - true when tune is PAL only
+func (h *NSFHeader) IsPAL() bool {
+	return h.Region&0b00000011 == 0b00000001
+}
+
+// IsBankswitched - true when any of initial bank values is set
+func (h *NSFHeader) IsBankswitched() bool {
+	for _, bank := range h.BankswitchInit {
+		if bank != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Strings are null terminated
+func nsfString(s [32]uint8) string {
+	if i := bytes.IndexByte(s[:], 0); i >= 0 {
+		return string(s[:i])
+	}
+
+	return string(s[:])
+}
+
+// LoadNSFFile - creates synthetic cartridge playing NSF file
+func (crt *Cartridge) LoadNSFFile(fileName string) (*NSFHeader, error) {
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	header := new(NSFHeader)
+	err = binary.Read(bytes.NewReader(data), binary.LittleEndian, header)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if string(header.Magic[:]) != "NESM\x1A" {
+		return nil, fmt.Errorf("%s is not a NSF file", fileName)
+	}
+
+	if header.TotalSongs == 0 {
+		return nil, fmt.Errorf("NSF file %s contains no songs", fileName)
+	}
+
+	if header.LoadAddress < 0x8000 {
+		return nil, fmt.Errorf("NSF load address $%04X is not supported", header.LoadAddress)
+	}
+
+	crt.mapper = mappers.NewMapperNSF(data[binary.Size(header):], header.LoadAddress, header.BankswitchInit)
+	crt.chrMem = nil
+	crt.fourScreen = false
+	crt.info = RomInfo{}
+	crt.romHash = sha1.Sum(data)
+	crt.saveRam = nil
+	crt.savedData = nil
+
+	return header, nil
+}
+
+// NSFPlayer - drives NSF init and play routines.
+// Routines are called by pointing CPU at them with return address set to idle loop provided by the cartridge.
+type NSFPlayer struct {
+	cpu    *CPU
+	header *NSFHeader
+	track  uint8
+
+	// Play routine period in CPU cycles
+	playPeriod  uint32
+	playCounter uint32
+	playPending bool
+}
+
+func NewNSFPlayer(cpu *CPU, header *NSFHeader) *NSFPlayer {
+	speed := header.PlaySpeedNTSC
+	defaultSpeed := uint16(16639)
+
+	if header.IsPAL() {
+		speed = header.PlaySpeedPAL
+		defaultSpeed = 19997
+	}
+
+	if speed == 0 {
+		speed = defaultSpeed
+	}
+
+	return &NSFPlayer{
+		cpu:        cpu,
+		header:     header,
+		playPeriod: uint32(uint64(speed) * CPUFrequency / 1000000),
+	}
+}
+
+func (p *NSFPlayer) GetTrack() uint8 {
+	return p.track
+}
+
+func (p *NSFPlayer) GetTotalTracks() uint8 {
+	return p.header.TotalSongs
+}
+
+// InitTrack - resets memory and sound and calls init routine for given track (0 based)
+func (p *NSFPlayer) InitTrack(track uint8) {
+	if track >= p.header.TotalSongs {
+		track = 0
+	}
+
+	p.track = track
+
+	// Clear RAM
+	for addr := uint16(0x0000); addr < 0x0800; addr++ {
+		p.cpu.Write(addr, 0)
+	}
+
+	for addr := uint16(0x6000); addr < 0x8000; addr++ {
+		p.cpu.Write(addr, 0)
+	}
+
+	// Initialize sound registers
+	for addr := uint16(0x4000); addr < 0x4014; addr++ {
+		p.cpu.Write(addr, 0)
+	}
+
+	p.cpu.Write(0x4015, 0x0F)
+	p.cpu.Write(0x4017, 0x40)
+
+	// Initial banks
+	if p.header.IsBankswitched() {
+		for i, bank := range p.header.BankswitchInit {
+			p.cpu.Write(0x5FF8+uint16(i), bank)
+		}
+	}
+
+	// Accumulator holds track number, X holds region
+	region := uint8(0)
+	if p.header.IsPAL() {
+		region = 1
+	}
+
+	p.cpu.SetA(track)
+	p.cpu.SetX(region)
+	p.cpu.SetSP(0xFD)
+	p.cpu.GetStatusFlags().Set(flags.I, true)
+	p.call(p.header.InitAddress)
+
+	p.playCounter = p.playPeriod
+	p.playPending = false
+}
+
+// Clock - should be called on every CPU cycle
+func (p *NSFPlayer) Clock() {
+	p.playCounter--
+
+	if p.playCounter == 0 {
+		p.playCounter = p.playPeriod
+		p.playPending = true
+	}
+
+	// Play routine is called only when previous routine returned to idle loop
+	if p.playPending && p.cpu.GetCyclesLeft() == 0 && p.cpu.GetPC() == mappers.NSFIdleAddress {
+		p.playPending = false
+		p.call(p.header.PlayAddress)
+	}
+}
+
+func (p *NSFPlayer) call(addr uint16) {
+	// RTS adds 1 to address pulled from stack
+	p.cpu.PushToStack16(mappers.NSFIdleAddress - 1)
+	p.cpu.SetPC(addr)
+}
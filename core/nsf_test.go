@@ -0,0 +1,217 @@
+package core_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core"
+	"github.com/szymonkups/nesgo/core/mappers"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// Init routine stores A and X in $00 and $01, play routine counts its calls in $02
+var nsfProgram = []uint8{
+	0x85, 0x00, // STA $00
+	0x86, 0x01, // STX $01
+	0x60,       // RTS
+	0xE6, 0x02, // INC $02
+	0x60, // RTS
+}
+
+func createTestNSFHeader() core.NSFHeader {
+	header := core.NSFHeader{
+		Version:       1,
+		TotalSongs:    3,
+		StartingSong:  1,
+		LoadAddress:   0x8000,
+		InitAddress:   0x8000,
+		PlayAddress:   0x8005,
+		PlaySpeedNTSC: 1000,
+		PlaySpeedPAL:  2000,
+	}
+	copy(header.Magic[:], "NESM\x1A")
+
+	return header
+}
+
+func writeTestNSF(t *testing.T, header core.NSFHeader, data []uint8) string {
+	f, err := ioutil.TempFile("", "nesgo-*.nsf")
+	assert.NoError(t, err)
+	defer f.Close()
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, binary.Write(buf, binary.LittleEndian, header))
+	buf.Write(data)
+
+	_, err = f.Write(buf.Bytes())
+	assert.NoError(t, err)
+
+	return f.Name()
+}
+
+type testNSFSystem struct {
+	cpu    *core.CPU
+	ram    *core.Ram
+	player *core.NSFPlayer
+}
+
+func createTestNSFSystem(t *testing.T, header core.NSFHeader, data []uint8) *testNSFSystem {
+	fileName := writeTestNSF(t, header, data)
+	defer os.Remove(fileName)
+
+	cpuBus := core.NewCPUBus()
+	crt := new(core.Cartridge)
+	loadedHeader, err := crt.LoadNSFFile(fileName)
+	assert.NoError(t, err)
+
+	s := &testNSFSystem{ram: new(core.Ram)}
+	cpuBus.ConnectDevice(crt)
+	cpuBus.ConnectDevice(s.ram)
+
+	s.cpu = core.NewCPU(cpuBus)
+	crt.ConnectCPU(s.cpu)
+
+	apu := core.NewAPU(cpuBus, s.cpu)
+	cpuBus.ConnectDevice(apu)
+
+	s.player = core.NewNSFPlayer(s.cpu, loadedHeader)
+
+	return s
+}
+
+func (s *testNSFSystem) run(cycles int) {
+	for i := 0; i < cycles; i++ {
+		s.cpu.Clock()
+		s.player.Clock()
+	}
+}
+
+func (s *testNSFSystem) readRam(addr uint16) uint8 {
+	data, _ := s.ram.Read("cpu", addr, true)
+	return data
+}
+
+func TestNSFHeader(t *testing.T) {
+	a := assert.New(t)
+	header := createTestNSFHeader()
+	copy(header.SongName[:], "Song")
+	copy(header.Artist[:], "Artist")
+	copy(header.Copyright[:], "2020 Copyright holder")
+	header.Region = 0b00000001
+	header.BankswitchInit[7] = 1
+
+	fileName := writeTestNSF(t, header, nsfProgram)
+	defer os.Remove(fileName)
+
+	loaded, err := new(core.Cartridge).LoadNSFFile(fileName)
+	a.NoError(err)
+	a.Equal("Song", loaded.GetSongName(), "Song name should be null terminated")
+	a.Equal("Artist", loaded.GetArtist())
+	a.Equal("2020 Copyright holder", loaded.GetCopyright())
+	a.Equal(uint8(3), loaded.TotalSongs)
+	a.Equal(uint16(0x8005), loaded.PlayAddress)
+	a.True(loaded.IsPAL(), "Tune should be PAL only")
+	a.True(loaded.IsBankswitched(), "Tune should be bankswitched when any initial bank is set")
+
+	// Dual region tunes are played as NTSC
+	loaded.Region = 0b00000010
+	a.False(loaded.IsPAL())
+	loaded.Region = 0b00000011
+	a.False(loaded.IsPAL())
+
+	loaded.BankswitchInit = [8]uint8{}
+	a.False(loaded.IsBankswitched())
+
+	// Full 32 character strings have no terminator
+	copy(loaded.SongName[:], "0123456789ABCDEF0123456789ABCDEF")
+	a.Equal("0123456789ABCDEF0123456789ABCDEF", loaded.GetSongName())
+}
+
+func TestNSFHeaderErrors(t *testing.T) {
+	a := assert.New(t)
+
+	for msg, modify := range map[string]func(h *core.NSFHeader){
+		"Wrong magic should be rejected":              func(h *core.NSFHeader) { h.Magic[3] = 'N' },
+		"File without songs should be rejected":       func(h *core.NSFHeader) { h.TotalSongs = 0 },
+		"Load address below $8000 should be rejected": func(h *core.NSFHeader) { h.LoadAddress = 0x6000 },
+	} {
+		header := createTestNSFHeader()
+		modify(&header)
+		fileName := writeTestNSF(t, header, nsfProgram)
+
+		_, err := new(core.Cartridge).LoadNSFFile(fileName)
+		a.Error(err, msg)
+		os.Remove(fileName)
+	}
+}
+
+func TestNSFPlayerInit(t *testing.T) {
+	a := assert.New(t)
+	s := createTestNSFSystem(t, createTestNSFHeader(), nsfProgram)
+
+	s.player.InitTrack(2)
+	s.run(100)
+	a.Equal(uint8(2), s.player.GetTrack())
+	a.Equal(uint8(2), s.readRam(0x00), "Init routine should get track number in A")
+	a.Equal(uint8(0), s.readRam(0x01), "Init routine should get NTSC flag in X")
+	a.Equal(uint16(mappers.NSFIdleAddress), s.cpu.GetPC(), "Init routine should return to idle loop")
+
+	// Invalid track falls back to first one
+	s.player.InitTrack(3)
+	s.run(100)
+	a.Equal(uint8(0), s.player.GetTrack())
+	a.Equal(uint8(0), s.readRam(0x00))
+
+	header := createTestNSFHeader()
+	header.Region = 0b00000001
+	s = createTestNSFSystem(t, header, nsfProgram)
+
+	s.player.InitTrack(1)
+	s.run(100)
+	a.Equal(uint8(1), s.readRam(0x00))
+	a.Equal(uint8(1), s.readRam(0x01), "Init routine should get PAL flag in X")
+}
+
+func TestNSFPlayerInitBanks(t *testing.T) {
+	a := assert.New(t)
+	header := createTestNSFHeader()
+	header.BankswitchInit = [8]uint8{2, 1, 2, 3, 4, 5, 6, 7}
+
+	// Routines are in third 4KB bank, which is switched to $8000 before init
+	data := make([]uint8, 0x3000)
+	copy(data[0x2000:], nsfProgram)
+	s := createTestNSFSystem(t, header, data)
+
+	s.player.InitTrack(1)
+	s.run(100)
+	a.Equal(uint8(1), s.readRam(0x00), "Init routine should be called with initial banks")
+}
+
+func TestNSFPlayerPlayRate(t *testing.T) {
+	a := assert.New(t)
+
+	// 1000us NTSC and 2000us PAL play speed
+	for region, period := range map[uint8]int{0: 1789, 1: 3579} {
+		header := createTestNSFHeader()
+		header.Region = region
+		s := createTestNSFSystem(t, header, nsfProgram)
+
+		s.player.InitTrack(0)
+		s.run(period - 1)
+		a.Equal(uint8(0), s.readRam(0x02), "Play routine should not be called before period elapses")
+
+		s.run(100)
+		a.Equal(uint8(1), s.readRam(0x02), "Play routine should be called after period elapses")
+
+		s.run(period * 10)
+		a.Equal(uint8(11), s.readRam(0x02), "Play routine should be called every %d cycles", period)
+		a.Equal(uint16(mappers.NSFIdleAddress), s.cpu.GetPC(), "Play routine should return to idle loop")
+
+		// Init clears RAM and restarts play timer
+		s.player.InitTrack(0)
+		s.run(period - 1)
+		a.Equal(uint8(0), s.readRam(0x02), "Init should clear RAM")
+	}
+}
@@ -2,7 +2,9 @@ package core
 
 import (
 	"fmt"
+	"github.com/szymonkups/nesgo/core/mappers"
 	"github.com/szymonkups/nesgo/core/ppu"
+	"github.com/szymonkups/nesgo/core/state"
 )
 
 type PPUColor struct {
@@ -41,8 +43,30 @@ type PPU struct {
 	bgShifterPatterHi uint16
 	bgShifterAttribLo uint16
 	bgShifterAttribHi uint16
+
+	oam          *ppu.OAM
+	oamAddress   uint8
+	secondaryOAM [8]ppu.Sprite
+	spriteCount  uint8
+
+	// True when sprite 0 was copied to secondary OAM (it's always in slot 0 then)
+	spriteZeroInSecondaryOAM bool
+
+	spriteShifterPatternLo [8]uint8
+	spriteShifterPatternHi [8]uint8
+	spriteAttributes       [8]uint8
+	spriteCounters         [8]uint8
+
+	// Cartridge observes PPU address bus (MMC3 IRQ counter etc.)
+	crt *Cartridge
+
+	// PPU cycles since power up
+	totalCycles uint64
 }
 
+// Secondary OAM is filled with 0xFF before sprite evaluation
+var emptySprite = ppu.Sprite{Y: 0xFF, TileId: 0xFF, Attributes: 0xFF, X: 0xFF}
+
 func NewPPU(bus *bus) *PPU {
 	newPPU := &PPU{
 		scanLine:        -1,
@@ -54,6 +78,7 @@ func NewPPU(bus *bus) *PPU {
 		maskRegister:    new(ppu.MaskRegister),
 		vRamAddress:     new(ppu.LoopyRegister),
 		tRamAddress:     new(ppu.LoopyRegister),
+		oam:             new(ppu.OAM),
 		fineX:           0,
 		dataBuffer:      0,
 	}
@@ -73,10 +98,113 @@ func (ppu *PPU) SetDrawMethod(draw setPixel) {
 	ppu.drawScreen = draw
 }
 
+// ConnectCartridge - cartridge will be notified about every address PPU puts on its bus
+func (ppu *PPU) ConnectCartridge(crt *Cartridge) {
+	ppu.crt = crt
+}
+
 func (ppu *PPU) GetCurrentScanLine() int16 {
 	return ppu.scanLine
 }
 
+// GetCurrentCycle - position of the beam within current scan line
+func (ppu *PPU) GetCurrentCycle() int16 {
+	return ppu.cycle
+}
+
+// GetTotalCycles - number of PPU cycles since power up
+func (ppu *PPU) GetTotalCycles() uint64 {
+	return ppu.totalCycles
+}
+
+// Serialize - saves registers, loopy registers, shifters and OAM, VRAM is serialized separately
+func (ppu *PPU) Serialize(w *state.Writer) {
+	w.Bool(ppu.NMI)
+	w.Int16(ppu.scanLine)
+	w.Int16(ppu.cycle)
+	w.Bool(ppu.IsFrameComplete)
+	w.Uint8(ppu.ctrlRegister.Read())
+	w.Uint8(ppu.statusRegister.Read())
+	w.Uint8(ppu.maskRegister.Read())
+	w.Uint16(ppu.vRamAddress.Read())
+	w.Uint16(ppu.tRamAddress.Read())
+	w.Bool(ppu.addressLatch)
+	w.Uint8(ppu.fineX)
+	w.Uint8(ppu.dataBuffer)
+
+	w.Uint8(ppu.bgNextTileId)
+	w.Uint8(ppu.bgNextTileAttrib)
+	w.Uint8(ppu.bgNextTileLsb)
+	w.Uint8(ppu.bgNextTileMsb)
+	w.Uint16(ppu.bgShifterPatterLo)
+	w.Uint16(ppu.bgShifterPatterHi)
+	w.Uint16(ppu.bgShifterAttribLo)
+	w.Uint16(ppu.bgShifterAttribHi)
+
+	ppu.oam.Serialize(w)
+	w.Uint8(ppu.oamAddress)
+
+	for _, sprite := range ppu.secondaryOAM {
+		w.Uint8(sprite.Y)
+		w.Uint8(sprite.TileId)
+		w.Uint8(sprite.Attributes)
+		w.Uint8(sprite.X)
+	}
+
+	w.Uint8(ppu.spriteCount)
+	w.Bool(ppu.spriteZeroInSecondaryOAM)
+	w.Bytes(ppu.spriteShifterPatternLo[:])
+	w.Bytes(ppu.spriteShifterPatternHi[:])
+	w.Bytes(ppu.spriteAttributes[:])
+	w.Bytes(ppu.spriteCounters[:])
+
+	w.Uint64(ppu.totalCycles)
+}
+
+func (ppu *PPU) Deserialize(r *state.Reader) {
+	ppu.NMI = r.Bool()
+	ppu.scanLine = r.Int16()
+	ppu.cycle = r.Int16()
+	ppu.IsFrameComplete = r.Bool()
+	ppu.ctrlRegister.Write(r.Uint8())
+	ppu.statusRegister.Write(r.Uint8())
+	ppu.maskRegister.Write(r.Uint8())
+	ppu.vRamAddress.Write(r.Uint16())
+	ppu.tRamAddress.Write(r.Uint16())
+	ppu.addressLatch = r.Bool()
+	ppu.fineX = r.Uint8()
+	ppu.dataBuffer = r.Uint8()
+
+	ppu.bgNextTileId = r.Uint8()
+	ppu.bgNextTileAttrib = r.Uint8()
+	ppu.bgNextTileLsb = r.Uint8()
+	ppu.bgNextTileMsb = r.Uint8()
+	ppu.bgShifterPatterLo = r.Uint16()
+	ppu.bgShifterPatterHi = r.Uint16()
+	ppu.bgShifterAttribLo = r.Uint16()
+	ppu.bgShifterAttribHi = r.Uint16()
+
+	ppu.oam.Deserialize(r)
+	ppu.oamAddress = r.Uint8()
+
+	for i := range ppu.secondaryOAM {
+		sprite := &ppu.secondaryOAM[i]
+		sprite.Y = r.Uint8()
+		sprite.TileId = r.Uint8()
+		sprite.Attributes = r.Uint8()
+		sprite.X = r.Uint8()
+	}
+
+	ppu.spriteCount = r.Uint8()
+	ppu.spriteZeroInSecondaryOAM = r.Bool()
+	r.Bytes(ppu.spriteShifterPatternLo[:])
+	r.Bytes(ppu.spriteShifterPatternHi[:])
+	r.Bytes(ppu.spriteAttributes[:])
+	r.Bytes(ppu.spriteCounters[:])
+
+	ppu.totalCycles = r.Uint64()
+}
+
 func (ppu *PPU) Read(_ string, addr uint16, debug bool) (uint8, bool) {
 	if debug {
 		panic(fmt.Errorf("debug read from ppu is not implemented yet"))
@@ -105,8 +233,7 @@ func (ppu *PPU) Read(_ string, addr uint16, debug bool) (uint8, bool) {
 
 		case 0x04:
 			// Sprite Memory Data - OAMDATA - read/write
-			// TODO implement reading OAMDATA
-			return 0x00, true
+			return ppu.oam.Read(ppu.oamAddress), true
 
 		case 0x05:
 			// Screen Scroll Offset - PPUSCROLL - write only
@@ -121,6 +248,7 @@ func (ppu *PPU) Read(_ string, addr uint16, debug bool) (uint8, bool) {
 
 			// Get is normally delayed by 1 cycle...
 			toReturn := ppu.dataBuffer
+			ppu.notifyAddress(address)
 			ppu.dataBuffer = ppu.bus.Read(address)
 
 			// ...until we read from palette memory
@@ -168,11 +296,13 @@ func (ppu *PPU) Write(_ string, addr uint16, data uint8, debug bool) bool {
 
 		case 0x03:
 			// Sprite Memory Address - OAMADDR - write only
+			ppu.oamAddress = data
 			return true
 
 		case 0x04:
 			// Sprite Memory Data - OAMDATA - read/write
-			// TODO implement reading OAMDATA
+			ppu.oam.Write(ppu.oamAddress, data)
+			ppu.oamAddress++
 			return true
 
 		case 0x05:
@@ -198,10 +328,12 @@ func (ppu *PPU) Write(_ string, addr uint16, data uint8, debug bool) bool {
 				ppu.tRamAddress.Write((tRamValue & 0xFF00) | uint16(data))
 				ppu.vRamAddress.Write(ppu.tRamAddress.Read())
 				ppu.addressLatch = false
+				ppu.notifyAddress(ppu.vRamAddress.Read())
 			}
 			return true
 
 		case 0x07:
+			ppu.notifyAddress(ppu.vRamAddress.Read())
 			ppu.bus.Write(ppu.vRamAddress.Read(), data)
 			ppu.vRamAddress.Increment(ppu.ctrlRegister.GetIncrementMode())
 			return true
@@ -212,9 +344,11 @@ func (ppu *PPU) Write(_ string, addr uint16, data uint8, debug bool) bool {
 }
 
 func (ppu *PPU) Clock() {
-	// End VBlank
+	// End VBlank, clear sprite flags
 	if ppu.scanLine == -1 && ppu.cycle == 1 {
 		ppu.statusRegister.SetVBlank(false)
+		ppu.statusRegister.SetSpriteZeroHit(false)
+		ppu.statusRegister.SetSpriteOverflow(false)
 	}
 
 	if ppu.scanLine >= -1 && ppu.scanLine < 240 {
@@ -232,9 +366,11 @@ func (ppu *PPU) Clock() {
 			switch (ppu.cycle - 1) % 8 {
 			case 0:
 				ppu.loadBgShifters()
-				ppu.bgNextTileId = ppu.bus.Read(0x2000 | (ppu.vRamAddress.Read() & 0x0FFF))
+				ppu.notifyFetch(mappers.FetchNameTable)
+				ppu.bgNextTileId = ppu.fetch(0x2000 | (ppu.vRamAddress.Read() & 0x0FFF))
 			case 2:
-				ppu.bgNextTileAttrib = ppu.bus.Read(
+				ppu.notifyFetch(mappers.FetchAttribute)
+				ppu.bgNextTileAttrib = ppu.fetch(
 					0x23C0 |
 						uint16(ppu.vRamAddress.GetNameTableY())<<11 |
 						uint16(ppu.vRamAddress.GetNameTableX())<<10 |
@@ -251,9 +387,11 @@ func (ppu *PPU) Clock() {
 
 				ppu.bgNextTileAttrib &= 0x03
 			case 4:
-				ppu.bgNextTileLsb = ppu.bus.Read(uint16(ppu.ctrlRegister.GetBgPatternTableAddress())<<12 + (uint16(ppu.bgNextTileId) << 4) + uint16(ppu.vRamAddress.GetFineY()))
+				ppu.notifyFetch(mappers.FetchBackgroundPattern)
+				ppu.bgNextTileLsb = ppu.fetch(uint16(ppu.ctrlRegister.GetBgPatternTableAddress())<<12 + (uint16(ppu.bgNextTileId) << 4) + uint16(ppu.vRamAddress.GetFineY()))
 			case 6:
-				ppu.bgNextTileMsb = ppu.bus.Read(uint16(ppu.ctrlRegister.GetBgPatternTableAddress())<<12 + (uint16(ppu.bgNextTileId) << 4) + uint16(ppu.vRamAddress.GetFineY()) + 8)
+				ppu.notifyFetch(mappers.FetchBackgroundPattern)
+				ppu.bgNextTileMsb = ppu.fetch(uint16(ppu.ctrlRegister.GetBgPatternTableAddress())<<12 + (uint16(ppu.bgNextTileId) << 4) + uint16(ppu.vRamAddress.GetFineY()) + 8)
 			case 7:
 				ppu.incrementScrollX()
 			}
@@ -270,6 +408,29 @@ func (ppu *PPU) Clock() {
 		if ppu.scanLine == -1 && ppu.cycle >= 280 && ppu.cycle < 305 {
 			ppu.transferAddressY()
 		}
+
+		// Sprites
+		// https://wiki.nesdev.com/w/index.php/PPU_sprite_evaluation
+		if ppu.isRenderingEnabled() {
+			if ppu.cycle == 64 {
+				ppu.clearSecondaryOAM()
+			}
+
+			if ppu.cycle == 257 {
+				if ppu.scanLine >= 0 {
+					ppu.evaluateSprites()
+				} else {
+					// Sprites are never rendered on the first visible scan line
+					ppu.spriteCount = 0
+					ppu.spriteZeroInSecondaryOAM = false
+				}
+			}
+
+			if ppu.cycle >= 257 && ppu.cycle <= 320 {
+				ppu.oamAddress = 0
+				ppu.fetchSprite(uint8((ppu.cycle-257)/8), (ppu.cycle-257)%8)
+			}
+		}
 	}
 
 	if ppu.scanLine == 240 {
@@ -285,46 +446,12 @@ func (ppu *PPU) Clock() {
 	}
 
 	// Render to the screen
-	if ppu.maskRegister.ShowBg {
-		var bitMux uint16 = 0x8000 >> ppu.fineX
-		var p0Pixel, p1Pixel uint8
-
-		if (ppu.bgShifterPatterLo & bitMux) > 0 {
-			p0Pixel = 1
-		} else {
-			p0Pixel = 0
-		}
-
-		if (ppu.bgShifterPatterHi & bitMux) > 0 {
-			p1Pixel = 1
-		} else {
-			p1Pixel = 0
-		}
-
-		pixel := (p1Pixel << 1) | p0Pixel
-
-		var pal0, pal1 uint8
-		if (ppu.bgShifterAttribLo & bitMux) > 0 {
-			pal0 = 1
-		} else {
-			pal0 = 0
-		}
-
-		if (ppu.bgShifterAttribHi & bitMux) > 0 {
-			pal1 = 1
-		} else {
-			pal1 = 0
-		}
-
-		palette := (pal1 << 1) | pal0
-
-		if ppu.drawScreen != nil && ppu.cycle-1 > -1 && ppu.scanLine > -1 {
-			ppu.drawScreen(ppu.cycle-1, ppu.scanLine, ppu.GetColorFromPalette(palette, pixel))
-		}
-
+	if ppu.scanLine >= 0 && ppu.scanLine < 240 && ppu.cycle >= 1 && ppu.cycle <= 256 {
+		ppu.renderPixel()
 	}
 
 	ppu.cycle++
+	ppu.totalCycles++
 	// Single line of screen is 256 but scan line goes above that to 341
 	if ppu.cycle >= 341 {
 		ppu.cycle = 0
@@ -363,6 +490,254 @@ func (ppu *PPU) updateShifters() {
 		ppu.bgShifterAttribLo <<= 1
 		ppu.bgShifterAttribHi <<= 1
 	}
+
+	if ppu.maskRegister.ShowSprites && ppu.cycle >= 1 && ppu.cycle < 258 {
+		for i := uint8(0); i < ppu.spriteCount; i++ {
+			if ppu.spriteCounters[i] > 0 {
+				ppu.spriteCounters[i]--
+			} else {
+				ppu.spriteShifterPatternLo[i] <<= 1
+				ppu.spriteShifterPatternHi[i] <<= 1
+			}
+		}
+	}
+}
+
+func (ppu *PPU) clearSecondaryOAM() {
+	for i := range ppu.secondaryOAM {
+		ppu.secondaryOAM[i] = emptySprite
+	}
+}
+
+// Finds up to 8 sprites visible on the next scan line and copies them to secondary OAM.
+// https://wiki.nesdev.com/w/index.php/PPU_sprite_evaluation
+func (ppu *PPU) evaluateSprites() {
+	height := int16(ppu.ctrlRegister.GetSpriteHeight())
+	ppu.spriteCount = 0
+	ppu.spriteZeroInSecondaryOAM = false
+
+	n := uint8(0)
+	for ; n < 64 && ppu.spriteCount < 8; n++ {
+		sprite := ppu.oam.GetSprite(n)
+		diff := ppu.scanLine - int16(sprite.Y)
+
+		if diff >= 0 && diff < height {
+			if n == 0 {
+				ppu.spriteZeroInSecondaryOAM = true
+			}
+
+			ppu.secondaryOAM[ppu.spriteCount] = sprite
+			ppu.spriteCount++
+		}
+	}
+
+	// Sprite overflow check. Hardware increments both sprite index (n) and byte index (m) when sprite is not
+	// in range, so it checks tile ids, attributes and x positions as if they were y coordinates. This results
+	// in both false positives and false negatives which some games depend on.
+	m := uint8(0)
+	for ; n < 64; n++ {
+		y := ppu.oam.Read(n<<2 + m)
+		diff := ppu.scanLine - int16(y)
+
+		if diff >= 0 && diff < height {
+			ppu.statusRegister.SetSpriteOverflow(true)
+			break
+		}
+
+		m = (m + 1) & 0x03
+	}
+}
+
+// Sprite tile fetches - 8 cycles for each of 8 secondary OAM slots. Empty slots still
+// perform reads (of tile 0xFF) but are loaded as transparent.
+func (ppu *PPU) fetchSprite(i uint8, step int16) {
+	sprite := ppu.secondaryOAM[i]
+
+	switch step {
+	case 4:
+		ppu.notifySpriteFetch(i)
+		lo := ppu.fetch(ppu.getSpritePatternAddress(sprite))
+
+		if sprite.IsFlippedHorizontally() {
+			lo = reverseBits(lo)
+		}
+
+		ppu.spriteShifterPatternLo[i] = lo
+	case 6:
+		ppu.notifySpriteFetch(i)
+		hi := ppu.fetch(ppu.getSpritePatternAddress(sprite) + 8)
+
+		if sprite.IsFlippedHorizontally() {
+			hi = reverseBits(hi)
+		}
+
+		ppu.spriteShifterPatternHi[i] = hi
+		ppu.spriteAttributes[i] = sprite.Attributes
+		ppu.spriteCounters[i] = sprite.X
+
+		if i >= ppu.spriteCount {
+			ppu.spriteShifterPatternLo[i] = 0
+			ppu.spriteShifterPatternHi[i] = 0
+		}
+	}
+}
+
+// Address of the low bit plane of sprite's row on the next scan line.
+func (ppu *PPU) getSpritePatternAddress(sprite ppu.Sprite) uint16 {
+	height := uint16(ppu.ctrlRegister.GetSpriteHeight())
+	row := uint16(ppu.scanLine-int16(sprite.Y)) & (height - 1)
+
+	if sprite.IsFlippedVertically() {
+		row = height - 1 - row
+	}
+
+	if height == 8 {
+		return uint16(ppu.ctrlRegister.GetSpritePatternTableAddress())<<12 | uint16(sprite.TileId)<<4 | row
+	}
+
+	// 8x16 sprites - pattern table is selected by bit 0 of tile id, bottom half uses next tile
+	tile := uint16(sprite.TileId & 0xFE)
+	if row >= 8 {
+		tile++
+	}
+
+	return uint16(sprite.TileId&0x01)<<12 | tile<<4 | (row & 0x07)
+}
+
+// Rendering fetch - PPU bus is idle when rendering is disabled, so cartridge doesn't see the address then
+func (ppu *PPU) fetch(addr uint16) uint8 {
+	if ppu.isRenderingEnabled() {
+		ppu.notifyAddress(addr)
+	}
+
+	return ppu.bus.Read(addr)
+}
+
+// Tells the cartridge which background tile next fetch belongs to. Tiles 0 and 1 are fetched at the end of
+// previous scan line, so first fetch during the line is the third tile.
+func (ppu *PPU) notifyFetch(kind uint8) {
+	if ppu.crt == nil || !ppu.isRenderingEnabled() {
+		return
+	}
+
+	if ppu.cycle >= 321 {
+		ppu.crt.NotifyRenderingFetch(kind, ppu.scanLine+1, uint8((ppu.cycle-321)/8))
+	} else {
+		ppu.crt.NotifyRenderingFetch(kind, ppu.scanLine, uint8((ppu.cycle+15)/8))
+	}
+}
+
+// Sprite patterns are fetched for the next scan line
+func (ppu *PPU) notifySpriteFetch(slot uint8) {
+	if ppu.crt == nil || !ppu.isRenderingEnabled() {
+		return
+	}
+
+	ppu.crt.NotifyRenderingFetch(mappers.FetchSpritePattern, ppu.scanLine+1, slot)
+}
+
+func (ppu *PPU) notifyAddress(addr uint16) {
+	if ppu.crt != nil {
+		ppu.crt.NotifyPPUAddress(addr, ppu.totalCycles)
+	}
+}
+
+func reverseBits(b uint8) uint8 {
+	b = (b&0xF0)>>4 | (b&0x0F)<<4
+	b = (b&0xCC)>>2 | (b&0x33)<<2
+	b = (b&0xAA)>>1 | (b&0x55)<<1
+
+	return b
+}
+
+func (ppu *PPU) isRenderingEnabled() bool {
+	return ppu.maskRegister.ShowBg || ppu.maskRegister.ShowSprites
+}
+
+func (ppu *PPU) getBackgroundPixel() (pixel uint8, palette uint8) {
+	var bitMux uint16 = 0x8000 >> ppu.fineX
+
+	if (ppu.bgShifterPatterLo & bitMux) > 0 {
+		pixel |= 0b01
+	}
+
+	if (ppu.bgShifterPatterHi & bitMux) > 0 {
+		pixel |= 0b10
+	}
+
+	if (ppu.bgShifterAttribLo & bitMux) > 0 {
+		palette |= 0b01
+	}
+
+	if (ppu.bgShifterAttribHi & bitMux) > 0 {
+		palette |= 0b10
+	}
+
+	return pixel, palette
+}
+
+// Returns first non transparent pixel of sprites on current position. Sprites earlier in OAM have priority.
+// Returned index is secondary OAM slot of the sprite.
+func (ppu *PPU) getSpritePixel() (pixel uint8, palette uint8, inFront bool, index uint8) {
+	for i := uint8(0); i < ppu.spriteCount; i++ {
+		if ppu.spriteCounters[i] != 0 {
+			continue
+		}
+
+		pixel = (ppu.spriteShifterPatternHi[i]>>7)<<1 | ppu.spriteShifterPatternLo[i]>>7
+
+		if pixel != 0 {
+			// Sprite palettes are stored after background palettes
+			palette = (ppu.spriteAttributes[i] & 0b00000011) + 4
+			inFront = ppu.spriteAttributes[i]&0b00100000 == 0
+
+			return pixel, palette, inFront, i
+		}
+	}
+
+	return 0, 0, false, 0
+}
+
+func (ppu *PPU) renderPixel() {
+	x := ppu.cycle - 1
+
+	var bgPixel, bgPalette uint8
+	if ppu.maskRegister.ShowBg && (ppu.maskRegister.ShowBgLeft || x >= 8) {
+		bgPixel, bgPalette = ppu.getBackgroundPixel()
+	}
+
+	var fgPixel, fgPalette, fgIndex uint8
+	var fgInFront bool
+	if ppu.maskRegister.ShowSprites && (ppu.maskRegister.ShowSpritesLeft || x >= 8) {
+		fgPixel, fgPalette, fgInFront, fgIndex = ppu.getSpritePixel()
+	}
+
+	// https://wiki.nesdev.com/w/index.php/PPU_OAM#Sprite_zero_hits
+	// Hit happens when opaque pixel of sprite 0 overlaps opaque background pixel, regardless of sprite priority.
+	// Pixels hidden by left 8 pixels clipping are already transparent here. Hit never happens at x=255.
+	if bgPixel != 0 && fgPixel != 0 && fgIndex == 0 && ppu.spriteZeroInSecondaryOAM && x != 255 {
+		ppu.statusRegister.SetSpriteZeroHit(true)
+	}
+
+	// https://wiki.nesdev.com/w/index.php/PPU_rendering#Preconditions
+	// Background and sprite multiplexer - pixel 0 of any palette is transparent.
+	var pixel, palette uint8
+	switch {
+	case bgPixel == 0 && fgPixel == 0:
+		pixel, palette = 0, 0
+	case bgPixel == 0:
+		pixel, palette = fgPixel, fgPalette
+	case fgPixel == 0:
+		pixel, palette = bgPixel, bgPalette
+	case fgInFront:
+		pixel, palette = fgPixel, fgPalette
+	default:
+		pixel, palette = bgPixel, bgPalette
+	}
+
+	if ppu.drawScreen != nil {
+		ppu.drawScreen(x, ppu.scanLine, ppu.GetColorFromPalette(palette, pixel))
+	}
 }
 
 func (ppu *PPU) incrementScrollX() {
@@ -14,6 +14,22 @@ func (ctrl *ControlRegister) GetBgPatternTableAddress() uint8 {
 	return 0
 }
 
+func (ctrl *ControlRegister) GetSpritePatternTableAddress() uint8 {
+	if ctrl.value&0b00001000 != 0 {
+		return 1
+	}
+
+	return 0
+}
+
+func (ctrl *ControlRegister) GetSpriteHeight() uint8 {
+	if ctrl.value&0b00100000 != 0 {
+		return 16
+	}
+
+	return 8
+}
+
 func (ctrl *ControlRegister) Write(value uint8) {
 	ctrl.value = value
 	//
@@ -19,7 +19,7 @@ func (mask *MaskRegister) Write(value uint8) {
 
 	mask.GreyScale = value&0b00000001 > 0
 	mask.ShowBgLeft = value&0b00000010 > 0
-	mask.ShowSprites = value&0b00000100 > 0
+	mask.ShowSpritesLeft = value&0b00000100 > 0
 	mask.ShowBg = value&0b00001000 > 0
 	mask.ShowSprites = value&0b00010000 > 0
 	mask.EmphasizeRed = value&0b00100000 > 0
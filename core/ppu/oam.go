@@ -0,0 +1,65 @@
+package ppu
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// https://wiki.nesdev.com/w/index.php/PPU_OAM
+// Object attribute memory - 64 sprites, 4 bytes each.
+type OAM struct {
+	data [0x100]uint8
+}
+
+func (oam *OAM) Read(addr uint8) uint8 {
+	// Bits 2-4 of attribute byte are not implemented in hardware and always read back as 0
+	if addr&0x03 == 0x02 {
+		return oam.data[addr] & 0b11100011
+	}
+
+	return oam.data[addr]
+}
+
+func (oam *OAM) Write(addr uint8, data uint8) {
+	oam.data[addr] = data
+}
+
+func (oam *OAM) GetSprite(index uint8) Sprite {
+	offset := uint16(index&0x3F) << 2
+
+	return Sprite{
+		Y:          oam.data[offset],
+		TileId:     oam.data[offset+1],
+		Attributes: oam.data[offset+2],
+		X:          oam.data[offset+3],
+	}
+}
+
+func (oam *OAM) Serialize(w *state.Writer) {
+	w.Bytes(oam.data[:])
+}
+
+func (oam *OAM) Deserialize(r *state.Reader) {
+	r.Bytes(oam.data[:])
+}
+
+// Sprite - single OAM entry
+type Sprite struct {
+	Y          uint8
+	TileId     uint8
+	Attributes uint8
+	X          uint8
+}
+
+func (s Sprite) GetPalette() uint8 {
+	return s.Attributes & 0b00000011
+}
+
+func (s Sprite) IsBehindBackground() bool {
+	return s.Attributes&0b00100000 != 0
+}
+
+func (s Sprite) IsFlippedHorizontally() bool {
+	return s.Attributes&0b01000000 != 0
+}
+
+func (s Sprite) IsFlippedVertically() bool {
+	return s.Attributes&0b10000000 != 0
+}
@@ -25,10 +25,26 @@ func (status *StatusRegister) GetSpriteZeroHit() bool {
 	return status.byteRepresentation&0b01000000 > 0
 }
 
+func (status *StatusRegister) SetSpriteZeroHit(value bool) {
+	if value {
+		status.byteRepresentation |= 0b01000000
+	} else {
+		status.byteRepresentation &= 0b10111111
+	}
+}
+
 func (status *StatusRegister) GetSpriteOverflow() bool {
 	return status.byteRepresentation&0b00100000 > 0
 }
 
+func (status *StatusRegister) SetSpriteOverflow(value bool) {
+	if value {
+		status.byteRepresentation |= 0b00100000
+	} else {
+		status.byteRepresentation &= 0b11011111
+	}
+}
+
 func (status *StatusRegister) Read() uint8 {
 	return status.byteRepresentation
 }
@@ -0,0 +1,427 @@
+package core_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core"
+	"testing"
+)
+
+const (
+	// Mask with background and sprites shown, including left 8 pixels
+	ppuMaskShowAll = 0b00011110
+	// Mask with background and sprites shown, left 8 pixels clipped
+	ppuMaskClipLeft = 0b00011000
+)
+
+type testScreen [240][256]*core.PPUColor
+
+// PPU with NROM cartridge using CHR RAM, palettes are set so every sprite and background color is different
+func createTestPPU(t *testing.T) *core.PPU {
+	crt := new(core.Cartridge)
+	assert.NoError(t, crt.LoadBytes(createTestRom([]uint8{'N', 'E', 'S', 0x1A, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 0x4000, 0)))
+
+	ppuBus := core.NewPPUBus()
+	ppuBus.ConnectDevice(crt)
+	ppuBus.ConnectDevice(core.NewVRam(crt))
+
+	p := core.NewPPU(ppuBus)
+	p.ConnectCartridge(crt)
+
+	writeVRam(p, 0x3F00,
+		0x0F, 0x01, 0x02, 0x03, 0x0F, 0x04, 0x05, 0x06, 0x0F, 0x07, 0x08, 0x09, 0x0F, 0x0A, 0x0B, 0x0C,
+		0x0F, 0x11, 0x12, 0x13, 0x0F, 0x14, 0x15, 0x16, 0x0F, 0x17, 0x18, 0x19, 0x0F, 0x1A, 0x1B, 0x1C)
+
+	return p
+}
+
+func writeVRam(p *core.PPU, addr uint16, data ...uint8) {
+	p.Write("cpu", 0x2006, uint8(addr>>8), false)
+	p.Write("cpu", 0x2006, uint8(addr), false)
+
+	for _, d := range data {
+		p.Write("cpu", 0x2007, d, false)
+	}
+}
+
+// Writes 8x8 tile with all rows set to given bit planes
+func writeTile(p *core.PPU, table uint16, tile uint8, lo uint8, hi uint8) {
+	addr := table<<12 | uint16(tile)<<4
+
+	for row := uint16(0); row < 8; row++ {
+		writeVRam(p, addr+row, lo)
+		writeVRam(p, addr+row+8, hi)
+	}
+}
+
+// Writes 8x8 tile with all pixels set to given value
+func writeSolidTile(p *core.PPU, table uint16, tile uint8, pixel uint8) {
+	lo, hi := uint8(0), uint8(0)
+
+	if pixel&0b01 != 0 {
+		lo = 0xFF
+	}
+
+	if pixel&0b10 != 0 {
+		hi = 0xFF
+	}
+
+	writeTile(p, table, tile, lo, hi)
+}
+
+// Places tile at given position of the first nametable
+func writeNameTable(p *core.PPU, column uint16, row uint16, tile uint8) {
+	writeVRam(p, 0x2000+row*32+column, tile)
+}
+
+// Hides all sprites below the screen and writes given sprites (Y, tile, attributes, X) to the beginning of OAM
+func writeSprites(p *core.PPU, sprites ...[4]uint8) {
+	p.Write("cpu", 0x2003, 0x00, false)
+
+	for i := 0; i < 64; i++ {
+		sprite := [4]uint8{0xFF, 0xFF, 0xFF, 0xFF}
+		if i < len(sprites) {
+			sprite = sprites[i]
+		}
+
+		for _, data := range sprite {
+			p.Write("cpu", 0x2004, data, false)
+		}
+	}
+}
+
+// Resets scroll and enables rendering
+func startRendering(p *core.PPU, ctrl uint8, mask uint8) {
+	p.Write("cpu", 0x2000, ctrl, false)
+	writeVRam(p, 0x0000)
+	p.Write("cpu", 0x2001, mask, false)
+}
+
+func renderFrame(p *core.PPU) *testScreen {
+	screen := new(testScreen)
+	p.SetDrawMethod(func(x, y int16, pixel *core.PPUColor) {
+		screen[y][x] = pixel
+	})
+
+	for !p.IsFrameComplete {
+		p.Clock()
+	}
+
+	p.IsFrameComplete = false
+
+	return screen
+}
+
+// Clocks PPU until given dot is about to be rendered
+func runUntil(p *core.PPU, scanLine int16, cycle int16) {
+	for p.GetCurrentScanLine() != scanLine || p.GetCurrentCycle() != cycle {
+		p.Clock()
+	}
+}
+
+func isSpriteZeroHit(p *core.PPU) bool {
+	status, _ := p.Read("cpu", 0x2002, false)
+	return status&0b01000000 != 0
+}
+
+func isSpriteOverflow(p *core.PPU) bool {
+	status, _ := p.Read("cpu", 0x2002, false)
+	return status&0b00100000 != 0
+}
+
+func getSpriteColor(p *core.PPU, palette uint8, pixel uint8) *core.PPUColor {
+	return p.GetColorFromPalette(palette+4, pixel)
+}
+
+func getBackdropColor(p *core.PPU) *core.PPUColor {
+	return p.GetColorFromPalette(0, 0)
+}
+
+func TestPPUSpriteLimit(t *testing.T) {
+	a := assert.New(t)
+	p := createTestPPU(t)
+	writeSolidTile(p, 0, 1, 1)
+
+	// 9 sprites on lines 21-28, each with different palette than its neighbour
+	var sprites [][4]uint8
+	for i := uint8(0); i < 9; i++ {
+		sprites = append(sprites, [4]uint8{20, 1, i % 4, i * 16})
+	}
+
+	writeSprites(p, sprites...)
+	startRendering(p, 0x00, ppuMaskShowAll)
+	screen := renderFrame(p)
+
+	for y := 21; y <= 28; y++ {
+		for i := 0; i < 8; i++ {
+			a.Equal(getSpriteColor(p, uint8(i%4), 1), screen[y][i*16], "Sprite %d should be drawn on line %d", i, y)
+		}
+
+		a.Equal(getBackdropColor(p), screen[y][8*16], "Only 8 sprites should be drawn on line %d", y)
+	}
+
+	a.Equal(getBackdropColor(p), screen[20][0], "Sprite should be drawn on line below its Y")
+	a.Equal(getBackdropColor(p), screen[29][0])
+}
+
+func TestPPU8x16Sprites(t *testing.T) {
+	a := assert.New(t)
+	p := createTestPPU(t)
+
+	// Pattern table is selected by bit 0 of tile id, bottom half uses next tile
+	writeSolidTile(p, 0, 4, 1)
+	writeSolidTile(p, 0, 5, 2)
+	writeSolidTile(p, 1, 4, 3)
+	writeSolidTile(p, 1, 5, 1)
+
+	writeSprites(p,
+		[4]uint8{30, 0x04, 0, 16},
+		[4]uint8{30, 0x05, 0, 32},
+		[4]uint8{30, 0x04, 0b10000000, 48},
+	)
+
+	// Sprite pattern table bit is ignored for 8x16 sprites
+	startRendering(p, 0b00101000, ppuMaskShowAll)
+	screen := renderFrame(p)
+
+	for y := 31; y <= 38; y++ {
+		a.Equal(getSpriteColor(p, 0, 1), screen[y][16], "Top half of tile $04 should use tile 4 of first table")
+		a.Equal(getSpriteColor(p, 0, 3), screen[y][32], "Top half of tile $05 should use tile 4 of second table")
+		a.Equal(getSpriteColor(p, 0, 2), screen[y][48], "Vertical flip should swap halves")
+	}
+
+	for y := 39; y <= 46; y++ {
+		a.Equal(getSpriteColor(p, 0, 2), screen[y][16], "Bottom half of tile $04 should use tile 5 of first table")
+		a.Equal(getSpriteColor(p, 0, 1), screen[y][32], "Bottom half of tile $05 should use tile 5 of second table")
+		a.Equal(getSpriteColor(p, 0, 1), screen[y][48], "Vertical flip should swap halves")
+	}
+
+	a.Equal(getBackdropColor(p), screen[47][16], "8x16 sprite should be 16 lines high")
+}
+
+func TestPPUSpriteFlip(t *testing.T) {
+	a := assert.New(t)
+	p := createTestPPU(t)
+
+	// Single pixel in top left corner
+	writeVRam(p, 0x0020, 0x80)
+
+	attributes := []uint8{0b00000000, 0b01000000, 0b10000000, 0b11000000}
+	var sprites [][4]uint8
+	for i, attr := range attributes {
+		sprites = append(sprites, [4]uint8{40, 2, attr, uint8(16 + i*16)})
+	}
+
+	writeSprites(p, sprites...)
+	startRendering(p, 0x00, ppuMaskShowAll)
+	screen := renderFrame(p)
+
+	expected := [][2]int{{0, 0}, {7, 0}, {0, 7}, {7, 7}}
+	for i := range attributes {
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				color := getBackdropColor(p)
+				if expected[i][0] == x && expected[i][1] == y {
+					color = getSpriteColor(p, 0, 1)
+				}
+
+				a.Equal(color, screen[41+y][16+i*16+x], "Sprite %d pixel at %d,%d", i, x, y)
+			}
+		}
+	}
+}
+
+func TestPPUSpritesNotOnFirstLine(t *testing.T) {
+	a := assert.New(t)
+	p := createTestPPU(t)
+	writeSolidTile(p, 0, 1, 1)
+	writeSolidTile(p, 0, 0xFF, 1)
+
+	// Sprite on the last line is evaluated for the line after it, empty secondary OAM slots use tile $FF
+	writeSprites(p, [4]uint8{238, 1, 0, 0}, [4]uint8{0, 1, 0, 16})
+	startRendering(p, 0x00, ppuMaskShowAll)
+	renderFrame(p)
+	screen := renderFrame(p)
+
+	a.Equal(getSpriteColor(p, 0, 1), screen[239][0], "Sprite should be drawn on the last line")
+
+	for x := 0; x < 256; x++ {
+		a.Equal(getBackdropColor(p), screen[0][x], "Sprites should not be drawn on the first line")
+	}
+
+	a.Equal(getSpriteColor(p, 0, 1), screen[1][16], "Sprite with Y=0 should be drawn from the second line")
+}
+
+func TestPPUSpritePriority(t *testing.T) {
+	a := assert.New(t)
+	p := createTestPPU(t)
+	writeSolidTile(p, 0, 1, 1)
+
+	// Left half of background tile is opaque
+	writeTile(p, 0, 3, 0x00, 0xF0)
+	writeNameTable(p, 2, 4, 3)
+	writeNameTable(p, 4, 4, 3)
+	writeNameTable(p, 6, 4, 3)
+	writeNameTable(p, 8, 4, 3)
+
+	writeSprites(p,
+		// In front of background
+		[4]uint8{31, 1, 0b00000001, 16},
+		// Behind background
+		[4]uint8{31, 1, 0b00100010, 32},
+		// Behind background, hides following sprite in front of it
+		[4]uint8{31, 1, 0b00100011, 48},
+		[4]uint8{31, 1, 0b00000000, 48},
+	)
+
+	startRendering(p, 0x00, ppuMaskShowAll)
+	screen := renderFrame(p)
+	bgColor := p.GetColorFromPalette(0, 2)
+
+	for y := 32; y < 40; y++ {
+		for x := 0; x < 4; x++ {
+			a.Equal(getSpriteColor(p, 1, 1), screen[y][16+x], "Sprite in front should cover background")
+			a.Equal(bgColor, screen[y][32+x], "Background should cover sprite behind it")
+			a.Equal(bgColor, screen[y][48+x], "Background should cover sprite behind it and sprites after it")
+		}
+
+		for x := 4; x < 8; x++ {
+			a.Equal(getSpriteColor(p, 1, 1), screen[y][16+x], "Sprite should be drawn over transparent background")
+			a.Equal(getSpriteColor(p, 2, 1), screen[y][32+x], "Sprite behind should be drawn over transparent background")
+			a.Equal(getSpriteColor(p, 3, 1), screen[y][48+x], "Sprite earlier in OAM should have priority")
+		}
+	}
+
+	a.Equal(bgColor, screen[32][64], "Background should be drawn without sprites")
+	a.Equal(getBackdropColor(p), screen[32][68])
+}
+
+func TestPPUSpriteZeroHit(t *testing.T) {
+	a := assert.New(t)
+	p := createTestPPU(t)
+	writeSolidTile(p, 0, 1, 1)
+	writeNameTable(p, 2, 4, 1)
+
+	// Sprite 0 overlaps background on lines 32-39 from x=20, sprite 1 overlaps it from x=16
+	writeSprites(p, [4]uint8{31, 1, 0, 20}, [4]uint8{31, 1, 0, 16})
+	startRendering(p, 0x00, ppuMaskShowAll)
+
+	runUntil(p, 32, 21)
+	a.False(isSpriteZeroHit(p), "Other sprites should not set sprite 0 hit")
+
+	p.Clock()
+	a.True(isSpriteZeroHit(p), "Sprite 0 hit should be set on first overlapping pixel")
+
+	runUntil(p, -1, 1)
+	a.True(isSpriteZeroHit(p), "Sprite 0 hit should stay set until pre-render line")
+
+	p.Clock()
+	a.False(isSpriteZeroHit(p), "Sprite 0 hit should be cleared at dot 1 of pre-render line")
+
+	// Sprite behind background still sets the flag
+	writeSprites(p, [4]uint8{31, 1, 0b00100000, 20})
+	runUntil(p, 240, 0)
+	a.True(isSpriteZeroHit(p), "Sprite 0 hit should not depend on sprite priority")
+
+	// Transparent background pixels don't set the flag
+	runUntil(p, -1, 2)
+	writeSprites(p, [4]uint8{31, 1, 0, 24})
+	runUntil(p, 240, 0)
+	a.False(isSpriteZeroHit(p), "Sprite 0 hit should need opaque background pixel")
+}
+
+func TestPPUSpriteZeroHitEdges(t *testing.T) {
+	a := assert.New(t)
+	p := createTestPPU(t)
+	writeSolidTile(p, 0, 1, 1)
+	writeNameTable(p, 0, 4, 1)
+	writeNameTable(p, 1, 4, 1)
+	writeNameTable(p, 31, 4, 1)
+
+	renderWithSprite := func(mask uint8, sprite [4]uint8) bool {
+		writeSprites(p, sprite)
+		startRendering(p, 0x00, mask)
+		runUntil(p, 240, 0)
+		hit := isSpriteZeroHit(p)
+		runUntil(p, -1, 2)
+
+		return hit
+	}
+
+	a.True(renderWithSprite(ppuMaskShowAll, [4]uint8{31, 1, 0, 254}), "Sprite 0 hit should happen at x=254")
+	a.False(renderWithSprite(ppuMaskShowAll, [4]uint8{31, 1, 0, 255}), "Sprite 0 hit should not happen at x=255")
+
+	a.True(renderWithSprite(ppuMaskShowAll, [4]uint8{31, 1, 0, 0}), "Sprite 0 hit should happen in left 8 pixels")
+	a.False(renderWithSprite(ppuMaskClipLeft, [4]uint8{31, 1, 0, 0}), "Clipped pixels should not set sprite 0 hit")
+	a.False(renderWithSprite(ppuMaskClipLeft|0b00000010, [4]uint8{31, 1, 0, 0}), "Clipped sprite should not set sprite 0 hit")
+	a.False(renderWithSprite(ppuMaskClipLeft|0b00000100, [4]uint8{31, 1, 0, 0}), "Clipped background should not set sprite 0 hit")
+	a.True(renderWithSprite(ppuMaskClipLeft, [4]uint8{31, 1, 0, 1}), "Sprite 0 hit should happen at x=8 when left pixels are clipped")
+}
+
+// Renders frame with given sprites and returns sprite overflow flag at the end of visible lines
+func renderWithOverflow(p *core.PPU, sprites ...[4]uint8) bool {
+	runUntil(p, -1, 2)
+	writeSprites(p, sprites...)
+	runUntil(p, 240, 0)
+	overflow := isSpriteOverflow(p)
+	runUntil(p, -1, 2)
+
+	return overflow
+}
+
+func TestPPUSpriteOverflow(t *testing.T) {
+	a := assert.New(t)
+	p := createTestPPU(t)
+	startRendering(p, 0x00, ppuMaskShowAll)
+
+	var sprites [][4]uint8
+	for i := uint8(0); i < 8; i++ {
+		sprites = append(sprites, [4]uint8{100, 0, 0, i * 8})
+	}
+
+	a.False(renderWithOverflow(p, sprites...), "8 sprites on line should not set overflow")
+
+	// Sprites don't need to be on the same line
+	a.True(renderWithOverflow(p, append(sprites, [4]uint8{107, 0, 0, 0})...), "9th sprite in range should set overflow")
+	a.False(renderWithOverflow(p, append(sprites, [4]uint8{108, 0, 0, 0})...), "9th sprite out of range should not set overflow")
+
+	writeSprites(p, append(sprites, [4]uint8{100, 0, 0, 0})...)
+	runUntil(p, -1, 1)
+	a.True(isSpriteOverflow(p), "Overflow should stay set until pre-render line")
+	p.Clock()
+	a.False(isSpriteOverflow(p), "Overflow should be cleared at dot 1 of pre-render line")
+
+	// 8x16 sprites are in range for 16 lines
+	p.Write("cpu", 0x2000, 0b00100000, false)
+	a.True(renderWithOverflow(p, append(sprites, [4]uint8{115, 0, 0, 0})...), "9th 8x16 sprite in range should set overflow")
+}
+
+// After 8 sprites are found, hardware increments byte index together with sprite index when sprite is not in
+// range and checks other bytes of following sprites as Y coordinates
+func TestPPUSpriteOverflowBug(t *testing.T) {
+	a := assert.New(t)
+	p := createTestPPU(t)
+	startRendering(p, 0x00, ppuMaskShowAll)
+
+	var sprites [][4]uint8
+	for i := uint8(0); i < 8; i++ {
+		sprites = append(sprites, [4]uint8{100, 0, 0, i * 8})
+	}
+
+	// Sprite 9 tile id is checked as its Y
+	a.True(renderWithOverflow(p, append(sprites, [4]uint8{0xFF, 0, 0, 0}, [4]uint8{0xFF, 100, 0, 0})...),
+		"Tile id in range should set overflow")
+
+	// Sprite 9 is in range, but only its tile id is checked
+	a.False(renderWithOverflow(p, append(sprites, [4]uint8{0xFF, 0, 0, 0}, [4]uint8{100, 0xFF, 0, 0})...),
+		"Sprite in range should be missed")
+
+	// Sprite 10 attribute byte is checked after sprite 9 tile id
+	a.True(renderWithOverflow(p, append(sprites, [4]uint8{0xFF, 0, 0, 0}, [4]uint8{0xFF, 0xFF, 0, 0}, [4]uint8{0xFF, 0xFF, 99, 0})...),
+		"Attribute byte in range should set overflow")
+
+	// Byte index wraps around and sprite 12 Y is checked
+	a.True(renderWithOverflow(p, append(sprites,
+		[4]uint8{0xFF, 0, 0, 0}, [4]uint8{0xFF, 0xFF, 0, 0}, [4]uint8{0xFF, 0xFF, 0xFF, 0}, [4]uint8{0xFF, 0xFF, 0xFF, 0xFF},
+		[4]uint8{100, 0xFF, 0xFF, 0xFF})...),
+		"Y of sprite checked after byte index wraps should set overflow")
+}
@@ -1,5 +1,7 @@
 package core
 
+import "github.com/szymonkups/nesgo/core/state"
+
 type Ram struct {
 	data [0x2000]uint8
 }
@@ -25,3 +27,11 @@ func (ram *Ram) Write(_ string, addr uint16, data uint8, _ bool) bool {
 
 	return false
 }
+
+func (ram *Ram) Serialize(w *state.Writer) {
+	w.Bytes(ram.data[:0x0800])
+}
+
+func (ram *Ram) Deserialize(r *state.Reader) {
+	r.Bytes(ram.data[:0x0800])
+}
@@ -0,0 +1,202 @@
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Every snapshot stored in full is followed by this many snapshots stored as differences to it
+const rewindKeyframeInterval = 30
+
+// Changed bytes separated by shorter runs of unchanged ones are stored together in delta
+const rewindMinUnchangedRun = 4
+
+// ErrRewindBufferEmpty - there are no more snapshots to go back to
+var ErrRewindBufferEmpty = errors.New("rewind buffer is empty")
+
+// RewindBuffer - ring buffer of system snapshots captured every few frames. Snapshots are grouped, each group starts
+// with a keyframe followed by deltas against it, so the oldest group is dropped as a whole when memory budget is
+// exceeded.
+type RewindBuffer struct {
+	saver *StateSaver
+
+	// Frames between snapshots and maximal size of stored snapshots in bytes
+	interval int
+	budget   int
+
+	groups []*rewindGroup
+	size   int
+
+	// Frames since last snapshot
+	frames int
+}
+
+type rewindGroup struct {
+	keyframe []uint8
+	deltas   [][]uint8
+}
+
+func NewRewindBuffer(saver *StateSaver, interval int, budget int) *RewindBuffer {
+	if interval < 1 {
+		interval = 1
+	}
+
+	return &RewindBuffer{saver: saver, interval: interval, budget: budget}
+}
+
+// OnFrame - should be called after every emulated frame, captures snapshot every interval frames
+func (rb *RewindBuffer) OnFrame() {
+	rb.frames++
+
+	if rb.frames < rb.interval {
+		return
+	}
+
+	rb.frames = 0
+	rb.Capture()
+}
+
+// Capture - stores snapshot of current system state
+func (rb *RewindBuffer) Capture() {
+	data := rb.saver.Serialize()
+	last := rb.getLastGroup()
+
+	if last == nil || len(last.deltas) >= rewindKeyframeInterval || len(last.keyframe) != len(data) {
+		rb.groups = append(rb.groups, &rewindGroup{keyframe: data})
+		rb.size += len(data)
+	} else {
+		delta := encodeDelta(last.keyframe, data)
+		last.deltas = append(last.deltas, delta)
+		rb.size += len(delta)
+	}
+
+	// Newest group is always kept, even if it alone doesn't fit
+	for rb.size > rb.budget && len(rb.groups) > 1 {
+		rb.size -= rb.groups[0].getSize()
+		rb.groups[0] = nil
+		rb.groups = rb.groups[1:]
+	}
+}
+
+// Rewind - restores the newest snapshot and removes it from the buffer
+func (rb *RewindBuffer) Rewind() error {
+	last := rb.getLastGroup()
+
+	if last == nil {
+		return ErrRewindBufferEmpty
+	}
+
+	var data []uint8
+
+	if n := len(last.deltas); n > 0 {
+		data = decodeDelta(last.keyframe, last.deltas[n-1])
+		rb.size -= len(last.deltas[n-1])
+		last.deltas = last.deltas[:n-1]
+	} else {
+		data = last.keyframe
+		rb.size -= len(last.keyframe)
+		rb.groups = rb.groups[:len(rb.groups)-1]
+	}
+
+	rb.frames = 0
+	return rb.saver.Deserialize(data)
+}
+
+// Len - number of stored snapshots
+func (rb *RewindBuffer) Len() int {
+	count := 0
+
+	for _, group := range rb.groups {
+		count += len(group.deltas) + 1
+	}
+
+	return count
+}
+
+// Size - memory used by stored snapshots in bytes
+func (rb *RewindBuffer) Size() int {
+	return rb.size
+}
+
+func (rb *RewindBuffer) getLastGroup() *rewindGroup {
+	if len(rb.groups) == 0 {
+		return nil
+	}
+
+	return rb.groups[len(rb.groups)-1]
+}
+
+func (group *rewindGroup) getSize() int {
+	size := len(group.keyframe)
+
+	for _, delta := range group.deltas {
+		size += len(delta)
+	}
+
+	return size
+}
+
+// Delta is a sequence of unchanged byte count, changed byte count and changed bytes, counts are varints.
+// Data has to be of the same size as keyframe.
+func encodeDelta(keyframe []uint8, data []uint8) []uint8 {
+	var delta []uint8
+	buf := make([]uint8, binary.MaxVarintLen64)
+
+	for i := 0; i < len(data); {
+		start := i
+		for i < len(data) && data[i] == keyframe[i] {
+			i++
+		}
+
+		unchanged := i - start
+		start = i
+
+		for i < len(data) && !isUnchangedRun(keyframe[i:], data[i:]) {
+			i++
+		}
+
+		// Trailing unchanged bytes don't need to be stored
+		if i == start {
+			break
+		}
+
+		delta = append(delta, buf[:binary.PutUvarint(buf, uint64(unchanged))]...)
+		delta = append(delta, buf[:binary.PutUvarint(buf, uint64(i-start))]...)
+		delta = append(delta, data[start:i]...)
+	}
+
+	return delta
+}
+
+func isUnchangedRun(keyframe []uint8, data []uint8) bool {
+	n := rewindMinUnchangedRun
+	if n > len(data) {
+		n = len(data)
+	}
+
+	for i := 0; i < n; i++ {
+		if data[i] != keyframe[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func decodeDelta(keyframe []uint8, delta []uint8) []uint8 {
+	data := append([]uint8{}, keyframe...)
+	offset := 0
+
+	for len(delta) > 0 {
+		unchanged, n := binary.Uvarint(delta)
+		delta = delta[n:]
+		changed, n := binary.Uvarint(delta)
+		delta = delta[n:]
+
+		offset += int(unchanged)
+		offset += copy(data[offset:], delta[:changed])
+		delta = delta[changed:]
+	}
+
+	return data
+}
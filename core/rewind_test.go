@@ -0,0 +1,56 @@
+package core_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core"
+	"testing"
+)
+
+func TestRewindRestoresSnapshotsInReverse(t *testing.T) {
+	a := assert.New(t)
+	s := createTestSystem(t, countingProgram)
+	rb := core.NewRewindBuffer(s.saver, 2, 1<<20)
+
+	var expected [][]uint8
+	for frame := 0; frame < 80; frame++ {
+		s.runFrames(1)
+		rb.OnFrame()
+
+		if frame%2 == 1 {
+			expected = append(expected, s.saver.Serialize())
+		}
+	}
+
+	a.Equal(40, rb.Len(), "Snapshot should be captured every 2 frames")
+	a.Less(rb.Size(), 40*len(expected[0])/4, "Snapshots should be stored as deltas")
+
+	for i := len(expected) - 1; i >= 0; i-- {
+		a.NoError(rb.Rewind())
+		a.Equal(expected[i], s.saver.Serialize(), "Snapshot %d should be restored", i)
+	}
+
+	a.Equal(core.ErrRewindBufferEmpty, rb.Rewind())
+	a.Equal(0, rb.Size())
+}
+
+func TestRewindMemoryBudget(t *testing.T) {
+	a := assert.New(t)
+	s := createTestSystem(t, countingProgram)
+	snapshotSize := len(s.saver.Serialize())
+	rb := core.NewRewindBuffer(s.saver, 1, snapshotSize*3)
+
+	for frame := 0; frame < 200; frame++ {
+		s.runFrames(1)
+		rb.OnFrame()
+	}
+
+	a.LessOrEqual(rb.Size(), snapshotSize*3, "Buffer should not exceed memory budget")
+	a.Less(rb.Len(), 200, "Oldest snapshots should be dropped")
+	a.Greater(rb.Len(), 30, "At least one group of snapshots should be kept")
+
+	// Dropping groups leaves the oldest remaining snapshot decodable
+	for rb.Rewind() == nil {
+	}
+
+	a.Equal(0, rb.Len())
+}
@@ -0,0 +1,277 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Header formats, archaic iNES files have bytes 7-15 filled with garbage, so only the most basic fields are used
+// https://wiki.nesdev.com/w/index.php/INES#Variant_comparison
+const (
+	RomFormatINES = iota
+	RomFormatArchaicINES
+	RomFormatNES20
+)
+
+// Console types
+const (
+	ConsoleNES = iota
+	ConsoleVsSystem
+	ConsolePlayChoice
+	ConsoleExtended
+)
+
+// CPU/PPU timing modes
+const (
+	TimingNTSC = iota
+	TimingPAL
+	TimingMultiRegion
+	TimingDendy
+)
+
+// Default expansion devices, only the ones emulator cares about are listed
+// https://wiki.nesdev.com/w/index.php/NES_2.0#Default_Expansion_Device
+const (
+	ExpansionDeviceUnspecified      = 0x00
+	ExpansionDeviceStandard         = 0x01
+	ExpansionDeviceFourScore        = 0x02
+	ExpansionDeviceFamicomFourScore = 0x03
+	ExpansionDeviceZapper           = 0x08
+	ExpansionDeviceArkanoidNES      = 0x0F
+	ExpansionDeviceArkanoidFamicom  = 0x10
+)
+
+// Sizes declared in header above this limit are rejected, largest existing ROMs are a few megabytes
+const maxRomSize = 64 * 1024 * 1024
+
+// Errors describing invalid ROM files
+var (
+	ErrInvalidMagic = errors.New("file has no iNES header")
+	ErrNoPrgRom     = errors.New("header declares no PRG ROM")
+	ErrRomTooLarge  = errors.New("header declares ROM larger than 64MB")
+)
+
+// TruncatedRomError - file ends before all data declared in header
+type TruncatedRomError struct {
+	Section  string
+	Expected int
+	Actual   int
+}
+
+func (err *TruncatedRomError) Error() string {
+	return fmt.Sprintf("file is truncated, %s has %d of %d bytes", err.Section, err.Actual, err.Expected)
+}
+
+// UnsupportedMapperError - file is valid, but emulator doesn't have its mapper
+type UnsupportedMapperError struct {
+	Mapper uint16
+}
+
+func (err *UnsupportedMapperError) Error() string {
+	return fmt.Sprintf("mapper %d not supported, yet", err.Mapper)
+}
+
+// https://wiki.nesdev.com/w/index.php/INES
+// https://wiki.nesdev.com/w/index.php/NES_2.0
+type fileHeader struct {
+	Name        [4]uint8
+	PrgRomBanks uint8
+	ChrRomBanks uint8
+	Flags6      uint8
+	Flags7      uint8
+	Flags8      uint8
+	Flags9      uint8
+	Flags10     uint8
+	Flags11     uint8
+	Flags12     uint8
+	Flags13     uint8
+	Flags14     uint8
+	Flags15     uint8
+}
+
+// RomInfo - cartridge metadata read from iNES or NES 2.0 file header, sizes are in bytes
+type RomInfo struct {
+	Format uint8
+
+	// Bytes 7-15 were overwritten with "DiskDude!" by old dumping tool, they are ignored
+	IsDiskDude bool
+
+	Mapper    uint16
+	Submapper uint8
+
+	PrgRomSize int
+	ChrRomSize int
+
+	// RAM sizes are known only from NES 2.0 header
+	PrgRamSize   int
+	PrgNvRamSize int
+	ChrRamSize   int
+	ChrNvRamSize int
+
+	Mirroring    uint8
+	IsFourScreen bool
+	HasBattery   bool
+	HasTrainer   bool
+
+	ConsoleType uint8
+	Timing      uint8
+
+	// Vs. System PPU and hardware type, used with ConsoleVsSystem
+	VsPPUType      uint8
+	VsHardwareType uint8
+
+	// Used with ConsoleExtended
+	ExtendedConsoleType uint8
+
+	MiscRoms        uint8
+	ExpansionDevice uint8
+}
+
+func parseHeader(header *fileHeader) RomInfo {
+	info := RomInfo{
+		Format:       getRomFormat(header),
+		Mapper:       uint16(header.Flags6 >> 4),
+		Mirroring:    MirroringHorizontal,
+		IsFourScreen: header.Flags6&0b00001000 != 0,
+		HasBattery:   header.Flags6&0b00000010 != 0,
+		HasTrainer:   header.Flags6&0b00000100 != 0,
+	}
+
+	if header.Flags6&0b00000001 != 0 {
+		info.Mirroring = MirroringVertical
+	}
+
+	switch info.Format {
+	case RomFormatArchaicINES:
+		info.IsDiskDude = string(header.getTail()) == "DiskDude!"
+		info.PrgRomSize = int(header.PrgRomBanks) * 0x4000
+		info.ChrRomSize = int(header.ChrRomBanks) * 0x2000
+
+		return info
+
+	case RomFormatINES:
+		info.Mapper |= uint16(header.Flags7 & 0xF0)
+		info.PrgRomSize = int(header.PrgRomBanks) * 0x4000
+		info.ChrRomSize = int(header.ChrRomBanks) * 0x2000
+
+		switch {
+		case header.Flags7&0b00000001 != 0:
+			info.ConsoleType = ConsoleVsSystem
+		case header.Flags7&0b00000010 != 0:
+			info.ConsoleType = ConsolePlayChoice
+		}
+
+		if header.Flags9&0b00000001 != 0 {
+			info.Timing = TimingPAL
+		}
+
+		return info
+	}
+
+	// Mapper number is extended by 4 bits, the other half of byte 8 is submapper
+	info.Mapper |= uint16(header.Flags7&0xF0) | uint16(header.Flags8&0x0F)<<8
+	info.Submapper = header.Flags8 >> 4
+
+	info.PrgRomSize = getRomSize(header.PrgRomBanks, header.Flags9&0x0F, 0x4000)
+	info.ChrRomSize = getRomSize(header.ChrRomBanks, header.Flags9>>4, 0x2000)
+
+	info.PrgRamSize = getRamSize(header.Flags10 & 0x0F)
+	info.PrgNvRamSize = getRamSize(header.Flags10 >> 4)
+	info.ChrRamSize = getRamSize(header.Flags11 & 0x0F)
+	info.ChrNvRamSize = getRamSize(header.Flags11 >> 4)
+
+	info.ConsoleType = header.Flags7 & 0b11
+	info.Timing = header.Flags12 & 0b11
+
+	switch info.ConsoleType {
+	case ConsoleVsSystem:
+		info.VsPPUType = header.Flags13 & 0x0F
+		info.VsHardwareType = header.Flags13 >> 4
+	case ConsoleExtended:
+		info.ExtendedConsoleType = header.Flags13 & 0x0F
+	}
+
+	info.MiscRoms = header.Flags14 & 0b11
+	info.ExpansionDevice = header.Flags15 & 0b00111111
+
+	return info
+}
+
+// Bytes 7-15 of the header
+func (header *fileHeader) getTail() []uint8 {
+	return []uint8{
+		header.Flags7, header.Flags8, header.Flags9, header.Flags10, header.Flags11,
+		header.Flags12, header.Flags13, header.Flags14, header.Flags15,
+	}
+}
+
+// NES 2.0 is identified by bits 2-3 of byte 7, archaic iNES files have something else there or in unused bytes 12-15
+func getRomFormat(header *fileHeader) uint8 {
+	switch {
+	case header.Flags7&0b00001100 == 0b00001000:
+		return RomFormatNES20
+	case header.Flags7&0b00001100 == 0 && header.Flags12|header.Flags13|header.Flags14|header.Flags15 == 0:
+		return RomFormatINES
+	default:
+		return RomFormatArchaicINES
+	}
+}
+
+// ROM size in NES 2.0 header is given in units, or as exponent and multiplier when most significant nibble is $F
+func getRomSize(lsb uint8, msb uint8, unit int) int {
+	if msb == 0x0F {
+		exponent := uint(lsb >> 2)
+		multiplier := uint64(lsb&0b11)*2 + 1
+
+		// Sizes above the limit are rejected anyway, they are reported as just above it, so int can't overflow
+		// even when it has 32 bits
+		if exponent >= 32 || multiplier<<exponent > maxRomSize {
+			return maxRomSize + 1
+		}
+
+		return int(multiplier << exponent)
+	}
+
+	return (int(msb)<<8 | int(lsb)) * unit
+}
+
+// RAM size in NES 2.0 header is a shift count, 0 means there is no RAM
+func getRamSize(shift uint8) int {
+	if shift == 0 {
+		return 0
+	}
+
+	return 64 << shift
+}
+
+// Reads part of the file, reporting short reads as TruncatedRomError
+func readRomSection(r io.Reader, section string, size int) ([]uint8, error) {
+	data := make([]uint8, size)
+	n, err := io.ReadFull(r, data)
+
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, &TruncatedRomError{Section: section, Expected: size, Actual: n}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Repeats ROM data to fill whole banks, the same way smaller ROMs are mirrored on real boards
+func padRom(data []uint8, bankSize int) []uint8 {
+	if len(data)%bankSize == 0 {
+		return data
+	}
+
+	padded := make([]uint8, (len(data)/bankSize+1)*bankSize)
+
+	for i := range padded {
+		padded[i] = data[i%len(data)]
+	}
+
+	return padded
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// HasSaveRAM - true when loaded cartridge has battery backed RAM or EEPROM which should be persisted
+func (crt *Cartridge) HasSaveRAM() bool {
+	return crt.saveRam != nil && crt.saveRam.Size() > 0
+}
+
+// LoadSaveRAM - restores battery backed memory from a .sav file, missing file is not an error
+func (crt *Cartridge) LoadSaveRAM(fileName string) error {
+	if !crt.HasSaveRAM() {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(fileName)
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	crt.saveRam.SetData(data)
+	crt.savedData = crt.saveRam.GetData()
+
+	return nil
+}
+
+// FlushSaveRAM - writes battery backed memory to a .sav file, file is written only when memory has changed since
+// last load or flush. File is replaced atomically, so crash in the middle of writing leaves previous save intact.
+func (crt *Cartridge) FlushSaveRAM(fileName string) error {
+	if !crt.HasSaveRAM() {
+		return nil
+	}
+
+	data := crt.saveRam.GetData()
+
+	if bytes.Equal(data, crt.savedData) {
+		return nil
+	}
+
+	err := writeFileAtomic(fileName, data)
+
+	if err != nil {
+		return err
+	}
+
+	crt.savedData = data
+	return nil
+}
+
+// Writes data to a temporary file in the same directory and renames it over the target. Directory is created when
+// it's missing, so it exists only when something was saved.
+func writeFileAtomic(fileName string, data []uint8) error {
+	err := os.MkdirAll(filepath.Dir(fileName), 0755)
+
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(fileName), filepath.Base(fileName)+".tmp")
+
+	if err != nil {
+		return err
+	}
+
+	// Does nothing once file is renamed
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(data)
+
+	if err == nil {
+		err = f.Sync()
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(f.Name(), 0644)
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), fileName)
+}
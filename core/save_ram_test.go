@@ -0,0 +1,63 @@
+package core_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCartridgeSaveRAM(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "nesgo-saves")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+
+	// Missing directory is created on first write
+	saveFile := filepath.Join(dir, "saves", "game.sav")
+	rom := createTestRom([]uint8{'N', 'E', 'S', 0x1A, 1, 1, 0x02, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 0x4000, 0x2000)
+
+	crt := new(core.Cartridge)
+	a.NoError(crt.LoadBytes(rom))
+	a.True(crt.HasSaveRAM(), "Battery backed PRG RAM should be saved")
+	a.NoError(crt.LoadSaveRAM(saveFile), "Missing save file should not be an error")
+
+	a.NoError(crt.FlushSaveRAM(saveFile))
+	_, err = os.Stat(saveFile)
+	a.True(os.IsNotExist(err), "Unchanged memory should not be written")
+
+	crt.Write("cpu", 0x6010, 0x42, false)
+	a.NoError(crt.FlushSaveRAM(saveFile))
+
+	files, _ := ioutil.ReadDir(filepath.Dir(saveFile))
+	a.Len(files, 1, "Temporary file should be renamed to save file")
+
+	data, err := ioutil.ReadFile(saveFile)
+	a.NoError(err)
+	a.Len(data, 0x2000)
+	a.Equal(uint8(0x42), data[0x10])
+
+	crt = new(core.Cartridge)
+	a.NoError(crt.LoadBytes(rom))
+	a.NoError(crt.LoadSaveRAM(saveFile))
+
+	value, _ := crt.Read("cpu", 0x6010, true)
+	a.Equal(uint8(0x42), value, "PRG RAM should be restored from save file")
+}
+
+func TestCartridgeWithoutBattery(t *testing.T) {
+	a := assert.New(t)
+	crt := new(core.Cartridge)
+	a.NoError(crt.LoadBytes(createTestRom([]uint8{'N', 'E', 'S', 0x1A, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 0x4000, 0x2000)))
+
+	a.False(crt.HasSaveRAM(), "PRG RAM without battery should not be saved")
+
+	saveDir := filepath.Join(os.TempDir(), "nesgo-missing")
+	crt.Write("cpu", 0x6010, 0x42, false)
+	a.NoError(crt.FlushSaveRAM(filepath.Join(saveDir, "game.sav")))
+
+	_, err := os.Stat(saveDir)
+	a.True(os.IsNotExist(err), "Save directory should not be created when there is nothing to save")
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/szymonkups/nesgo/core/state"
+	"hash/crc32"
+	"io/ioutil"
+)
+
+// SaveStateVersion - version of save state format, it has to be increased whenever any component changes
+// what it serializes
+const SaveStateVersion = 3
+
+var saveStateMagic = [4]uint8{'N', 'E', 'S', 'S'}
+
+// Errors describing save states which cannot be loaded
+var (
+	ErrInvalidSaveState   = errors.New("file is not a save state")
+	ErrSaveStateChecksum  = errors.New("save state is damaged")
+	ErrSaveStateOtherGame = errors.New("save state was made with a different game")
+
+	// Devices plugged into input ports keep different state, so state has to be loaded with the same ones
+	ErrSaveStateInputDevices = errors.New("save state was made with different input devices")
+)
+
+// SaveStateVersionError - save state was made by incompatible version of emulator
+type SaveStateVersionError struct {
+	Version uint16
+}
+
+func (err *SaveStateVersionError) Error() string {
+	return fmt.Sprintf("save state version %d is not supported, expected version %d", err.Version, SaveStateVersion)
+}
+
+// Serializable - component which is a part of save state
+type Serializable interface {
+	Serialize(w *state.Writer)
+	Deserialize(r *state.Reader)
+}
+
+// Save state file starts with this header, followed by state of all components
+type saveStateHeader struct {
+	Magic    [4]uint8
+	Version  uint16
+	RomHash  [sha1.Size]uint8
+	Size     uint32
+	Checksum uint32
+}
+
+// StateSaver - saves and restores state of the whole system
+type StateSaver struct {
+	crt        *Cartridge
+	components []Serializable
+}
+
+// NewStateSaver - components are serialized in given order after the cartridge, so the order can't change
+// without increasing SaveStateVersion
+func NewStateSaver(crt *Cartridge, components ...Serializable) *StateSaver {
+	return &StateSaver{
+		crt:        crt,
+		components: append([]Serializable{crt}, components...),
+	}
+}
+
+// Serialize - raw state of all components, without header
+func (s *StateSaver) Serialize() []uint8 {
+	w := new(state.Writer)
+
+	for _, component := range s.components {
+		component.Serialize(w)
+	}
+
+	return w.GetData()
+}
+
+// Deserialize - restores state returned by Serialize. When state can't be read, system is left unchanged.
+func (s *StateSaver) Deserialize(data []uint8) error {
+	previous := s.Serialize()
+	err := s.deserialize(data)
+
+	if err != nil {
+		s.deserialize(previous)
+	}
+
+	return err
+}
+
+func (s *StateSaver) deserialize(data []uint8) error {
+	r := state.NewReader(data)
+
+	for _, component := range s.components {
+		component.Deserialize(r)
+	}
+
+	return r.Finish()
+}
+
+// Save - state of all components in versioned save state format, checksummed and bound to the loaded game
+func (s *StateSaver) Save() []uint8 {
+	data := s.Serialize()
+
+	header := saveStateHeader{
+		Magic:    saveStateMagic,
+		Version:  SaveStateVersion,
+		RomHash:  s.crt.GetRomHash(),
+		Size:     uint32(len(data)),
+		Checksum: crc32.ChecksumIEEE(data),
+	}
+
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.LittleEndian, &header)
+	buf.Write(data)
+
+	return buf.Bytes()
+}
+
+// Load - restores state saved by Save. Invalid states are reported with ErrInvalidSaveState, ErrSaveStateChecksum,
+// ErrSaveStateOtherGame or *SaveStateVersionError, system is left unchanged then.
+func (s *StateSaver) Load(data []uint8) error {
+	header := saveStateHeader{}
+	err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &header)
+
+	if err != nil || header.Magic != saveStateMagic {
+		return ErrInvalidSaveState
+	}
+
+	if header.Version != SaveStateVersion {
+		return &SaveStateVersionError{Version: header.Version}
+	}
+
+	if header.RomHash != s.crt.GetRomHash() {
+		return ErrSaveStateOtherGame
+	}
+
+	data = data[binary.Size(header):]
+
+	if uint32(len(data)) != header.Size || crc32.ChecksumIEEE(data) != header.Checksum {
+		return ErrSaveStateChecksum
+	}
+
+	return s.Deserialize(data)
+}
+
+// SaveFile - writes save state to a file, file is replaced atomically
+func (s *StateSaver) SaveFile(fileName string) error {
+	return writeFileAtomic(fileName, s.Save())
+}
+
+// LoadFile - loads save state from a file, see Load
+func (s *StateSaver) LoadFile(fileName string) error {
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		return err
+	}
+
+	return s.Load(data)
+}
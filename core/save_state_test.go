@@ -0,0 +1,179 @@
+package core_test
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core"
+	"github.com/szymonkups/nesgo/core/state"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Console with NROM cartridge running given program from $C000
+type testSystem struct {
+	cpu   *core.CPU
+	ppu   *core.PPU
+	ram   *core.Ram
+	apu   *core.APU
+	ports *core.InputPorts
+	saver *core.StateSaver
+}
+
+func createTestSystem(t *testing.T, program []uint8) *testSystem {
+	rom := createTestRom([]uint8{'N', 'E', 'S', 0x1A, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 0x4000, 0x2000)
+	prg := rom[16 : 16+0x4000]
+	copy(prg, program)
+
+	// Reset vector - $C000
+	prg[0x3FFC] = 0x00
+	prg[0x3FFD] = 0xC0
+
+	cpuBus := core.NewCPUBus()
+	ppuBus := core.NewPPUBus()
+	crt := new(core.Cartridge)
+	assert.NoError(t, crt.LoadBytes(rom))
+
+	s := &testSystem{ppu: core.NewPPU(ppuBus), ram: new(core.Ram)}
+	vRam := core.NewVRam(crt)
+	ports := new(core.InputPorts)
+	ports.Connect(0, new(core.Controller))
+	s.ports = ports
+
+	cpuBus.ConnectDevice(crt)
+	cpuBus.ConnectDevice(s.ram)
+	cpuBus.ConnectDevice(s.ppu)
+	cpuBus.ConnectDevice(ports)
+	ppuBus.ConnectDevice(crt)
+	ppuBus.ConnectDevice(vRam)
+
+	s.cpu = core.NewCPU(cpuBus)
+	s.ppu.ConnectCartridge(crt)
+	crt.ConnectCPU(s.cpu)
+
+	s.apu = core.NewAPU(cpuBus, s.cpu)
+	s.apu.ConnectCartridge(crt)
+	cpuBus.ConnectDevice(core.NewDMA(cpuBus, s.cpu))
+	cpuBus.ConnectDevice(s.apu)
+
+	s.saver = core.NewStateSaver(crt, s.cpu, s.ram, s.ppu, vRam, s.apu, ports)
+
+	return s
+}
+
+func (s *testSystem) runFrames(frames int) {
+	for i := 0; i < frames; i++ {
+		for !s.ppu.IsFrameComplete {
+			s.ppu.Clock()
+
+			if (s.ppu.GetTotalCycles()-1)%3 == 0 {
+				s.cpu.Clock()
+			}
+		}
+
+		s.ppu.IsFrameComplete = false
+	}
+}
+
+func (s *testSystem) readRam(addr uint16) uint8 {
+	data, _ := s.ram.Read("cpu", addr, true)
+	return data
+}
+
+// Program counting in $10 and $6000
+var countingProgram = []uint8{
+	0xE6, 0x10, // INC $10
+	0xA5, 0x10, // LDA $10
+	0x8D, 0x00, 0x60, // STA $6000
+	0x4C, 0x00, 0xC0, // JMP $C000
+}
+
+func TestSaveStateRestoresSystem(t *testing.T) {
+	a := assert.New(t)
+	s := createTestSystem(t, countingProgram)
+	s.runFrames(2)
+
+	saved := s.saver.Save()
+	s.runFrames(3)
+	expectedCounter := s.readRam(0x10)
+	expectedCPU := s.cpu.GetDebugInfo()
+	expectedState := s.saver.Serialize()
+
+	a.NoError(s.saver.Load(saved))
+	a.NotEqual(expectedCounter, s.readRam(0x10), "RAM should be restored")
+
+	s.runFrames(3)
+	a.Equal(expectedCounter, s.readRam(0x10), "Emulation should continue the same way after loading state")
+	a.Equal(expectedCPU, s.cpu.GetDebugInfo())
+	a.Equal(expectedState, s.saver.Serialize(), "Whole system should be in the same state")
+}
+
+func TestSaveStateInvalid(t *testing.T) {
+	a := assert.New(t)
+	s := createTestSystem(t, countingProgram)
+	s.runFrames(1)
+
+	saved := s.saver.Save()
+	current := s.saver.Serialize()
+
+	a.Equal(core.ErrInvalidSaveState, s.saver.Load([]uint8("garbage")))
+
+	damaged := append([]uint8{}, saved...)
+	damaged[len(damaged)-1] ^= 0xFF
+	a.Equal(core.ErrSaveStateChecksum, s.saver.Load(damaged))
+
+	a.Equal(core.ErrSaveStateChecksum, s.saver.Load(saved[:len(saved)-1]), "Truncated state should be rejected")
+
+	newer := append([]uint8{}, saved...)
+	newer[4] = 0xFF
+	var versionErr *core.SaveStateVersionError
+	a.True(errors.As(s.saver.Load(newer), &versionErr))
+
+	a.Equal(current, s.saver.Serialize(), "Rejected states should not change the system")
+
+	other := createTestSystem(t, []uint8{0xEA})
+	a.Equal(core.ErrSaveStateOtherGame, other.saver.Load(saved))
+}
+
+func TestSaveStateInputDevices(t *testing.T) {
+	a := assert.New(t)
+	s := createTestSystem(t, countingProgram)
+	s.runFrames(1)
+
+	saved := s.saver.Save()
+	s.ports.Connect(1, core.NewZapper(s.ppu))
+	current := s.saver.Serialize()
+
+	a.Equal(core.ErrSaveStateInputDevices, s.saver.Load(saved), "State without Zapper should be rejected")
+	a.Equal(current, s.saver.Serialize(), "Rejected state should not change the system")
+
+	withZapper := s.saver.Save()
+	s.ports.Connect(1, nil)
+	a.Equal(core.ErrSaveStateInputDevices, s.saver.Load(withZapper), "State with Zapper should be rejected")
+	a.NoError(s.saver.Load(saved))
+
+	a.Equal(state.ErrTrailingData, s.saver.Deserialize(append(s.saver.Serialize(), 0x00)), "Unread data should be reported")
+}
+
+func TestSaveStateFile(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "nesgo-states")
+	a.NoError(err)
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "game.1.state")
+	s := createTestSystem(t, countingProgram)
+	s.runFrames(1)
+
+	a.NoError(s.saver.SaveFile(fileName))
+	counter := s.readRam(0x10)
+	s.runFrames(1)
+
+	a.NoError(s.saver.LoadFile(fileName))
+	a.Equal(counter, s.readRam(0x10))
+
+	_, err = os.Stat(filepath.Join(dir, "missing.state"))
+	a.True(os.IsNotExist(err))
+	a.True(os.IsNotExist(s.saver.LoadFile(filepath.Join(dir, "missing.state"))))
+}
@@ -0,0 +1,177 @@
+// Package state - binary format of emulator components state used by save states. Components write their fields
+// with Writer and read them back with Reader in the same order, values are stored little endian.
+package state
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Errors reported by Reader
+var (
+	ErrUnexpectedEnd = errors.New("state data ends unexpectedly")
+	ErrSizeMismatch  = errors.New("state memory size doesn't match loaded game")
+	ErrTrailingData  = errors.New("state data continues after all components are read")
+)
+
+// Writer - collects state of components
+type Writer struct {
+	data []uint8
+}
+
+// GetData - state written so far
+func (w *Writer) GetData() []uint8 {
+	return w.data
+}
+
+func (w *Writer) Bool(v bool) {
+	if v {
+		w.Uint8(1)
+	} else {
+		w.Uint8(0)
+	}
+}
+
+func (w *Writer) Uint8(v uint8) {
+	w.data = append(w.data, v)
+}
+
+func (w *Writer) Uint16(v uint16) {
+	w.data = append(w.data, uint8(v), uint8(v>>8))
+}
+
+func (w *Writer) Uint32(v uint32) {
+	w.data = append(w.data, uint8(v), uint8(v>>8), uint8(v>>16), uint8(v>>24))
+}
+
+func (w *Writer) Uint64(v uint64) {
+	w.Uint32(uint32(v))
+	w.Uint32(uint32(v >> 32))
+}
+
+func (w *Writer) Int16(v int16) {
+	w.Uint16(uint16(v))
+}
+
+// Int - stored as 64 bit value regardless of platform
+func (w *Writer) Int(v int) {
+	w.Uint64(uint64(v))
+}
+
+func (w *Writer) Float32(v float32) {
+	w.Uint32(math.Float32bits(v))
+}
+
+// Bytes - memory block preceded by its size, so it can be verified when reading
+func (w *Writer) Bytes(v []uint8) {
+	w.Uint32(uint32(len(v)))
+	w.data = append(w.data, v...)
+}
+
+// Reader - reads state written by Writer. After first error all reads return zero values, error is reported by Err.
+type Reader struct {
+	data []uint8
+	err  error
+}
+
+func NewReader(data []uint8) *Reader {
+	return &Reader{data: data}
+}
+
+// Err - first error which occurred while reading
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Fail - reports error found by component reading its state, only first error is kept
+func (r *Reader) Fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Finish - should be called when all state is read, reports ErrTrailingData when some data is left
+func (r *Reader) Finish() error {
+	if len(r.data) > 0 {
+		r.Fail(ErrTrailingData)
+	}
+
+	return r.err
+}
+
+func (r *Reader) next(size int) []uint8 {
+	if r.err != nil {
+		return nil
+	}
+
+	if len(r.data) < size {
+		r.err = ErrUnexpectedEnd
+		return nil
+	}
+
+	value := r.data[:size]
+	r.data = r.data[size:]
+
+	return value
+}
+
+func (r *Reader) Bool() bool {
+	return r.Uint8() != 0
+}
+
+func (r *Reader) Uint8() uint8 {
+	if v := r.next(1); v != nil {
+		return v[0]
+	}
+
+	return 0
+}
+
+func (r *Reader) Uint16() uint16 {
+	if v := r.next(2); v != nil {
+		return binary.LittleEndian.Uint16(v)
+	}
+
+	return 0
+}
+
+func (r *Reader) Uint32() uint32 {
+	if v := r.next(4); v != nil {
+		return binary.LittleEndian.Uint32(v)
+	}
+
+	return 0
+}
+
+func (r *Reader) Uint64() uint64 {
+	if v := r.next(8); v != nil {
+		return binary.LittleEndian.Uint64(v)
+	}
+
+	return 0
+}
+
+func (r *Reader) Int16() int16 {
+	return int16(r.Uint16())
+}
+
+func (r *Reader) Int() int {
+	return int(r.Uint64())
+}
+
+func (r *Reader) Float32() float32 {
+	return math.Float32frombits(r.Uint32())
+}
+
+// Bytes - reads memory block into dst, block has to be of the same size
+func (r *Reader) Bytes(dst []uint8) {
+	size := int(r.Uint32())
+
+	if r.err == nil && size != len(dst) {
+		r.err = ErrSizeMismatch
+		return
+	}
+
+	copy(dst, r.next(size))
+}
@@ -0,0 +1,61 @@
+package state_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core/state"
+	"testing"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	a := assert.New(t)
+	w := new(state.Writer)
+	w.Bool(true)
+	w.Uint8(0x12)
+	w.Uint16(0x3456)
+	w.Uint32(0x789ABCDE)
+	w.Uint64(0x0123456789ABCDEF)
+	w.Int16(-2)
+	w.Int(-100)
+	w.Float32(0.5)
+	w.Bytes([]uint8{1, 2, 3})
+
+	r := state.NewReader(w.GetData())
+	a.True(r.Bool())
+	a.Equal(uint8(0x12), r.Uint8())
+	a.Equal(uint16(0x3456), r.Uint16())
+	a.Equal(uint32(0x789ABCDE), r.Uint32())
+	a.Equal(uint64(0x0123456789ABCDEF), r.Uint64())
+	a.Equal(int16(-2), r.Int16())
+	a.Equal(-100, r.Int())
+	a.Equal(float32(0.5), r.Float32())
+
+	data := make([]uint8, 3)
+	r.Bytes(data)
+	a.Equal([]uint8{1, 2, 3}, data)
+	a.NoError(r.Err())
+}
+
+func TestStateReaderErrors(t *testing.T) {
+	a := assert.New(t)
+	w := new(state.Writer)
+	w.Bytes([]uint8{1, 2, 3})
+	w.Uint8(0x42)
+
+	r := state.NewReader(w.GetData())
+	r.Bytes(make([]uint8, 4))
+	a.Equal(state.ErrSizeMismatch, r.Err(), "Memory of different size should not be restored")
+	a.Equal(uint8(0), r.Uint8(), "Reads after error should return zero")
+
+	r = state.NewReader([]uint8{0x01})
+	a.Equal(uint16(0), r.Uint16())
+	a.Equal(state.ErrUnexpectedEnd, r.Err())
+
+	r = state.NewReader([]uint8{0x01, 0x02})
+	r.Uint8()
+	a.Equal(state.ErrTrailingData, r.Finish(), "Unread data should be reported")
+
+	r = state.NewReader([]uint8{0x01})
+	r.Fail(state.ErrSizeMismatch)
+	r.Fail(state.ErrUnexpectedEnd)
+	a.Equal(state.ErrSizeMismatch, r.Finish(), "First error should be kept")
+}
@@ -0,0 +1,95 @@
+package core
+
+import "github.com/szymonkups/nesgo/core/state"
+
+// Range of potentiometer values reported by Vaus controller shipped with Arkanoid
+const (
+	vausMinPosition = 0x62
+	vausMaxPosition = 0xF2
+)
+
+// Vaus - Arkanoid paddle controller for NES, plugged into second port. Knob position is read serially in bit 3 and
+// fire button in bit 4.
+// https://wiki.nesdev.com/w/index.php/Arkanoid_controller
+type Vaus struct {
+	vausPaddle
+}
+
+// Read - inverted next bit of latched position in bit 3 and button in bit 4
+func (v *Vaus) Read() uint8 {
+	return v.readData()<<3 | v.readButton()<<4
+}
+
+// FamicomVaus - Arkanoid paddle controller for Famicom, plugged into expansion port. Button is read in bit 1 of $4016
+// and position in bit 1 of $4017.
+type FamicomVaus struct {
+	vausPaddle
+}
+
+func (v *FamicomVaus) Read(port int) uint8 {
+	if port == 0 {
+		return v.readButton() << 1
+	}
+
+	return v.readData() << 1
+}
+
+type vausPaddle struct {
+	position uint8
+	button   bool
+
+	// Position is latched into shift register while strobe is high and shifted out MSB first
+	shift  uint8
+	strobe bool
+}
+
+// SetPosition - moves the knob, 0 is the leftmost and 255 the rightmost position
+func (v *vausPaddle) SetPosition(position uint8) {
+	v.position = vausMinPosition + uint8(int(position)*(vausMaxPosition-vausMinPosition)/0xFF)
+}
+
+func (v *vausPaddle) SetButton(pressed bool) {
+	v.button = pressed
+}
+
+func (v *vausPaddle) SetStrobe(strobe bool) {
+	v.strobe = strobe
+
+	if strobe {
+		v.shift = v.position
+	}
+}
+
+func (v *vausPaddle) readButton() uint8 {
+	if v.button {
+		return 0x01
+	}
+
+	return 0x00
+}
+
+// Data line is inverted, so 1 is returned after all 8 bits are read
+func (v *vausPaddle) readData() uint8 {
+	if v.strobe {
+		v.shift = v.position
+	}
+
+	value := ^v.shift >> 7 & 0x01
+
+	if !v.strobe {
+		v.shift <<= 1
+	}
+
+	return value
+}
+
+// Serialize - saves latched position, knob and button are live input and are not a part of the state
+func (v *vausPaddle) Serialize(w *state.Writer) {
+	w.Uint8(v.shift)
+	w.Bool(v.strobe)
+}
+
+func (v *vausPaddle) Deserialize(r *state.Reader) {
+	v.shift = r.Uint8()
+	v.strobe = r.Bool()
+}
@@ -1,11 +1,12 @@
 package core
 
+import "github.com/szymonkups/nesgo/core/state"
+
 type vRam struct {
 	crt          *Cartridge
 	patternTable [0x2000]uint8
 	palette      [0x20]uint8
-	nameTable1   [0x400]uint8
-	nameTable2   [0x400]uint8
+	nameTables   [2][0x400]uint8
 }
 
 func NewVRam(crt *Cartridge) *vRam {
@@ -26,25 +27,7 @@ func (vRam *vRam) Read(_ string, addr uint16, _ bool) (uint8, bool) {
 	}
 
 	if addr >= 0x2000 && addr < 0x3F00 {
-		addr &= 0x0FFF
-
-		if vRam.crt.GetMirroring() == MirroringVertical {
-			if (addr <= 0x03FF) || (addr >= 0x0800 && addr <= 0x0BFF) {
-				return vRam.nameTable1[addr&0x03FF], true
-			}
-
-			if (addr >= 0x0400 && addr <= 0x07FF) || (addr >= 0x0C00 && addr <= 0x0FF) {
-				return vRam.nameTable2[addr&0x03FF], true
-			}
-		} else if vRam.crt.GetMirroring() == MirroringHorizontal {
-			if (addr <= 0x03FF) || (addr >= 0x0400 && addr <= 0x07FF) {
-				return vRam.nameTable1[addr&0x03FF], true
-			}
-
-			if (addr >= 0x0800 && addr <= 0x0BFF) || addr >= 0x0C00 && addr <= 0x0FFF {
-				return vRam.nameTable2[addr&0x03FF], true
-			}
-		}
+		return vRam.getNameTable(addr)[addr&0x03FF], true
 	}
 
 	// Sprite palette.
@@ -74,29 +57,8 @@ func (vRam *vRam) Write(_ string, addr uint16, data uint8, _ bool) bool {
 	}
 
 	if addr >= 0x2000 && addr < 0x3F00 {
-		addr &= 0x0FFF
-
-		if vRam.crt.GetMirroring() == MirroringVertical {
-			if (addr <= 0x03FF) || (addr >= 0x0800 && addr <= 0x0BFF) {
-				vRam.nameTable1[addr&0x03FF] = data
-				return true
-			}
-
-			if (addr >= 0x0400 && addr <= 0x07FF) || (addr >= 0x0C00 && addr <= 0x0FF) {
-				vRam.nameTable2[addr&0x03FF] = data
-				return true
-			}
-		} else if vRam.crt.GetMirroring() == MirroringHorizontal {
-			if (addr <= 0x03FF) || (addr >= 0x0400 && addr <= 0x07FF) {
-				vRam.nameTable1[addr&0x03FF] = data
-				return true
-			}
-
-			if (addr >= 0x0800 && addr <= 0x0BFF) || addr >= 0x0C00 && addr <= 0x0FFF {
-				vRam.nameTable2[addr&0x03FF] = data
-				return true
-			}
-		}
+		vRam.getNameTable(addr)[addr&0x03FF] = data
+		return true
 	}
 
 	// Sprite palette.
@@ -116,6 +78,54 @@ func (vRam *vRam) Write(_ string, addr uint16, data uint8, _ bool) bool {
 	return false
 }
 
+func (vRam *vRam) Serialize(w *state.Writer) {
+	w.Bytes(vRam.patternTable[:])
+	w.Bytes(vRam.palette[:])
+	w.Bytes(vRam.nameTables[0][:])
+	w.Bytes(vRam.nameTables[1][:])
+}
+
+func (vRam *vRam) Deserialize(r *state.Reader) {
+	r.Bytes(vRam.patternTable[:])
+	r.Bytes(vRam.palette[:])
+	r.Bytes(vRam.nameTables[0][:])
+	r.Bytes(vRam.nameTables[1][:])
+}
+
+// Maps $2000-$3EFF address to one of nametables, depending on mirroring set by the cartridge.
+// https://wiki.nesdev.com/w/index.php/Mirroring#Nametable_Mirroring
+func (vRam *vRam) getNameTable(addr uint16) *[0x400]uint8 {
+	// Logical nametable 0-3: $2000, $2400, $2800, $2C00
+	table := (addr >> 10) & 0x03
+
+	// Some mappers (MMC5) select console VRAM page for each nametable separately
+	if page, ok := vRam.crt.getNameTablePage(table); ok {
+		return &vRam.nameTables[page&0x01]
+	}
+
+	switch vRam.crt.GetMirroring() {
+	case MirroringVertical:
+		return &vRam.nameTables[table&0x01]
+
+	case MirroringSingleScreenLow:
+		return &vRam.nameTables[0]
+
+	case MirroringSingleScreenHigh:
+		return &vRam.nameTables[1]
+
+	case MirroringFourScreen:
+		if table >= 2 {
+			return vRam.crt.getFourScreenNameTable(table - 2)
+		}
+
+		return &vRam.nameTables[table]
+
+	default:
+		// Horizontal
+		return &vRam.nameTables[table>>1]
+	}
+}
+
 func mapPalette(addr uint16) uint16 {
 	switch addr {
 	case 0x3F10:
@@ -0,0 +1,78 @@
+package core_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/szymonkups/nesgo/core"
+	"github.com/szymonkups/nesgo/core/state"
+	"testing"
+)
+
+// Writes marker into each of nametables at $2000/$2400/$2800/$2C00 and returns console VRAM page each of them
+// landed in, -1 when it's not in console VRAM
+func getNameTablePages(t *testing.T, crt *core.Cartridge) [4]int {
+	vRam := core.NewVRam(crt)
+
+	for table := uint16(0); table < 4; table++ {
+		vRam.Write("ppu", 0x2000+table*0x400+table, uint8(0xA0+table), false)
+	}
+
+	for table := uint16(0); table < 4; table++ {
+		data, _ := vRam.Read("ppu", 0x2000+table*0x400+table, false)
+		assert.Equal(t, uint8(0xA0+table), data, "Nametable %d should be readable", table)
+	}
+
+	// Saved state holds pattern table, palette and both console VRAM pages
+	w := new(state.Writer)
+	vRam.Serialize(w)
+	r := state.NewReader(w.GetData())
+	r.Bytes(make([]uint8, 0x2000))
+	r.Bytes(make([]uint8, 0x20))
+
+	var pages [2][0x400]uint8
+	r.Bytes(pages[0][:])
+	r.Bytes(pages[1][:])
+	assert.NoError(t, r.Finish())
+
+	result := [4]int{-1, -1, -1, -1}
+	for table := range result {
+		for page := range pages {
+			if pages[page][table] == uint8(0xA0+table) {
+				result[table] = page
+			}
+		}
+	}
+
+	return result
+}
+
+func TestVRamNameTableMirroring(t *testing.T) {
+	a := assert.New(t)
+	crt := new(core.Cartridge)
+
+	loadRom := func(flags6 uint8) {
+		a.NoError(crt.LoadBytes(createTestRom([]uint8{'N', 'E', 'S', 0x1A, 2, 1, flags6, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 0x8000, 0x2000)))
+	}
+
+	loadRom(0x00)
+	a.Equal([4]int{0, 0, 1, 1}, getNameTablePages(t, crt), "Horizontal mirroring")
+
+	loadRom(0x01)
+	a.Equal([4]int{0, 1, 0, 1}, getNameTablePages(t, crt), "Vertical mirroring")
+
+	// Last two nametables are in cartridge RAM
+	loadRom(0x08)
+	a.Equal([4]int{0, 1, -1, -1}, getNameTablePages(t, crt), "Four-screen mirroring")
+
+	// AxROM selects single screen with bit 4
+	loadRom(0x70)
+	crt.Write("cpu", 0x8000, 0x00, false)
+	a.Equal([4]int{0, 0, 0, 0}, getNameTablePages(t, crt), "Single screen mirroring, low page")
+
+	crt.Write("cpu", 0x8000, 0x10, false)
+	a.Equal([4]int{1, 1, 1, 1}, getNameTablePages(t, crt), "Single screen mirroring, high page")
+
+	// MMC5 selects page of each nametable in $5105
+	loadRom(0x50)
+	crt.Write("cpu", 0x5105, 0b00010100, false)
+	a.Equal([4]int{0, 1, 1, 0}, getNameTablePages(t, crt), "Nametable pages selected by mapper")
+}
@@ -0,0 +1,100 @@
+package core
+
+// Photodiode keeps sensing light for this many scan lines after the beam passes aimed pixel
+const zapperLightScanLines = 20
+
+// Pixels around aimed point which are seen by the photodiode
+const zapperLightRadius = 2
+
+// Minimal brightness of a pixel (0-255) which is sensed as light
+const zapperLightThreshold = 0x80
+
+// Zapper - NES light gun, usually plugged into second port. Light is sensed from pixels drawn by PPU around aimed point
+// shortly after the beam draws them, so games flashing targets for a frame work as on CRT.
+// https://wiki.nesdev.com/w/index.php/Zapper
+type Zapper struct {
+	ppu *PPU
+
+	// Aimed screen pixel, negative when gun points away from the screen
+	x, y int
+
+	trigger bool
+
+	// Pixels of current frame above the beam and of previous frame below it
+	light [256 * 240]bool
+}
+
+func NewZapper(ppu *PPU) *Zapper {
+	return &Zapper{ppu: ppu, x: -1, y: -1}
+}
+
+// DrawPixel - has to be called for every pixel drawn by PPU
+func (z *Zapper) DrawPixel(x, y int16, pixel *PPUColor) {
+	if x < 0 || x >= 256 || y < 0 || y >= 240 {
+		return
+	}
+
+	brightness := (int(pixel.R)*299 + int(pixel.G)*587 + int(pixel.B)*114) / 1000
+	z.light[int(y)*256+int(x)] = brightness >= zapperLightThreshold
+}
+
+// Aim - points the gun at screen pixel, coordinates outside of the screen point it away
+func (z *Zapper) Aim(x, y int) {
+	if x < 0 || x >= 256 || y < 0 || y >= 240 {
+		x, y = -1, -1
+	}
+
+	z.x = x
+	z.y = y
+}
+
+func (z *Zapper) SetTrigger(pulled bool) {
+	z.trigger = pulled
+}
+
+// SetStrobe - Zapper has no shift register, its lines are read directly
+func (z *Zapper) SetStrobe(_ bool) {
+}
+
+// Read - light sense in bit 3 (0 when light is detected) and trigger in bit 4 (1 when pulled)
+func (z *Zapper) Read() uint8 {
+	value := uint8(0b00001000)
+
+	if z.isLightSensed() {
+		value = 0
+	}
+
+	if z.trigger {
+		value |= 0b00010000
+	}
+
+	return value
+}
+
+func (z *Zapper) isLightSensed() bool {
+	if z.x < 0 {
+		return false
+	}
+
+	scanLine := int(z.ppu.GetCurrentScanLine())
+	cycle := int(z.ppu.GetCurrentCycle())
+
+	for y := z.y - zapperLightRadius; y <= z.y+zapperLightRadius; y++ {
+		// Only pixels drawn recently are still glowing
+		if y < 0 || y >= 240 || scanLine < y || scanLine >= y+zapperLightScanLines {
+			continue
+		}
+
+		for x := z.x - zapperLightRadius; x <= z.x+zapperLightRadius; x++ {
+			if x < 0 || x >= 256 || (scanLine == y && cycle <= x+1) {
+				continue
+			}
+
+			if z.light[y*256+x] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
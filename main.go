@@ -1,19 +1,37 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/pkg/profile"
 
 	//"github.com/pkg/profile"
+	"github.com/szymonkups/nesgo/audio"
 	"github.com/szymonkups/nesgo/core"
 	"github.com/szymonkups/nesgo/ui"
+	"github.com/szymonkups/nesgo/ui/engine"
 	"github.com/veandco/go-sdl2/sdl"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 const (
 	screenFPS           uint32 = 60
 	screenTicksPerFrame        = 1000 / screenFPS
+
+	audioSampleRate = 48000
+
+	// Number of queued audio samples to keep - around 3 frames of latency
+	audioBufferSize = audioSampleRate / 20
+
+	// Battery backed memory is flushed every 5 seconds, so crash loses only last moments of progress
+	saveFlushFrames = 300
+
+	// Maximal number of rewind snapshots restored per frame
+	maxRewindSpeed = 8
 )
 
 //func main() {
@@ -32,7 +50,72 @@ const (
 //	os.Exit(exitcode)
 //}
 
+// Controller button pressed with a key
+type keyBinding struct {
+	controller int
+	button     core.Button
+}
+
+var keyBindings = map[sdl.Keycode]keyBinding{
+	sdl.K_UP:     {0, core.ButtonUp},
+	sdl.K_DOWN:   {0, core.ButtonDown},
+	sdl.K_LEFT:   {0, core.ButtonLeft},
+	sdl.K_RIGHT:  {0, core.ButtonRight},
+	sdl.K_x:      {0, core.ButtonA},
+	sdl.K_z:      {0, core.ButtonB},
+	sdl.K_SPACE:  {0, core.ButtonSelect},
+	sdl.K_RETURN: {0, core.ButtonStart},
+
+	sdl.K_w: {1, core.ButtonUp},
+	sdl.K_s: {1, core.ButtonDown},
+	sdl.K_a: {1, core.ButtonLeft},
+	sdl.K_d: {1, core.ButtonRight},
+	sdl.K_h: {1, core.ButtonA},
+	sdl.K_g: {1, core.ButtonB},
+	sdl.K_t: {1, core.ButtonSelect},
+	sdl.K_y: {1, core.ButtonStart},
+
+	// Controllers 3 and 4 are used only with four player adapters
+	sdl.K_i: {2, core.ButtonUp},
+	sdl.K_k: {2, core.ButtonDown},
+	sdl.K_j: {2, core.ButtonLeft},
+	sdl.K_l: {2, core.ButtonRight},
+	sdl.K_o: {2, core.ButtonA},
+	sdl.K_u: {2, core.ButtonB},
+	sdl.K_b: {2, core.ButtonSelect},
+	sdl.K_n: {2, core.ButtonStart},
+
+	sdl.K_KP_8: {3, core.ButtonUp},
+	sdl.K_KP_5: {3, core.ButtonDown},
+	sdl.K_KP_4: {3, core.ButtonLeft},
+	sdl.K_KP_6: {3, core.ButtonRight},
+	sdl.K_KP_3: {3, core.ButtonA},
+	sdl.K_KP_1: {3, core.ButtonB},
+	sdl.K_KP_7: {3, core.ButtonSelect},
+	sdl.K_KP_9: {3, core.ButtonStart},
+}
+
+var (
+	romFile  = flag.String("rom", "/home/szymon/Downloads/nes/dk.nes", "ROM file to load")
+	wavFile  = flag.String("wav", "", "record audio to given WAV file, recording can be also toggled with F9")
+	headless = flag.Bool("headless", false, "run without window and audio device, requires -frames")
+	frames   = flag.Int("frames", 0, "stop after given number of frames, 0 means no limit")
+	track    = flag.Int("track", 0, "NSF track to play (starting from 1), 0 means default track from the file")
+	saveDir  = flag.String("saves", "", "directory for battery backed saves, by default they are kept next to the ROM file")
+	input    = flag.String("input", "", "input devices: standard, fourscore, famicom4, zapper, vaus or vaus-famicom, by default taken from NES 2.0 header")
+
+	rewindMemory   = flag.Int("rewind-mb", 64, "memory for rewind buffer in megabytes, 0 disables rewinding")
+	rewindInterval = flag.Int("rewind-interval", 2, "number of frames between rewind snapshots")
+)
+
 func main() {
+	flag.Parse()
+
+	if *headless && *frames <= 0 {
+		fmt.Println("Headless mode requires number of frames to run.")
+		os.Exit(1)
+	}
+
 	// CPU profiling by default
 	defer profile.Start().Stop()
 
@@ -48,35 +131,150 @@ func main() {
 	crt := new(core.Cartridge)
 	// TODO: think about better separation of vRam and crt
 	vRam := core.NewVRam(crt)
-	controller := new(core.Controller)
+
+	// Devices are plugged into ports when cartridge is loaded, as its header can select them
+	ports := new(core.InputPorts)
 
 	// Connect devices to CPU bus.
 	cpuBus.ConnectDevice(crt) // This must be first to allow grab any address and map it as it want s.
 	cpuBus.ConnectDevice(ram)
 	cpuBus.ConnectDevice(ppu)
-	cpuBus.ConnectDevice(controller)
+	cpuBus.ConnectDevice(ports)
 
 	// Connect devices to PPU bus.
 	ppuBus.ConnectDevice(crt) // This must be first to allow grab any address and map it as it wants.
 	ppuBus.ConnectDevice(vRam)
 
-	err := crt.LoadFile("/home/szymon/Downloads/nes/dk.nes")
+	// NSF files are played using synthetic cartridge
+	var nsfHeader *core.NSFHeader
+	var err error
+
+	if strings.EqualFold(filepath.Ext(*romFile), ".nsf") {
+		nsfHeader, err = crt.LoadNSFFile(*romFile)
+	} else {
+		err = crt.LoadFile(*romFile)
+	}
 
 	if err != nil {
-		fmt.Printf("Could not load a file: %s.\n", err)
+		reportLoadError(err)
+		os.Exit(1)
+	}
+
+	if crt.GetRomInfo().IsDiskDude {
+		fmt.Println("ROM header is polluted by \"DiskDude!\" signature, some of its fields are ignored.")
+	}
+
+	inputs, err := connectInputDevices(ports, ppu, getInputDevicesName(crt.GetRomInfo().ExpansionDevice))
+
+	if err != nil {
+		fmt.Printf("%s.\n", err)
 		os.Exit(1)
 	}
 
+	saveFile := getSaveFileName(*romFile)
+	err = crt.LoadSaveRAM(saveFile)
+
+	if err != nil {
+		fmt.Printf("Could not load saved game: %s.\n", err)
+	}
+
+	flushSaveRAM := func() {
+		err := crt.FlushSaveRAM(saveFile)
+
+		if err != nil {
+			fmt.Printf("Could not save game: %s.\n", err)
+		}
+	}
+
+	defer flushSaveRAM()
+
 	cpu := core.NewCPU(cpuBus)
 
+	// Mappers observe PPU address bus and drive CPU IRQ line (MMC3 scan line counter).
+	ppu.ConnectCartridge(crt)
+	crt.ConnectCPU(cpu)
+
+	// DMA and APU need CPU to be able to stall it during transfers.
+	apu := core.NewAPU(cpuBus, cpu)
+	apu.ConnectCartridge(crt)
+	cpuBus.ConnectDevice(core.NewDMA(cpuBus, cpu))
+	cpuBus.ConnectDevice(apu)
+
+	// Full system snapshots, saved to numbered slots
+	stateSaver := core.NewStateSaver(crt, cpu, ram, ppu, vRam, apu, ports)
+	stateSlot := 1
+
+	// Rewinding plays snapshots back while backspace is held, number of snapshots per frame is changed with -/=
+	var rewind *core.RewindBuffer
+	if *rewindMemory > 0 {
+		rewind = core.NewRewindBuffer(stateSaver, *rewindInterval, *rewindMemory*1024*1024)
+	}
+
+	isRewinding := false
+	rewindSpeed := 1
+
+	var nsfPlayer *core.NSFPlayer
+	if nsfHeader != nil {
+		nsfPlayer = core.NewNSFPlayer(cpu, nsfHeader)
+
+		startTrack := int(nsfHeader.StartingSong)
+		if *track > 0 {
+			startTrack = *track
+		}
+
+		if startTrack > 0 {
+			startTrack--
+		}
+
+		nsfPlayer.InitTrack(uint8(startTrack))
+	}
+
 	var gui *ui.UI
-	gui = new(ui.UI)
-	err = gui.Init(cpu, ppu, crt)
+	var audioDevice *engine.AudioDevice
+	sampleRate := audioSampleRate
 
-	if err != nil {
-		panic(err)
+	if !*headless {
+		gui = new(ui.UI)
+		err = gui.Init(cpu, ppu, crt)
+
+		if err != nil {
+			panic(err)
+		}
+
+		if nsfPlayer != nil {
+			gui.ShowNSFInfo(nsfHeader, nsfPlayer)
+		}
+
+		// Emulation runs without sound if audio device cannot be opened
+		audioDevice, err = gui.OpenAudio(audioSampleRate, audioBufferSize)
+
+		if err != nil {
+			fmt.Printf("Could not open audio device: %s.\n", err)
+		} else {
+			sampleRate = audioDevice.GetSampleRate()
+			defer audioDevice.Close()
+		}
+	}
+
+	resampler := audio.NewResampler(apu, core.CPUFrequency, sampleRate)
+	samples := make([]float32, sampleRate)
+
+	var recorder *wavRecorder
+	if *wavFile != "" {
+		recorder, err = startRecording(*wavFile, sampleRate)
+
+		if err != nil {
+			fmt.Printf("Could not start audio recording: %s.\n", err)
+			os.Exit(1)
+		}
 	}
 
+	defer func() {
+		if recorder != nil {
+			recorder.stop()
+		}
+	}()
+
 	cycles := 0
 	running := true
 	stepMode := false
@@ -91,6 +289,13 @@ func main() {
 		ppu.Clock()
 		if cycles%3 == 0 {
 			cpu.Clock()
+			apu.Clock()
+			crt.Clock()
+			resampler.Clock()
+
+			if nsfPlayer != nil {
+				nsfPlayer.Clock()
+			}
 		}
 		cycles++
 
@@ -106,6 +311,10 @@ func main() {
 			return
 		}
 
+		if inputs.zapper != nil {
+			inputs.zapper.DrawPixel(x, y, pixel)
+		}
+
 		offset := (256 * 4 * int32(y)) + int32(x)*4
 		screen[offset+0] = pixel.B
 		screen[offset+1] = pixel.G
@@ -113,6 +322,46 @@ func main() {
 		screen[offset+3] = 0xFF
 	})
 
+	// Runs emulation until frame is complete and passes produced audio to outputs
+	emulatedFrames := 0
+	runFrame := func() {
+		for !ppu.IsFrameComplete {
+			tick()
+		}
+
+		ppu.IsFrameComplete = false
+		emulatedFrames++
+
+		if emulatedFrames%saveFlushFrames == 0 {
+			flushSaveRAM()
+		}
+		n := resampler.ReadSamples(samples)
+
+		if recorder != nil {
+			err := recorder.WriteSamples(samples[:n])
+
+			if err != nil {
+				fmt.Printf("Could not record audio: %s.\n", err)
+			}
+		}
+
+		if audioDevice != nil {
+			err := audioDevice.WriteSamples(samples[:n])
+
+			if err != nil {
+				fmt.Printf("Could not queue audio: %s.\n", err)
+			}
+		}
+	}
+
+	if *headless {
+		for frame := 0; frame < *frames; frame++ {
+			runFrame()
+		}
+
+		return
+	}
+
 	for running {
 		// Timer for FPS cap
 		capTimer.Start()
@@ -129,24 +378,81 @@ func main() {
 			case *sdl.QuitEvent:
 				running = false
 
+			case *sdl.MouseMotionEvent:
+				inputs.moveMouse(gui.GetScreenPosition(t.X, t.Y))
+
+			case *sdl.MouseButtonEvent:
+				if t.Button == sdl.BUTTON_LEFT {
+					inputs.pressMouse(t.State == sdl.PRESSED)
+				}
+
+			case *sdl.WindowEvent:
+				// Zapper pointed away from the window doesn't see any light
+				if t.Event == sdl.WINDOWEVENT_LEAVE && inputs.zapper != nil {
+					inputs.zapper.Aim(-1, -1)
+				}
+
 			case *sdl.KeyboardEvent:
+				binding, isBound := keyBindings[t.Keysym.Sym]
+
 				if t.GetType() == sdl.KEYDOWN {
+					if isBound {
+						inputs.controllers[binding.controller].PressButton(binding.button)
+					}
+
 					switch t.Keysym.Sym {
 					case sdl.K_ESCAPE:
 						running = false
-					//
-					//case sdl.K_RETURN:
-					//	messages <- "step"
-					//
-					case sdl.K_RETURN:
-						controller.PressButton(core.ButtonStart)
-					case sdl.K_SPACE:
-						controller.PressButton(core.ButtonSelect)
-					case sdl.K_UP:
-						controller.PressButton(core.ButtonUp)
-					case sdl.K_DOWN:
-						controller.PressButton(core.ButtonDown)
 
+					case sdl.K_F9:
+						recorder = toggleRecording(recorder, sampleRate)
+
+					case sdl.K_0, sdl.K_1, sdl.K_2, sdl.K_3, sdl.K_4, sdl.K_5, sdl.K_6, sdl.K_7, sdl.K_8, sdl.K_9:
+						stateSlot = int(t.Keysym.Sym - sdl.K_0)
+						fmt.Printf("Save state slot %d selected.\n", stateSlot)
+
+					case sdl.K_BACKSPACE:
+						isRewinding = rewind != nil && nsfPlayer == nil
+
+					case sdl.K_MINUS:
+						if rewindSpeed > 1 {
+							rewindSpeed--
+						}
+						fmt.Printf("Rewind speed %dx.\n", rewindSpeed)
+
+					case sdl.K_EQUALS:
+						if rewindSpeed < maxRewindSpeed {
+							rewindSpeed++
+						}
+						fmt.Printf("Rewind speed %dx.\n", rewindSpeed)
+
+					case sdl.K_F5:
+						if nsfPlayer == nil {
+							saveState(stateSaver, stateSlot)
+						}
+
+					case sdl.K_F7:
+						if nsfPlayer == nil && loadState(stateSaver, stateSlot) {
+							// Keep CPU and PPU clocks aligned the same way as when state was saved
+							cycles = int(ppu.GetTotalCycles())
+						}
+
+					case sdl.K_LEFT:
+						if nsfPlayer != nil {
+							// Computed in int, uint8 would wrap for files with more than 128 tracks
+							total := int(nsfPlayer.GetTotalTracks())
+							nsfPlayer.InitTrack(uint8((int(nsfPlayer.GetTrack()) + total - 1) % total))
+						}
+
+					case sdl.K_RIGHT:
+						if nsfPlayer != nil {
+							total := int(nsfPlayer.GetTotalTracks())
+							nsfPlayer.InitTrack(uint8((int(nsfPlayer.GetTrack()) + 1) % total))
+						}
+						//
+						//case sdl.K_RETURN:
+						//	messages <- "step"
+						//
 						//case sdl.K_p:
 						//	paletteId = (paletteId + 1) % 8
 						//
@@ -156,39 +462,290 @@ func main() {
 				}
 
 				if t.GetType() == sdl.KEYUP {
-					switch t.Keysym.Sym {
-					case sdl.K_SPACE:
-						controller.ReleaseButton(core.ButtonSelect)
-					case sdl.K_RETURN:
-						controller.ReleaseButton(core.ButtonStart)
-					case sdl.K_UP:
-						controller.ReleaseButton(core.ButtonUp)
-					case sdl.K_DOWN:
-						controller.ReleaseButton(core.ButtonDown)
+					if isBound {
+						inputs.controllers[binding.controller].ReleaseButton(binding.button)
+					}
+
+					if t.Keysym.Sym == sdl.K_BACKSPACE {
+						isRewinding = false
 					}
 				}
 			}
 		}
 
+		if isRewinding {
+			rewindFrame(rewind, rewindSpeed)
+
+			// Keep CPU and PPU clocks aligned the same way as when snapshot was taken
+			cycles = int(ppu.GetTotalCycles())
+		}
+
 		if !stepMode {
-			for !ppu.IsFrameComplete {
-				tick()
-			}
+			runFrame()
 
-			ppu.IsFrameComplete = false
+			if rewind != nil && !isRewinding && nsfPlayer == nil {
+				rewind.OnFrame()
+			}
 		}
 
 		gui.DrawScreen(screen)
 		fmt.Println("FPS: ", avgFPS)
 		countedFrames++
 
-		//If frame finished early
-		frameTicks := capTimer.GetTicks()
-		if frameTicks < screenTicksPerFrame {
-			//Wait remaining time
-			//sdl.Delay(screenTicksPerFrame - frameTicks)
+		if *frames > 0 && countedFrames >= uint32(*frames) {
+			running = false
+		}
+
+		if audioDevice != nil {
+			// Sync emulation speed to audio - wait until enough queued samples are played
+			for audioDevice.GetFillLevel() > 1 {
+				sdl.Delay(1)
+			}
+		} else {
+			//If frame finished early
+			frameTicks := capTimer.GetTicks()
+			if frameTicks < screenTicksPerFrame {
+				//Wait remaining time
+				sdl.Delay(screenTicksPerFrame - frameTicks)
+			}
+		}
+	}
+}
+
+// Save file has the same name as the ROM file with .sav extension, it is placed in -saves directory when given
+func getSaveFileName(romFile string) string {
+	fileName := strings.TrimSuffix(romFile, filepath.Ext(romFile)) + ".sav"
+
+	if *saveDir != "" {
+		return filepath.Join(*saveDir, filepath.Base(fileName))
+	}
+
+	return fileName
+}
+
+// Goes back by given number of snapshots, frame emulated afterwards shows the restored moment
+func rewindFrame(rewind *core.RewindBuffer, speed int) {
+	for i := 0; i < speed; i++ {
+		err := rewind.Rewind()
+
+		if err == core.ErrRewindBufferEmpty {
+			return
+		}
+
+		if err != nil {
+			fmt.Printf("Could not rewind: %s.\n", err)
+			return
+		}
+	}
+}
+
+// Save states are kept with battery backed saves, one file per slot
+func getStateFileName(slot int) string {
+	return strings.TrimSuffix(getSaveFileName(*romFile), ".sav") + fmt.Sprintf(".%d.state", slot)
+}
+
+func saveState(saver *core.StateSaver, slot int) {
+	err := saver.SaveFile(getStateFileName(slot))
+
+	if err != nil {
+		fmt.Printf("Could not save state: %s.\n", err)
+		return
+	}
+
+	fmt.Printf("State saved to slot %d.\n", slot)
+}
+
+func loadState(saver *core.StateSaver, slot int) bool {
+	var versionErr *core.SaveStateVersionError
+	err := saver.LoadFile(getStateFileName(slot))
+
+	switch {
+	case err == nil:
+		fmt.Printf("State loaded from slot %d.\n", slot)
+		return true
+	case os.IsNotExist(err):
+		fmt.Printf("Slot %d is empty.\n", slot)
+	case errors.Is(err, core.ErrSaveStateOtherGame):
+		fmt.Printf("State in slot %d belongs to a different game.\n", slot)
+	case errors.As(err, &versionErr):
+		fmt.Printf("State in slot %d was saved by incompatible version of emulator.\n", slot)
+	default:
+		fmt.Printf("Could not load state: %s.\n", err)
+	}
+
+	return false
+}
+
+// Explains why file could not be loaded, invalid files get more specific messages
+func reportLoadError(err error) {
+	var truncated *core.TruncatedRomError
+	var unsupported *core.UnsupportedMapperError
+
+	switch {
+	case errors.Is(err, core.ErrInvalidMagic):
+		fmt.Printf("File %s is not a NES ROM.\n", *romFile)
+	case errors.As(err, &truncated):
+		fmt.Printf("File %s is damaged: %s.\n", *romFile, err)
+	case errors.As(err, &unsupported):
+		fmt.Printf("Game uses mapper %d, which is not supported yet.\n", unsupported.Mapper)
+	default:
+		fmt.Printf("Could not load a file: %s.\n", err)
+	}
+}
+
+// Input devices plugged into ports, mouse drives the Zapper or the Arkanoid paddle
+type inputDevices struct {
+	controllers [4]*core.Controller
+	zapper      *core.Zapper
+	paddle      paddle
+}
+
+type paddle interface {
+	SetPosition(position uint8)
+	SetButton(pressed bool)
+}
+
+// Input devices selected with -input flag, or by default expansion device from ROM header
+func getInputDevicesName(expansionDevice uint8) string {
+	if *input != "" {
+		return *input
+	}
+
+	switch expansionDevice {
+	case core.ExpansionDeviceFourScore:
+		return "fourscore"
+	case core.ExpansionDeviceFamicomFourScore:
+		return "famicom4"
+	case core.ExpansionDeviceZapper:
+		return "zapper"
+	case core.ExpansionDeviceArkanoidNES:
+		return "vaus"
+	case core.ExpansionDeviceArkanoidFamicom:
+		return "vaus-famicom"
+	default:
+		return "standard"
+	}
+}
+
+func connectInputDevices(ports *core.InputPorts, ppu *core.PPU, name string) (*inputDevices, error) {
+	inputs := new(inputDevices)
+	for i := range inputs.controllers {
+		inputs.controllers[i] = new(core.Controller)
+	}
+
+	// Standard controllers are plugged into both ports unless a device takes one of them
+	ports.Connect(0, inputs.controllers[0])
+	ports.Connect(1, inputs.controllers[1])
+
+	switch name {
+	case "standard":
+	case "fourscore":
+		fourScore := core.NewFourScore(inputs.controllers)
+		ports.Connect(0, fourScore.GetPort(0))
+		ports.Connect(1, fourScore.GetPort(1))
+	case "famicom4":
+		ports.ConnectExpansion(core.NewFamicomFourPlayers(inputs.controllers[2], inputs.controllers[3]))
+	case "zapper":
+		inputs.zapper = core.NewZapper(ppu)
+		ports.Connect(1, inputs.zapper)
+	case "vaus":
+		vaus := new(core.Vaus)
+		inputs.paddle = vaus
+		ports.Connect(1, vaus)
+	case "vaus-famicom":
+		vaus := new(core.FamicomVaus)
+		inputs.paddle = vaus
+		ports.ConnectExpansion(vaus)
+	default:
+		return nil, fmt.Errorf("unknown input devices %q", name)
+	}
+
+	return inputs, nil
+}
+
+// Mouse position in screen pixels aims the Zapper and turns the paddle knob
+func (inputs *inputDevices) moveMouse(x, y int) {
+	if inputs.zapper != nil {
+		inputs.zapper.Aim(x, y)
+	}
+
+	if inputs.paddle != nil {
+		if x < 0 {
+			x = 0
+		} else if x > 0xFF {
+			x = 0xFF
 		}
+
+		inputs.paddle.SetPosition(uint8(x))
+	}
+}
+
+// Left mouse button pulls the Zapper trigger and presses the paddle button
+func (inputs *inputDevices) pressMouse(pressed bool) {
+	if inputs.zapper != nil {
+		inputs.zapper.SetTrigger(pressed)
+	}
+
+	if inputs.paddle != nil {
+		inputs.paddle.SetButton(pressed)
+	}
+}
+
+// wavRecorder - records produced audio to WAV file
+type wavRecorder struct {
+	file *os.File
+	*audio.WAVWriter
+}
+
+func startRecording(fileName string, sampleRate int) (*wavRecorder, error) {
+	f, err := os.Create(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	wav, err := audio.NewWAVWriter(f, sampleRate)
+
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	fmt.Printf("Recording audio to %s.\n", fileName)
+	return &wavRecorder{file: f, WAVWriter: wav}, nil
+}
+
+func (r *wavRecorder) stop() {
+	err := r.Close()
+
+	if err == nil {
+		err = r.file.Close()
 	}
+
+	if err != nil {
+		fmt.Printf("Could not finish audio recording: %s.\n", err)
+		return
+	}
+
+	fmt.Printf("Audio recorded to %s.\n", r.file.Name())
+}
+
+// Starts recording to new timestamped file or stops current recording
+func toggleRecording(recorder *wavRecorder, sampleRate int) *wavRecorder {
+	if recorder != nil {
+		recorder.stop()
+		return nil
+	}
+
+	fileName := fmt.Sprintf("nesgo-%s.wav", time.Now().Format("20060102-150405"))
+	recorder, err := startRecording(fileName, sampleRate)
+
+	if err != nil {
+		fmt.Printf("Could not start audio recording: %s.\n", err)
+		return nil
+	}
+
+	return recorder
 }
 
 // Kudos to https://lazyfoo.net/tutorials/SDL/23_advanced_timers/index.php
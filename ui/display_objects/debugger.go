@@ -14,6 +14,11 @@ type Debugger struct {
 	CRT *core.Cartridge
 
 	paletteId uint8
+	children  []engine.Displayable
+}
+
+func (d *Debugger) AddChild(child engine.Displayable) {
+	d.children = append(d.children, child)
 }
 
 func (d *Debugger) SetPaletteId(newId uint8) {
@@ -103,7 +108,7 @@ func getBit(i uint8, n uint8) uint8 {
 }
 
 func (d *Debugger) GetChildren() []engine.Displayable {
-	return nil
+	return d.children
 }
 
 func drawRegister16(e *engine.UIEngine, name string, value uint16, x, y int32) {
@@ -0,0 +1,41 @@
+package display_objects
+
+import (
+	"fmt"
+	"github.com/szymonkups/nesgo/core"
+	"github.com/szymonkups/nesgo/ui/engine"
+	"strings"
+)
+
+// NSFInfo - shows currently played NSF tune
+type NSFInfo struct {
+	Header *core.NSFHeader
+	Player *core.NSFPlayer
+}
+
+func (n *NSFInfo) Draw(e *engine.UIEngine) error {
+	lines := []string{
+		n.Header.GetSongName(),
+		n.Header.GetArtist(),
+		n.Header.GetCopyright(),
+		"",
+		fmt.Sprintf("TRACK %d OF %d", n.Player.GetTrack()+1, n.Player.GetTotalTracks()),
+		"",
+		"LEFT, RIGHT - CHANGE TRACK",
+	}
+
+	// Font has only upper case glyphs
+	for i, line := range lines {
+		err := e.DrawText(strings.ToUpper(line), 16, 32+int32(i)*12, 0xFF, 0xFF, 0xFF, 0xFF)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (n *NSFInfo) GetChildren() []engine.Displayable {
+	return nil
+}
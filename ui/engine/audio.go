@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// AudioDevice - SDL audio output fed with queued mono float samples
+type AudioDevice struct {
+	device     sdl.AudioDeviceID
+	sampleRate int
+
+	// Number of queued samples considered as full buffer
+	bufferSize int
+	data       []byte
+}
+
+// OpenAudio - opens default audio device. Obtained sample rate may differ from requested one.
+func (ui *UIEngine) OpenAudio(sampleRate int, bufferSize int) (*AudioDevice, error) {
+	desired := sdl.AudioSpec{
+		Freq:     int32(sampleRate),
+		Format:   sdl.AUDIO_F32,
+		Channels: 1,
+		Samples:  1024,
+	}
+	obtained := sdl.AudioSpec{}
+
+	device, err := sdl.OpenAudioDevice("", false, &desired, &obtained, sdl.AUDIO_ALLOW_FREQUENCY_CHANGE)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sdl.PauseAudioDevice(device, false)
+
+	return &AudioDevice{
+		device:     device,
+		sampleRate: int(obtained.Freq),
+		bufferSize: bufferSize,
+	}, nil
+}
+
+func (a *AudioDevice) GetSampleRate() int {
+	return a.sampleRate
+}
+
+// WriteSamples - queues samples for playback
+func (a *AudioDevice) WriteSamples(samples []float32) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	a.data = a.data[:0]
+	for _, s := range samples {
+		a.data = append(a.data, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(a.data[len(a.data)-4:], math.Float32bits(s))
+	}
+
+	return sdl.QueueAudio(a.device, a.data)
+}
+
+// GetQueuedSamples - number of samples waiting for playback
+func (a *AudioDevice) GetQueuedSamples() int {
+	// 4 bytes per single float sample
+	return int(sdl.GetQueuedAudioSize(a.device)) / 4
+}
+
+// GetFillLevel - queued samples relative to buffer size, values above 1.0 mean that emulation runs too fast
+func (a *AudioDevice) GetFillLevel() float32 {
+	return float32(a.GetQueuedSamples()) / float32(a.bufferSize)
+}
+
+func (a *AudioDevice) Close() {
+	sdl.CloseAudioDevice(a.device)
+}
@@ -51,6 +51,21 @@ func (ui *UI) Init(cpu *core.CPU, ppu *core.PPU, crt *core.Cartridge) error {
 	return nil
 }
 
+// ShowNSFInfo - displays information about played NSF tune
+func (ui *UI) ShowNSFInfo(header *core.NSFHeader, player *core.NSFPlayer) {
+	ui.debugger.AddChild(&display_objects.NSFInfo{Header: header, Player: player})
+}
+
+// OpenAudio - opens audio output, buffer size is number of queued samples to keep
+func (ui *UI) OpenAudio(sampleRate int, bufferSize int) (*engine.AudioDevice, error) {
+	return ui.engine.OpenAudio(sampleRate, bufferSize)
+}
+
+// GetScreenPosition - converts window coordinates, e.g. of mouse events, to NES screen pixel
+func (ui *UI) GetScreenPosition(x, y int32) (int, int) {
+	return int(x) * 256 / windowWidth, int(y) * 240 / windowHeight
+}
+
 func (ui *UI) Destroy() {
 	ui.engine.Destroy()
 